@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"dpb-cv02-04/pkg/redisconf"
+	"dpb-cv02-04/pkg/transcript"
+)
+
+// Protocol is the RESP protocol version of redis-cli, so the replies are printed the same.
+const Protocol = 2
+
+func main() {
+	allowFlush := flag.Bool("allow-flush", false, "allow FLUSHALL and FLUSHDB, which delete the keys of everyone using the server")
+	redisConfig, err := redisconf.FromEnv()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	redisConfig.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] transcript...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	redisConfig.Protocol = Protocol
+	client, err := redisConfig.NewClient()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	defer client.Close()
+
+	runner := transcript.NewRunner(client, os.Stdout)
+	runner.AllowFlush = *allowFlush
+	failed := 0
+	for _, name := range flag.Args() {
+		result, err := runFile(ctx, runner, name)
+		if err != nil {
+			slog.Error("Failed to run transcript", "file", name, "error", err)
+			panic(err.Error())
+		}
+		slog.Info("Transcript finished", "file", name, "commands", result.Commands, "checked", result.Checked, "failed", result.Failed)
+		failed += result.Failed
+	}
+	if failed > 0 {
+		slog.Error("Unexpected replies", "failed", failed)
+		os.Exit(1)
+	}
+}
+
+// runFile parses the transcript in the file and runs it.
+func runFile(ctx context.Context, runner *transcript.Runner, name string) (transcript.Result, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return transcript.Result{}, err
+	}
+	defer file.Close()
+
+	commands, err := transcript.Parse(file)
+	if err != nil {
+		return transcript.Result{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return runner.Run(ctx, name, commands)
+}
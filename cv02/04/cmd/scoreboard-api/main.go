@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"dpb-cv02-04/pkg/api"
+	"dpb-cv02-04/pkg/audit"
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/notify"
+	"dpb-cv02-04/pkg/ratelimit"
+	"dpb-cv02-04/pkg/redisconf"
+)
+
+const (
+	DefaultListenAddr = ":8080"
+	// DefaultKey contains a hash tag, so the keys derived from it stay in one cluster slot.
+	DefaultKey = "{scoreboard}"
+	// RateLimitKey is the base of the keys of the submission rate limits.
+	RateLimitKey = "{scoreboard-ratelimit}"
+
+	MinScore = 0
+	MaxScore = 999
+)
+
+func main() {
+	listenAddr := flag.String("listen", DefaultListenAddr, "address the HTTP server listens on")
+	key := flag.String("key", DefaultKey, "key of the scoreboard sorted set")
+	notifyChannel := flag.String("notify-channel", "", "publish the rank changes to this redis pub/sub channel, disabled if empty")
+	auditMaxLen := flag.Int64("audit-maxlen", 0, "record the score changes in an audit stream trimmed to about this many records, disabled if 0")
+	memberRate := flag.String("rate-member", "", "limit of the submissions of each member, e.g. 10/1m, unlimited if empty")
+	globalRate := flag.String("rate-global", "", "limit of the submissions of all members together, e.g. 1000/s, unlimited if empty")
+	redisConfig, err := redisconf.FromEnv()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	redisConfig.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	var limits ratelimit.Limits
+	if limits.PerMember, err = ratelimit.ParseLimit(*memberRate); err != nil {
+		panic(err.Error())
+	}
+	if limits.Global, err = ratelimit.ParseLimit(*globalRate); err != nil {
+		panic(err.Error())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// create client and check connectivity
+	client, err := redisConfig.NewClient()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	if err := client.Ping(ctx).Err(); err != nil {
+		slog.Error("Failed to connect to redis", "error", err)
+		panic(err.Error())
+	}
+	defer client.Close()
+
+	store := leaderboard.NewRedisStore(client)
+	if err := store.LoadScripts(ctx); err != nil {
+		slog.Error("Failed to load redis scripts", "error", err)
+		panic(err.Error())
+	}
+	scoreboard := leaderboard.New(store, *key)
+	scoreboard.Bounds = &leaderboard.Bounds{Min: MinScore, Max: MaxScore}
+
+	var updater leaderboard.Updater = scoreboard
+	if *auditMaxLen > 0 {
+		auditLog := audit.NewLog(client, audit.StreamKey(*key))
+		auditLog.MaxLen = *auditMaxLen
+		// every change of the scoreboard is recorded, also the changes made outside of the API
+		scoreboard.Changes = auditLog.ChangeStream()
+	}
+	if *notifyChannel != "" {
+		updater = notify.NewNotifier(updater, scoreboard, notify.NewRedisPublisher(client, *notifyChannel))
+	}
+	// the limits are checked first, so the rejected submissions are not recorded
+	if limits.PerMember.Enabled() || limits.Global.Enabled() {
+		limiter := ratelimit.NewRedisLimiter(client, RateLimitKey, limits)
+		updater = ratelimit.NewGuard(updater, limiter)
+	}
+	handler := api.NewHandler(scoreboard)
+	handler.Updater = updater
+
+	server := &http.Server{
+		Addr:              *listenAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to shut down the server", "error", err)
+		}
+	}()
+
+	slog.Info("Serving the scoreboard", "addr", *listenAddr, "key", *key)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Failed to serve", "error", err)
+		panic(err.Error())
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/redisconf"
+	"dpb-cv02-04/pkg/season"
+)
+
+const DefaultKey = "{scoreboard}"
+
+func main() {
+	key := flag.String("key", DefaultKey, "key of the scoreboard sorted set")
+	n := flag.Int64("n", 10, "number of entries printed by top")
+	pointsList := flag.String("points", "100,50,25", "comma separated points awarded by carry-over to the final ranks 1, 2, ...")
+	redisConfig, err := redisconf.FromEnv()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	redisConfig.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] start ID [NAME] | current | list | top ID | carry-over ID\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if !validArgs(flag.Args()) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	client, err := redisConfig.NewClient()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	defer client.Close()
+	seasons := season.New(client, leaderboard.New(leaderboard.NewRedisStore(client), *key))
+
+	switch flag.Arg(0) {
+	case "start":
+		ended, err := seasons.Start(ctx, flag.Arg(1), flag.Arg(2))
+		if err != nil {
+			slog.Error("Failed to start the season", "key", *key, "season", flag.Arg(1), "error", err)
+			panic(err.Error())
+		}
+		if ended.ID != "" {
+			slog.Info("Archived the season", "season", ended.ID, "archive", ended.Key, "members", ended.Members)
+		}
+		slog.Info("Started the season", "key", *key, "season", flag.Arg(1))
+	case "current":
+		current, err := seasons.Current(ctx)
+		if err != nil {
+			panic(err.Error())
+		}
+		printSeason(current)
+	case "list":
+		all, err := seasons.List(ctx)
+		if err != nil {
+			panic(err.Error())
+		}
+		for _, s := range all {
+			printSeason(s)
+		}
+	case "top":
+		top, err := seasons.Top(ctx, flag.Arg(1), *n)
+		if err != nil {
+			panic(err.Error())
+		}
+		for _, entry := range top {
+			fmt.Printf("%v. %v (%v)\n", entry.Rank, entry.Member, entry.Score)
+		}
+	case "carry-over":
+		points, err := parsePoints(*pointsList)
+		if err != nil {
+			panic(err.Error())
+		}
+		awarded, err := seasons.CarryOver(ctx, flag.Arg(1), points)
+		if err != nil {
+			slog.Error("Failed to carry over the season", "key", *key, "season", flag.Arg(1), "error", err)
+			panic(err.Error())
+		}
+		slog.Info("Carried over the season", "key", *key, "season", flag.Arg(1), "members", awarded)
+	}
+}
+
+// validArgs reports whether the arguments are a command with the right number of arguments.
+func validArgs(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "start":
+		return len(args) == 2 || len(args) == 3
+	case "current", "list":
+		return len(args) == 1
+	case "top", "carry-over":
+		return len(args) == 2
+	default:
+		return false
+	}
+}
+
+// parsePoints parses the comma separated points of the final ranks.
+func parsePoints(list string) ([]float64, error) {
+	var points []float64
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid points: %q", field)
+		}
+		points = append(points, value)
+	}
+	return points, nil
+}
+
+// printSeason prints a single line describing the season.
+func printSeason(s season.Season) {
+	ended := "current"
+	if !s.Active() {
+		ended = fmt.Sprintf("ended %v, %v members", s.Ended.Format(time.RFC3339), s.Members)
+		if s.CarriedOver {
+			ended += ", carried over"
+		}
+	}
+	fmt.Printf("%v %q started %v, %v\n", s.ID, s.Name, s.Started.Format(time.RFC3339), ended)
+}
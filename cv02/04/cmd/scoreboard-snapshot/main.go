@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/redisconf"
+	"dpb-cv02-04/pkg/snapshot"
+)
+
+const DefaultKey = "{scoreboard}"
+
+func main() {
+	key := flag.String("key", DefaultKey, "key of the scoreboard sorted set")
+	formatName := flag.String("format", snapshot.JSONLines.String(), "format of the snapshot: jsonl or csv")
+	modeName := flag.String("mode", snapshot.Replace.String(), "import mode: replace, max or sum")
+	file := flag.String("file", "-", "snapshot file, - for stdout or stdin")
+	redisConfig, err := redisconf.FromEnv()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	redisConfig.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] export|import\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || (flag.Arg(0) != "export" && flag.Arg(0) != "import") {
+		flag.Usage()
+		os.Exit(2)
+	}
+	format, err := snapshot.ParseFormat(*formatName)
+	if err != nil {
+		panic(err.Error())
+	}
+	mode, err := snapshot.ParseMode(*modeName)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	ctx := context.Background()
+	client, err := redisConfig.NewClient()
+	if err != nil {
+		slog.Error("Invalid redis configuration", "error", err)
+		panic(err.Error())
+	}
+	defer client.Close()
+	scoreboard := leaderboard.New(leaderboard.NewRedisStore(client), *key)
+
+	if flag.Arg(0) == "export" {
+		var w io.Writer = os.Stdout
+		if *file != "-" {
+			f, err := os.Create(*file)
+			if err != nil {
+				panic(err.Error())
+			}
+			defer f.Close()
+			w = f
+		}
+		exporter := &snapshot.Exporter{}
+		n, err := exporter.Export(ctx, scoreboard, w, format)
+		if err != nil {
+			slog.Error("Failed to export the scoreboard", "key", *key, "error", err)
+			panic(err.Error())
+		}
+		slog.Info("Exported the scoreboard", "key", *key, "entries", n)
+		return
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			panic(err.Error())
+		}
+		defer f.Close()
+		r = f
+	}
+	importer := &snapshot.Importer{}
+	n, err := importer.Import(ctx, scoreboard, r, format, mode)
+	if err != nil {
+		slog.Error("Failed to import the scoreboard", "key", *key, "error", err)
+		panic(err.Error())
+	}
+	slog.Info("Imported the scoreboard", "key", *key, "mode", mode, "entries", n)
+}
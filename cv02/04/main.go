@@ -2,14 +2,24 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
+
+	"dpb-cv02-04/pkg/keyspace"
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/redisconf"
 
 	"github.com/redis/go-redis/v9"
 )
 
 const ScoreboardKey = "scoreboard"
 
+// DefaultPrefix is the base of the key prefix unique to every run.
+const DefaultPrefix = "dpb-cv02-04"
+
 const MinScore = 0
 const MaxScore = 999
 
@@ -17,66 +27,94 @@ var MinScoreStr = strconv.Itoa(MinScore)
 var MaxScoreStr = strconv.Itoa(MaxScore)
 
 func main() {
+	prefix := flag.String("prefix", "", "prefix of all keys of this run, unique to the run if empty")
+	dryRun := flag.Bool("dry-run", false, "only list the keys the cleanup would delete")
+	redisConfig, err := redisconf.FromEnv()
+	if err != nil {
+		panic(err.Error())
+	}
+	redisConfig.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
 	ctx := context.Background()
+	// all keys of the run are in its own namespace, so the cleanup does not touch any other keys
+	ns := keyspace.NewRun(DefaultPrefix)
+	if *prefix != "" {
+		ns = keyspace.New(*prefix)
+	}
+	// the hash tag keeps all scoreboard keys in one cluster slot
+	scoreboardKey := ns.Key(keyspace.HashTag(ScoreboardKey))
 
 	// create client
-	client := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
+	client, err := redisConfig.NewClient()
+	if err != nil {
+		panic(err.Error())
+	}
 	// check connectivity
 	if _, err := client.Ping(ctx).Result(); err != nil {
 		panic(err.Error())
 	}
-	// deferred function that will clear redis and close connection
+	// deferred function that will delete the keys of the run and close connection
 	defer func() {
-		if _, err := client.FlushAll(ctx).Result(); err != nil {
+		cleaner := keyspace.NewCleaner(client)
+		cleaner.DryRun = *dryRun
+		keys, err := cleaner.Clean(ctx, ns)
+		if err != nil {
 			panic(err.Error())
 		}
+		if *dryRun {
+			fmt.Printf("Keys that would be deleted (%v):\n", len(keys))
+			for _, key := range keys {
+				fmt.Printf("- %v\n", key)
+			}
+		}
 		if err := client.Close(); err != nil {
 			panic(err.Error())
 		}
 	}()
 
-	// add Alfred with score 888
-	client.ZAdd(ctx, ScoreboardKey, redis.Z{
-		Score:  888,
-		Member: "Alfred",
-	})
+	store := leaderboard.NewRedisStore(client)
+	scoreboard := leaderboard.New(store, scoreboardKey)
+	scoreboard.Bounds = &leaderboard.Bounds{Min: MinScore, Max: MaxScore}
+	// the all-time leaderboard of the periodic scoreboard is the scoreboard itself
+	periodicScoreboard := leaderboard.NewPeriodic(store, scoreboardKey)
+
+	// add Alfred with score 888 to the all-time, daily and weekly leaderboards
+	if err := periodicScoreboard.Submit(ctx, "Alfred", 888); err != nil {
+		panic(err.Error())
+	}
 
 	// add other ten players
-	players := []redis.Z{
-		{Score: 123, Member: "Tom"},
-		{Score: 111, Member: "Bob"},
-		{Score: 222, Member: "Alice"},
-		{Score: 333, Member: "Theresa"},
-		{Score: 444, Member: "Jim"},
-		{Score: 555, Member: "Tim"},
-		{Score: 666, Member: "Martin"},
-		{Score: 777, Member: "Joanna"},
-		{Score: 889, Member: "Garfield"},
-		{Score: 999, Member: "Maurice"},
-	}
-	client.ZAdd(ctx, ScoreboardKey, players...)
+	players := []leaderboard.Entry{
+		{Member: "Tom", Score: 123},
+		{Member: "Bob", Score: 111},
+		{Member: "Alice", Score: 222},
+		{Member: "Theresa", Score: 333},
+		{Member: "Jim", Score: 444},
+		{Member: "Tim", Score: 555},
+		{Member: "Martin", Score: 666},
+		{Member: "Joanna", Score: 777},
+		{Member: "Garfield", Score: 889},
+		{Member: "Maurice", Score: 999},
+	}
+	for _, player := range players {
+		if err := periodicScoreboard.Submit(ctx, player.Member, player.Score); err != nil {
+			panic(err.Error())
+		}
+	}
 
 	// top 3 by score
-	topThree, err := client.ZRevRangeByScore(ctx, ScoreboardKey, &redis.ZRangeBy{
-		Min:    MinScoreStr,
-		Max:    MaxScoreStr,
-		Offset: 0,
-		Count:  3,
-	}).Result()
+	topThree, err := scoreboard.Top(ctx, 3)
 	if err != nil {
 		panic(err.Error())
 	}
 	fmt.Println("Top 3:")
-	for pos, player := range topThree {
-		fmt.Printf("%v. %v\n", pos+1, player)
+	for _, player := range topThree {
+		fmt.Printf("%v. %v\n", player.Rank, player.Member)
 	}
 
 	// worst score
-	worstPlayers, err := client.ZRangeByScoreWithScores(ctx, ScoreboardKey, &redis.ZRangeBy{
+	worstPlayers, err := client.ZRangeByScoreWithScores(ctx, scoreboardKey, &redis.ZRangeBy{
 		Min:    MinScoreStr,
 		Max:    MaxScoreStr,
 		Offset: 0,
@@ -89,14 +127,14 @@ func main() {
 	fmt.Printf("The worst score is: %v\n", worstPlayer.Score)
 
 	// number of players with score < 100 (noobies)
-	numPlayersSub100, err := client.ZCount(ctx, ScoreboardKey, MinScoreStr, "100").Result()
+	numPlayersSub100, err := scoreboard.CountBetween(ctx, MinScore, 100)
 	if err != nil {
 		panic(err.Error())
 	}
 	fmt.Printf("Number of noobies: %v\n", numPlayersSub100)
 
 	// players with score > 850 (pros)
-	playersOver850, err := client.ZRangeByScore(ctx, ScoreboardKey, &redis.ZRangeBy{
+	playersOver850, err := client.ZRangeByScore(ctx, scoreboardKey, &redis.ZRangeBy{
 		Min: "850",
 		Max: MaxScoreStr,
 	}).Result()
@@ -109,20 +147,70 @@ func main() {
 	}
 
 	// get Alfred's position
-	alfredsPos, err := client.ZRevRank(ctx, ScoreboardKey, "Alfred").Result()
+	alfred, err := scoreboard.Rank(ctx, "Alfred")
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("Alfred's position now: %v\n", alfred.Rank)
+
+	// players around Alfred
+	aroundAlfred, err := scoreboard.Around(ctx, "Alfred", 1)
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Println("Players around Alfred:")
+	for _, player := range aroundAlfred {
+		fmt.Printf("%v. %v (%v)\n", player.Rank, player.Member, player.Score)
+	}
+
+	// Alfred's percentile
+	alfredsStanding, err := scoreboard.Percentile(ctx, "Alfred")
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("Alfred is in the top %.0f%%\n", math.Ceil(alfredsStanding.TopPercent()))
+	median, err := scoreboard.ScoreAtPercentile(ctx, 50)
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("The median score is: %v\n", median)
+
+	// score distribution by hundreds
+	buckets, err := scoreboard.Histogram(ctx, leaderboard.LinearBoundaries(100, 100, 9))
 	if err != nil {
 		panic(err.Error())
 	}
-	fmt.Printf("Alfred's position now: %v\n", alfredsPos+1)
+	fmt.Println("Score distribution:")
+	for _, bucket := range buckets {
+		fmt.Printf("%6v - %-6v %v\n", bucket.Min, bucket.Max, strings.Repeat("#", int(bucket.Count)))
+	}
 
-	// increment Alfred's score and check position
-	if _, err = client.ZIncrBy(ctx, ScoreboardKey, 12, "Alfred").Result(); err != nil {
+	// increment Alfred's score in all periods and check position
+	if err = periodicScoreboard.Increment(ctx, "Alfred", 12); err != nil {
 		panic(err.Error())
 	}
 	fmt.Println("Alfred played some games...")
-	alfredsPos, err = client.ZRevRank(ctx, ScoreboardKey, "Alfred").Result()
+	alfred, err = scoreboard.Rank(ctx, "Alfred")
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("Alfred's position now: %v\n", alfred.Rank)
+
+	// today's best
+	todaysBest, err := periodicScoreboard.Current(leaderboard.Daily).Top(ctx, 3)
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Println("Today's best:")
+	for _, player := range todaysBest {
+		fmt.Printf("%v. %v (%v)\n", player.Rank, player.Member, player.Score)
+	}
+
+	// submit a score over the maximum, it is capped and the best score is kept
+	garfieldsUpdate, err := scoreboard.Update(ctx, "Garfield", 1200, leaderboard.UpdateBest)
 	if err != nil {
 		panic(err.Error())
 	}
-	fmt.Printf("Alfred's position now: %v\n", alfredsPos+1)
+	fmt.Printf("Garfield submitted 1200, his score is now %v and he moved from position %v to %v\n",
+		garfieldsUpdate.New.Score, garfieldsUpdate.Old.Rank, garfieldsUpdate.New.Rank)
 }
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/ratelimit"
+)
+
+const (
+	// DefaultLimit is the number of entries returned by GET /top if no limit is given.
+	DefaultLimit = 10
+	// MaxLimit is the maximum number of entries returned by GET /top.
+	MaxLimit = 100
+	// maxBodySize is the maximum size of a request body.
+	maxBodySize = 1 << 16
+)
+
+// SubmitRequest is the body of POST /scores.
+// Mode is one of "set", "incr" and "best", "set" if empty.
+type SubmitRequest struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+	Mode   string  `json:"mode,omitempty"`
+}
+
+// PageResponse is the body of the response of GET /top.
+type PageResponse struct {
+	Entries []leaderboard.Entry `json:"entries"`
+	Offset  int64               `json:"offset"`
+	Limit   int64               `json:"limit"`
+	Total   int64               `json:"total"`
+}
+
+// CountResponse is the body of the response of GET /count.
+// Min and Max are omitted if the range is not bounded from that side.
+type CountResponse struct {
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// ErrorResponse is the body of the error responses.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves the leaderboard over HTTP as a JSON API:
+//
+//	POST /scores                 submits a score, see SubmitRequest, 400 if the score is invalid,
+//	                             422 if it is below the minimum, 429 with Retry-After if rate limited
+//	GET  /top?offset=0&limit=10  returns a page of the best members
+//	GET  /players/{member}/rank  returns the rank of the member
+//	GET  /count?min=0&max=100    returns the number of members with a score in the range
+type Handler struct {
+	// Updater applies the submitted scores, e.g. a recorder of the score changes or a rate limiting guard,
+	// the leaderboard if nil.
+	Updater leaderboard.Updater
+
+	lb  *leaderboard.Leaderboard
+	mux *http.ServeMux
+}
+
+// Interface guard for http.Handler.
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler returns a new Handler serving the leaderboard.
+func NewHandler(lb *leaderboard.Leaderboard) *Handler {
+	h := &Handler{
+		lb:  lb,
+		mux: http.NewServeMux(),
+	}
+	h.mux.HandleFunc("/scores", h.handleScores)
+	h.mux.HandleFunc("/top", h.handleTop)
+	h.mux.HandleFunc("/players/", h.handlePlayers)
+	h.mux.HandleFunc("/count", h.handleCount)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleScores handles POST /scores.
+func (h *Handler) handleScores(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req SubmitRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Member == "" {
+		writeError(w, http.StatusBadRequest, errors.New("member must not be empty"))
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = leaderboard.UpdateSet.String()
+	}
+	mode, err := leaderboard.ParseUpdateMode(req.Mode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := h.updater().Update(r.Context(), req.Member, req.Score, mode)
+	if errors.Is(err, leaderboard.ErrInvalidScore) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if errors.Is(err, leaderboard.ErrScoreBelowMin) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	var limitErr *ratelimit.LimitError
+	if errors.As(err, &limitErr) {
+		// Retry-After is in whole seconds, rounded up so the retry is not rejected again
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(limitErr.RetryAfter.Seconds())), 10))
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleTop handles GET /top.
+func (h *Handler) handleTop(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	offset, err := intParam(query, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid offset: %q", query.Get("offset")))
+		return
+	}
+	limit, err := intParam(query, "limit", DefaultLimit)
+	if err != nil || limit < 1 || limit > MaxLimit {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %q must be in the range [1, %d]", query.Get("limit"), MaxLimit))
+		return
+	}
+
+	entries, err := h.lb.Page(r.Context(), offset, limit)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	total, err := h.lb.Size(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PageResponse{
+		Entries: entries,
+		Offset:  offset,
+		Limit:   limit,
+		Total:   total,
+	})
+}
+
+// handlePlayers handles GET /players/{member}/rank.
+func (h *Handler) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	// the member is taken from the escaped path, so it may contain an escaped slash
+	escapedMember, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.EscapedPath(), "/players/"), "/rank")
+	if !ok || escapedMember == "" || strings.Contains(escapedMember, "/") {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	member, err := url.PathUnescape(escapedMember)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid member: %w", err))
+		return
+	}
+
+	entry, err := h.lb.Rank(r.Context(), member)
+	if errors.Is(err, leaderboard.ErrMemberNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("player %q not found", member))
+		return
+	}
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// handleCount handles GET /count.
+func (h *Handler) handleCount(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	query := r.URL.Query()
+	min, err := floatParam(query, "min", math.Inf(-1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid min: %q", query.Get("min")))
+		return
+	}
+	max, err := floatParam(query, "max", math.Inf(1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid max: %q", query.Get("max")))
+		return
+	}
+	if min > max {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid score range: [%v, %v]", min, max))
+		return
+	}
+
+	count, err := h.lb.CountBetween(r.Context(), min, max)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	// infinite bounds cannot be encoded in JSON, so they are omitted
+	response := CountResponse{Count: count}
+	if !math.IsInf(min, 0) {
+		response.Min = &min
+	}
+	if !math.IsInf(max, 0) {
+		response.Max = &max
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// updater returns the updater applying the submitted scores.
+func (h *Handler) updater() leaderboard.Updater {
+	if h.Updater != nil {
+		return h.Updater
+	}
+	return h.lb
+}
+
+// allowMethod writes 405 Method Not Allowed and returns false if the request method is not the given method.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+// intParam returns the integer query parameter or the default value if it is not set.
+func intParam(query url.Values, name string, defaultValue int64) (int64, error) {
+	if !query.Has(name) {
+		return defaultValue, nil
+	}
+	return strconv.ParseInt(query.Get(name), 10, 64)
+}
+
+// floatParam returns the float query parameter or the default value if it is not set.
+func floatParam(query url.Values, name string, defaultValue float64) (float64, error) {
+	if !query.Has(name) {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseFloat(query.Get(name), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) {
+		return 0, errors.New("score must be a number")
+	}
+	return value, nil
+}
+
+// writeJSON writes the value as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+// writeError writes the error as a JSON response with the given status code.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// writeInternalError logs the error and writes a generic 500 Internal Server Error response.
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.Error("Failed to handle request", "method", r.Method, "path", r.URL.Path, "error", err)
+	writeError(w, http.StatusInternalServerError, errors.New("internal server error"))
+}
@@ -0,0 +1,272 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/api"
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/ratelimit"
+)
+
+// newHandler returns a handler serving a leaderboard in a memory store with the scores of the players.
+func newHandler(t *testing.T, scores map[string]float64) (*api.Handler, *leaderboard.Leaderboard) {
+	t.Helper()
+	lb := leaderboard.New(leaderboard.NewMemoryStore(), "{scoreboard}")
+	lb.Bounds = &leaderboard.Bounds{Min: 0, Max: 999}
+	for member, score := range scores {
+		if err := lb.Submit(context.Background(), member, score); err != nil {
+			t.Fatalf("Submit(%q, %v) failed: %v", member, score, err)
+		}
+	}
+	return api.NewHandler(lb), lb
+}
+
+// serve serves the request by the handler and returns the recorded response.
+func serve(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return recorder
+}
+
+// decode decodes the JSON body of the response into value.
+func decode(t *testing.T, response *httptest.ResponseRecorder, value any) {
+	t.Helper()
+	if err := json.Unmarshal(response.Body.Bytes(), value); err != nil {
+		t.Fatalf("failed to decode body %q: %v", response.Body.String(), err)
+	}
+}
+
+func TestScores(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"set", `{"member": "Alfred", "score": 500}`, http.StatusOK},
+		{"incr", `{"member": "Bob", "score": 50, "mode": "incr"}`, http.StatusOK},
+		{"best", `{"member": "Bob", "score": 10, "mode": "best"}`, http.StatusOK},
+		{"malformed body", `{"member": "Alfred", "score":`, http.StatusBadRequest},
+		{"unknown field", `{"member": "Alfred", "score": 1, "level": 3}`, http.StatusBadRequest},
+		{"empty member", `{"member": "", "score": 1}`, http.StatusBadRequest},
+		{"invalid mode", `{"member": "Alfred", "score": 1, "mode": "max"}`, http.StatusBadRequest},
+		{"below minimum", `{"member": "Alfred", "score": -1}`, http.StatusUnprocessableEntity},
+	}
+	handler, _ := newHandler(t, map[string]float64{"Bob": 100})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := serve(handler, http.MethodPost, "/scores", test.body)
+			if response.Code != test.status {
+				t.Fatalf("POST /scores %s = %d %s, want %d", test.body, response.Code, response.Body, test.status)
+			}
+			if test.status != http.StatusOK {
+				var body api.ErrorResponse
+				decode(t, response, &body)
+				if body.Error == "" {
+					t.Errorf("POST /scores %s returned no error message", test.body)
+				}
+			}
+		})
+	}
+
+	var result leaderboard.UpdateResult
+	decode(t, serve(handler, http.MethodPost, "/scores", `{"member": "Bob", "score": 50, "mode": "incr"}`), &result)
+	want := leaderboard.UpdateResult{
+		Old:     leaderboard.Entry{Rank: 2, Member: "Bob", Score: 150},
+		New:     leaderboard.Entry{Rank: 2, Member: "Bob", Score: 200},
+		Updated: true,
+	}
+	if result != want {
+		t.Errorf("POST /scores = %v, want %v", result, want)
+	}
+}
+
+func TestScoresInvalidScore(t *testing.T) {
+	handler, lb := newHandler(t, nil)
+	lb.TieBreakByTime = true
+	// the scores with the times of achievement must be integers
+	response := serve(handler, http.MethodPost, "/scores", `{"member": "Alfred", "score": 1.5}`)
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("POST /scores with score 1.5 = %d %s, want %d", response.Code, response.Body, http.StatusBadRequest)
+	}
+}
+
+func TestScoresRateLimited(t *testing.T) {
+	handler, lb := newHandler(t, nil)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.Limits{
+		PerMember: ratelimit.Limit{Events: 2, Window: 10 * time.Second},
+	})
+	limiter.Now = func() time.Time { return now }
+	handler.Updater = ratelimit.NewGuard(lb, limiter)
+
+	for i := 0; i < 2; i++ {
+		if response := serve(handler, http.MethodPost, "/scores", `{"member": "Alfred", "score": 1}`); response.Code != http.StatusOK {
+			t.Fatalf("POST /scores #%d = %d %s, want %d", i+1, response.Code, response.Body, http.StatusOK)
+		}
+		now = now.Add(1500 * time.Millisecond)
+	}
+	// the first event leaves the window after 10s - 3s = 7s
+	response := serve(handler, http.MethodPost, "/scores", `{"member": "Alfred", "score": 1}`)
+	if response.Code != http.StatusTooManyRequests {
+		t.Fatalf("POST /scores = %d %s, want %d", response.Code, response.Body, http.StatusTooManyRequests)
+	}
+	if got := response.Header().Get("Retry-After"); got != "7" {
+		t.Errorf("Retry-After = %q, want %q", got, "7")
+	}
+	// a fraction of a second is rounded up
+	now = now.Add(500 * time.Millisecond)
+	response = serve(handler, http.MethodPost, "/scores", `{"member": "Alfred", "score": 1}`)
+	if got := response.Header().Get("Retry-After"); response.Code != http.StatusTooManyRequests || got != "7" {
+		t.Errorf("POST /scores = %d with Retry-After %q, want %d with %q", response.Code, got, http.StatusTooManyRequests, "7")
+	}
+	// the other members are not limited
+	if response := serve(handler, http.MethodPost, "/scores", `{"member": "Bob", "score": 1}`); response.Code != http.StatusOK {
+		t.Errorf("POST /scores of Bob = %d %s, want %d", response.Code, response.Body, http.StatusOK)
+	}
+}
+
+func TestTop(t *testing.T) {
+	handler, _ := newHandler(t, map[string]float64{"Alfred": 300, "Bob": 200, "Cecil": 100})
+	tests := []struct {
+		target string
+		status int
+		want   api.PageResponse
+	}{
+		{"/top", http.StatusOK, api.PageResponse{
+			Entries: []leaderboard.Entry{{Rank: 1, Member: "Alfred", Score: 300}, {Rank: 2, Member: "Bob", Score: 200}, {Rank: 3, Member: "Cecil", Score: 100}},
+			Offset:  0, Limit: api.DefaultLimit, Total: 3,
+		}},
+		{"/top?offset=1&limit=1", http.StatusOK, api.PageResponse{
+			Entries: []leaderboard.Entry{{Rank: 2, Member: "Bob", Score: 200}},
+			Offset:  1, Limit: 1, Total: 3,
+		}},
+		{"/top?offset=5", http.StatusOK, api.PageResponse{
+			Entries: []leaderboard.Entry{},
+			Offset:  5, Limit: api.DefaultLimit, Total: 3,
+		}},
+		{"/top?limit=100", http.StatusOK, api.PageResponse{
+			Entries: []leaderboard.Entry{{Rank: 1, Member: "Alfred", Score: 300}, {Rank: 2, Member: "Bob", Score: 200}, {Rank: 3, Member: "Cecil", Score: 100}},
+			Offset:  0, Limit: api.MaxLimit, Total: 3,
+		}},
+		{"/top?offset=-1", http.StatusBadRequest, api.PageResponse{}},
+		{"/top?offset=first", http.StatusBadRequest, api.PageResponse{}},
+		{"/top?limit=0", http.StatusBadRequest, api.PageResponse{}},
+		{"/top?limit=101", http.StatusBadRequest, api.PageResponse{}},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			response := serve(handler, http.MethodGet, test.target, "")
+			if response.Code != test.status {
+				t.Fatalf("GET %s = %d %s, want %d", test.target, response.Code, response.Body, test.status)
+			}
+			if test.status != http.StatusOK {
+				return
+			}
+			var page api.PageResponse
+			decode(t, response, &page)
+			if page.Offset != test.want.Offset || page.Limit != test.want.Limit || page.Total != test.want.Total ||
+				len(page.Entries) != len(test.want.Entries) {
+				t.Fatalf("GET %s = %+v, want %+v", test.target, page, test.want)
+			}
+			for i, entry := range page.Entries {
+				if entry != test.want.Entries[i] {
+					t.Errorf("GET %s entry %d = %v, want %v", test.target, i, entry, test.want.Entries[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlayerRank(t *testing.T) {
+	handler, _ := newHandler(t, map[string]float64{"Alfred": 300, "Bob": 200, "Cecil Smith": 100})
+	tests := []struct {
+		target string
+		status int
+		want   leaderboard.Entry
+	}{
+		{"/players/Bob/rank", http.StatusOK, leaderboard.Entry{Rank: 2, Member: "Bob", Score: 200}},
+		{"/players/Cecil%20Smith/rank", http.StatusOK, leaderboard.Entry{Rank: 3, Member: "Cecil Smith", Score: 100}},
+		{"/players/Dave/rank", http.StatusNotFound, leaderboard.Entry{}},
+		{"/players/Bob", http.StatusNotFound, leaderboard.Entry{}},
+		{"/players/Bob/score", http.StatusNotFound, leaderboard.Entry{}},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			response := serve(handler, http.MethodGet, test.target, "")
+			if response.Code != test.status {
+				t.Fatalf("GET %s = %d %s, want %d", test.target, response.Code, response.Body, test.status)
+			}
+			if test.status != http.StatusOK {
+				return
+			}
+			var entry leaderboard.Entry
+			decode(t, response, &entry)
+			if entry != test.want {
+				t.Errorf("GET %s = %v, want %v", test.target, entry, test.want)
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	handler, _ := newHandler(t, map[string]float64{"Alfred": 300, "Bob": 200, "Cecil": 100})
+	tests := []struct {
+		target string
+		status int
+		want   int64
+	}{
+		{"/count", http.StatusOK, 3},
+		{"/count?min=150", http.StatusOK, 2},
+		{"/count?max=200", http.StatusOK, 2},
+		{"/count?min=100&max=200", http.StatusOK, 2},
+		{"/count?min=201&max=299", http.StatusOK, 0},
+		{"/count?min=high", http.StatusBadRequest, 0},
+		{"/count?max=low", http.StatusBadRequest, 0},
+		{"/count?min=200&max=100", http.StatusBadRequest, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			response := serve(handler, http.MethodGet, test.target, "")
+			if response.Code != test.status {
+				t.Fatalf("GET %s = %d %s, want %d", test.target, response.Code, response.Body, test.status)
+			}
+			if test.status != http.StatusOK {
+				return
+			}
+			var count api.CountResponse
+			decode(t, response, &count)
+			if count.Count != test.want {
+				t.Errorf("GET %s = %d, want %d", test.target, count.Count, test.want)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	handler, _ := newHandler(t, nil)
+	tests := []struct {
+		method string
+		target string
+		allow  string
+	}{
+		{http.MethodGet, "/scores", http.MethodPost},
+		{http.MethodPost, "/top", http.MethodGet},
+		{http.MethodDelete, "/players/Alfred/rank", http.MethodGet},
+		{http.MethodPut, "/count", http.MethodGet},
+	}
+	for _, test := range tests {
+		response := serve(handler, test.method, test.target, "")
+		if response.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s = %d, want %d", test.method, test.target, response.Code, http.StatusMethodNotAllowed)
+		}
+		if got := response.Header().Get("Allow"); got != test.allow {
+			t.Errorf("%s %s Allow = %q, want %q", test.method, test.target, got, test.allow)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultPageSize is the default number of records read from the stream by a single XRANGE.
+const DefaultPageSize = 1000
+
+// StreamKey returns the key of the audit stream of the leaderboard with the given key.
+func StreamKey(key string) string {
+	return key + ":history"
+}
+
+// Record is a single score change recorded in the audit stream.
+// Delta is the change of the score, Score is the score of the member after the change.
+// The records are appended by the update script of the leaderboard, see Recorder and Log.ChangeStream.
+type Record struct {
+	ID     string
+	Time   time.Time
+	Member string
+	Mode   leaderboard.UpdateMode
+	Value  float64
+	Delta  float64
+	Score  float64
+}
+
+// Log is an append-only audit log of score changes stored in a redis stream.
+// The time of a record is the time of its stream ID, assigned by redis when the record is appended.
+type Log struct {
+	// MaxLen trims the stream to approximately MaxLen records on every append, no trimming if zero.
+	MaxLen int64
+	// PageSize is the number of records read by a single XRANGE, DefaultPageSize if zero.
+	PageSize int64
+
+	client redis.UniversalClient
+	stream string
+}
+
+// NewLog returns a new Log stored in the stream.
+func NewLog(client redis.UniversalClient, stream string) *Log {
+	return &Log{
+		client: client,
+		stream: stream,
+	}
+}
+
+// Stream returns the key of the stream backing the log.
+func (l *Log) Stream() string {
+	return l.stream
+}
+
+// ChangeStream returns the change stream appending the records to the log trimmed to about MaxLen records.
+// Setting it as leaderboard.Leaderboard.Changes records every score change made directly on the leaderboard.
+func (l *Log) ChangeStream() *leaderboard.ChangeStream {
+	return &leaderboard.ChangeStream{
+		Key:    l.stream,
+		MaxLen: l.MaxLen,
+	}
+}
+
+// Append appends the record to the stream using XADD and returns its ID. The ID and time of the record are ignored.
+func (l *Log) Append(ctx context.Context, record Record) (string, error) {
+	args := &redis.XAddArgs{
+		Stream: l.stream,
+		Values: []any{
+			"member", record.Member,
+			"mode", record.Mode.String(),
+			"value", formatFloat(record.Value),
+			"delta", formatFloat(record.Delta),
+			"score", formatFloat(record.Score),
+		},
+	}
+	if l.MaxLen > 0 {
+		args.MaxLen = l.MaxLen
+		args.Approx = true
+	}
+	id, err := l.client.XAdd(ctx, args).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to append record of %q to %q: %w", record.Member, l.stream, err)
+	}
+	return id, nil
+}
+
+// Records returns the records with IDs in the range [start, stop] in the order they were appended.
+// The IDs may be "-" and "+" for the first and the last record, at most count records are returned.
+func (l *Log) Records(ctx context.Context, start, stop string, count int64) ([]Record, error) {
+	messages, err := l.client.XRangeN(ctx, l.stream, start, stop, count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records of %q: %w", l.stream, err)
+	}
+	records := make([]Record, 0, len(messages))
+	for _, message := range messages {
+		record, err := parseRecord(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse record %s of %q: %w", message.ID, l.stream, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Each calls fn for every record in the stream in the order they were appended, reading the stream in pages.
+// It stops at the first error returned by fn.
+func (l *Log) Each(ctx context.Context, fn func(record Record) error) error {
+	start := "-"
+	for {
+		records, err := l.Records(ctx, start, "+", l.pageSize())
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		if int64(len(records)) < l.pageSize() {
+			return nil
+		}
+		// the next page starts right after the last record (exclusive range requires redis 6.2)
+		start = "(" + records[len(records)-1].ID
+	}
+}
+
+// History returns the records of the member in the order they were appended.
+// The stream is not indexed by member, so the whole stream is read.
+func (l *Log) History(ctx context.Context, member string) ([]Record, error) {
+	var history []Record
+	err := l.Each(ctx, func(record Record) error {
+		if record.Member == member {
+			history = append(history, record)
+		}
+		return nil
+	})
+	return history, err
+}
+
+// Replay rebuilds the scores on the target leaderboard from the stream and returns the number of replayed records.
+// Every record sets the score of its member to the recorded score, achieved at the time of the record,
+// so replaying into an empty leaderboard reproduces the leaderboard including the tie-breaking by time.
+// Records removed by trimming are lost, so the stream must not be trimmed if it is the only copy of the scores.
+func (l *Log) Replay(ctx context.Context, target *leaderboard.Leaderboard) (int64, error) {
+	var replayed int64
+	// a copy of the target submits every score at the time of its record, without recording it again
+	board := *target
+	board.Changes = nil
+	err := l.Each(ctx, func(record Record) error {
+		board.Now = func() time.Time {
+			return record.Time
+		}
+		if err := board.Submit(ctx, record.Member, record.Score); err != nil {
+			return fmt.Errorf("failed to replay record %s: %w", record.ID, err)
+		}
+		replayed++
+		return nil
+	})
+	return replayed, err
+}
+
+// TrimMaxLen trims the stream to the newest maxLen records using XTRIM MAXLEN and returns the number of removed records.
+// With approx, redis may keep a few more records to trim efficiently.
+func (l *Log) TrimMaxLen(ctx context.Context, maxLen int64, approx bool) (int64, error) {
+	var cmd *redis.IntCmd
+	if approx {
+		cmd = l.client.XTrimMaxLenApprox(ctx, l.stream, maxLen, 0)
+	} else {
+		cmd = l.client.XTrimMaxLen(ctx, l.stream, maxLen)
+	}
+	removed, err := cmd.Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim %q to %d records: %w", l.stream, maxLen, err)
+	}
+	return removed, nil
+}
+
+// TrimBefore removes the records appended before the given time using XTRIM MINID (requires redis 6.2)
+// and returns the number of removed records.
+func (l *Log) TrimBefore(ctx context.Context, before time.Time) (int64, error) {
+	minID := strconv.FormatInt(before.UnixMilli(), 10)
+	removed, err := l.client.XTrimMinID(ctx, l.stream, minID).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim records of %q before %v: %w", l.stream, before, err)
+	}
+	return removed, nil
+}
+
+// TrimOlderThan removes the records older than the given age, see TrimBefore.
+func (l *Log) TrimOlderThan(ctx context.Context, age time.Duration) (int64, error) {
+	return l.TrimBefore(ctx, time.Now().Add(-age))
+}
+
+// pageSize returns the number of records read by a single XRANGE.
+func (l *Log) pageSize() int64 {
+	if l.PageSize > 0 {
+		return l.PageSize
+	}
+	return DefaultPageSize
+}
+
+// parseRecord parses the record from the stream message.
+func parseRecord(message redis.XMessage) (Record, error) {
+	record := Record{ID: message.ID}
+	millis, _, ok := strings.Cut(message.ID, "-")
+	if !ok {
+		return Record{}, fmt.Errorf("invalid ID: %q", message.ID)
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return Record{}, fmt.Errorf("invalid ID: %q", message.ID)
+	}
+	record.Time = time.UnixMilli(ms)
+
+	member, ok := message.Values["member"].(string)
+	if !ok {
+		return Record{}, errors.New("missing member")
+	}
+	record.Member = member
+	mode, _ := message.Values["mode"].(string)
+	if record.Mode, err = leaderboard.ParseUpdateMode(mode); err != nil {
+		return Record{}, err
+	}
+	for name, value := range map[string]*float64{"value": &record.Value, "delta": &record.Delta, "score": &record.Score} {
+		field, _ := message.Values[name].(string)
+		if *value, err = strconv.ParseFloat(field, 64); err != nil {
+			return Record{}, fmt.Errorf("invalid %s: %q", name, field)
+		}
+	}
+	return record, nil
+}
+
+// formatFloat formats the float exactly with the shortest representation.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,195 @@
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/audit"
+	"dpb-cv02-04/pkg/leaderboard"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisAddrEnv is the environment variable with the address of a real redis to run the tests against,
+// the same as in the tests of the leaderboard package. The database of the address is flushed by the tests.
+const redisAddrEnv = "LEADERBOARD_TEST_REDIS_ADDR"
+
+// newRedisClient returns a client of the flushed real redis, the test is skipped if its address is not set.
+// The audit log needs streams and the update script, which the in-process test server does not support.
+func newRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skip(redisAddrEnv + " is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := client.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("FLUSHDB failed: %v", err)
+	}
+	return client
+}
+
+// appendAt appends a record of the score set at the time directly to the stream, so the record has a known time.
+func appendAt(t *testing.T, client *redis.Client, stream string, at time.Time, member string, score float64) {
+	t.Helper()
+	err := client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: stream,
+		ID:     fmt.Sprintf("%d-0", at.UnixMilli()),
+		Values: []any{"member", member, "mode", "set", "value", score, "delta", score, "score", score},
+	}).Err()
+	if err != nil {
+		t.Fatalf("XADD of %q at %v failed: %v", member, at, err)
+	}
+}
+
+// assertTop checks the best entries of the leaderboard.
+func assertTop(t *testing.T, lb *leaderboard.Leaderboard, want []leaderboard.Entry) {
+	t.Helper()
+	top, err := lb.Top(context.Background(), int64(len(want))+1)
+	if err != nil {
+		t.Fatalf("Top() failed: %v", err)
+	}
+	if len(top) != len(want) {
+		t.Fatalf("Top() = %v, want %v", top, want)
+	}
+	for i, entry := range top {
+		if entry != want[i] {
+			t.Errorf("Top()[%d] = %v, want %v", i, entry, want[i])
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	key := "{scoreboard}"
+	log := audit.NewLog(client, audit.StreamKey(key))
+	lb := leaderboard.New(leaderboard.NewRedisStore(client), key)
+	lb.Changes = log.ChangeStream()
+
+	// the changes made directly on the leaderboard are recorded
+	if err := lb.Submit(ctx, "Alfred", 100); err != nil {
+		t.Fatalf("Submit(Alfred, 100) failed: %v", err)
+	}
+	if score, err := lb.Increment(ctx, "Alfred", 20); err != nil || score != 120 {
+		t.Fatalf("Increment(Alfred, 20) = %v, %v, want 120", score, err)
+	}
+	if _, err := lb.Update(ctx, "Bob", 50, leaderboard.UpdateBest); err != nil {
+		t.Fatalf("Update(Bob, 50, best) failed: %v", err)
+	}
+	// the bulk changes are not recorded
+	if err := lb.AddMany(ctx, []leaderboard.Entry{{Member: "Cecil", Score: 10}}, ""); err != nil {
+		t.Fatalf("AddMany() failed: %v", err)
+	}
+
+	history, err := log.History(ctx, "Alfred")
+	if err != nil {
+		t.Fatalf("History(Alfred) failed: %v", err)
+	}
+	want := []audit.Record{
+		{Member: "Alfred", Mode: leaderboard.UpdateSet, Value: 100, Delta: 100, Score: 100},
+		{Member: "Alfred", Mode: leaderboard.UpdateIncrement, Value: 20, Delta: 20, Score: 120},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("History(Alfred) = %v, want %v", history, want)
+	}
+	for i, record := range history {
+		record.ID, record.Time = "", time.Time{}
+		if record != want[i] {
+			t.Errorf("History(Alfred)[%d] = %v, want %v", i, record, want[i])
+		}
+	}
+	if records, err := log.Records(ctx, "-", "+", 10); err != nil || len(records) != 3 {
+		t.Errorf("Records() = %v, %v, want 3 records", records, err)
+	}
+
+	// the replay rebuilds the recorded scores without recording them again
+	replayed := leaderboard.New(leaderboard.NewRedisStore(client), key+":replay")
+	replayed.Changes = log.ChangeStream()
+	if n, err := log.Replay(ctx, replayed); err != nil || n != 3 {
+		t.Fatalf("Replay() = %d, %v, want 3", n, err)
+	}
+	assertTop(t, replayed, []leaderboard.Entry{{Rank: 1, Member: "Alfred", Score: 120}, {Rank: 2, Member: "Bob", Score: 50}})
+	if records, err := log.Records(ctx, "-", "+", 10); err != nil || len(records) != 3 {
+		t.Errorf("Records() after Replay() = %v, %v, want 3 records", records, err)
+	}
+}
+
+func TestReplayTieBreakByTime(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	stream := audit.StreamKey("{scoreboard}")
+	log := audit.NewLog(client, stream)
+	// the page size is smaller than the stream, so the records are read in several pages
+	log.PageSize = 2
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	appendAt(t, client, stream, start, "Alfred", 100)
+	appendAt(t, client, stream, start.Add(time.Minute), "Bob", 100)
+	appendAt(t, client, stream, start.Add(2*time.Minute), "Cecil", 200)
+	// Alfred achieves the score of Cecil later, so Cecil stays ahead
+	appendAt(t, client, stream, start.Add(3*time.Minute), "Alfred", 200)
+	// Dave achieves the score of Bob later than Bob
+	appendAt(t, client, stream, start.Add(4*time.Minute), "Dave", 100)
+
+	target := leaderboard.New(leaderboard.NewRedisStore(client), "{scoreboard}:replay")
+	target.TieBreakByTime = true
+	if n, err := log.Replay(ctx, target); err != nil || n != 5 {
+		t.Fatalf("Replay() = %d, %v, want 5", n, err)
+	}
+	assertTop(t, target, []leaderboard.Entry{
+		{Rank: 1, Member: "Cecil", Score: 200},
+		{Rank: 2, Member: "Alfred", Score: 200},
+		{Rank: 3, Member: "Bob", Score: 100},
+		{Rank: 4, Member: "Dave", Score: 100},
+	})
+	// the times of achievement are those of the records
+	entries, err := target.TimedPage(ctx, 0, 4)
+	if err != nil {
+		t.Fatalf("TimedPage() failed: %v", err)
+	}
+	wantTimes := []time.Time{start.Add(2 * time.Minute), start.Add(3 * time.Minute), start.Add(time.Minute), start.Add(4 * time.Minute)}
+	for i, entry := range entries {
+		if !entry.Achieved.Equal(wantTimes[i]) {
+			t.Errorf("TimedPage()[%d] = %v, want time %v", i, entry, wantTimes[i])
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	stream := audit.StreamKey("{scoreboard}")
+	log := audit.NewLog(client, stream)
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, member := range []string{"Alfred", "Bob", "Cecil", "Dave", "Eve"} {
+		appendAt(t, client, stream, start.Add(time.Duration(i)*time.Hour), member, 100)
+	}
+
+	// the records appended exactly at the time are kept
+	if removed, err := log.TrimBefore(ctx, start.Add(time.Hour)); err != nil || removed != 1 {
+		t.Errorf("TrimBefore(+1h) = %d, %v, want 1", removed, err)
+	}
+	if removed, err := log.TrimBefore(ctx, start.Add(time.Hour)); err != nil || removed != 0 {
+		t.Errorf("TrimBefore(+1h) again = %d, %v, want 0", removed, err)
+	}
+	if removed, err := log.TrimMaxLen(ctx, 3, false); err != nil || removed != 1 {
+		t.Errorf("TrimMaxLen(3) = %d, %v, want 1", removed, err)
+	}
+	records, err := log.Records(ctx, "-", "+", 10)
+	if err != nil {
+		t.Fatalf("Records() failed: %v", err)
+	}
+	if len(records) != 3 || records[0].Member != "Cecil" || !records[0].Time.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Records() = %v, want the records of Cecil, Dave and Eve", records)
+	}
+	// all the records are older than a day
+	if removed, err := log.TrimOlderThan(ctx, 24*time.Hour); err != nil || removed != 3 {
+		t.Errorf("TrimOlderThan(24h) = %d, %v, want 3", removed, err)
+	}
+}
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+
+	"dpb-cv02-04/pkg/leaderboard"
+)
+
+// Recorder updates scores on a leaderboard and records every change of a score in the audit log.
+// The record is appended by the same script as the update, so a change is recorded if and only if it is applied.
+// Only the updates routed through the Recorder as a leaderboard.Updater are audited, the scores changed
+// directly on the leaderboard are recorded only if Log.ChangeStream is set as its Changes.
+type Recorder struct {
+	lb  *leaderboard.Leaderboard
+	log *Log
+}
+
+// Interface guard for leaderboard.Updater.
+var _ leaderboard.Updater = (*Recorder)(nil)
+
+// NewRecorder returns a new Recorder updating the scores on the leaderboard and recording the changes in the log.
+// The leaderboard must be backed by a leaderboard.RedisStore, with a redis cluster the stream of the log must map
+// to the same slot as the leaderboard, e.g. the StreamKey of a key with a hash tag.
+func NewRecorder(lb *leaderboard.Leaderboard, log *Log) *Recorder {
+	return &Recorder{
+		lb:  lb,
+		log: log,
+	}
+}
+
+// Submit sets the score of the member, see Update.
+func (r *Recorder) Submit(ctx context.Context, member string, score float64) (leaderboard.UpdateResult, error) {
+	return r.Update(ctx, member, score, leaderboard.UpdateSet)
+}
+
+// Increment adds delta to the score of the member, see Update.
+func (r *Recorder) Increment(ctx context.Context, member string, delta float64) (leaderboard.UpdateResult, error) {
+	return r.Update(ctx, member, delta, leaderboard.UpdateIncrement)
+}
+
+// Update updates the score of the member with leaderboard.Leaderboard.UpdateRecorded, appending a Record to the log
+// if the score was changed. The log is trimmed to about Log.MaxLen records.
+func (r *Recorder) Update(ctx context.Context, member string, value float64, mode leaderboard.UpdateMode) (leaderboard.UpdateResult, error) {
+	return r.lb.UpdateRecorded(ctx, member, value, mode, *r.log.ChangeStream())
+}
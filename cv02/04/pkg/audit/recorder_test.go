@@ -0,0 +1,68 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dpb-cv02-04/pkg/audit"
+	"dpb-cv02-04/pkg/leaderboard"
+)
+
+func TestRecorder(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+
+	key := "{scoreboard}"
+	lb := leaderboard.New(leaderboard.NewRedisStore(client), key)
+	lb.Bounds = &leaderboard.Bounds{Min: 0, Max: 999}
+	log := audit.NewLog(client, audit.StreamKey(key))
+	recorder := audit.NewRecorder(lb, log)
+
+	if _, err := recorder.Submit(ctx, "Alfred", 888); err != nil {
+		t.Fatalf("Submit(Alfred, 888) failed: %v", err)
+	}
+	// the best score is kept, so there is no change to record
+	if _, err := recorder.Update(ctx, "Alfred", 100, leaderboard.UpdateBest); err != nil {
+		t.Fatalf("Update(Alfred, 100, best) failed: %v", err)
+	}
+	// the score is capped at the maximum, the record holds the capped change
+	if _, err := recorder.Increment(ctx, "Alfred", 200); err != nil {
+		t.Fatalf("Increment(Alfred, 200) failed: %v", err)
+	}
+	// a rejected update is neither applied nor recorded
+	if _, err := recorder.Increment(ctx, "Alfred", -2000); !errors.Is(err, leaderboard.ErrScoreBelowMin) {
+		t.Fatalf("Increment(Alfred, -2000) = %v, want ErrScoreBelowMin", err)
+	}
+	// updates made directly on the leaderboard are not recorded
+	if err := lb.Submit(ctx, "Bob", 10); err != nil {
+		t.Fatalf("Submit(Bob) failed: %v", err)
+	}
+
+	records, err := log.Records(ctx, "-", "+", 10)
+	if err != nil {
+		t.Fatalf("Records() failed: %v", err)
+	}
+	want := []audit.Record{
+		{Member: "Alfred", Mode: leaderboard.UpdateSet, Value: 888, Delta: 888, Score: 888},
+		{Member: "Alfred", Mode: leaderboard.UpdateIncrement, Value: 200, Delta: 111, Score: 999},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("Records() = %v, want %v", records, want)
+	}
+	for i, record := range records {
+		if record.ID == "" || record.Time.IsZero() {
+			t.Errorf("record %d has no ID or time: %v", i, record)
+		}
+		record.ID, record.Time = "", want[i].Time
+		if record != want[i] {
+			t.Errorf("record %d = %v, want %v", i, record, want[i])
+		}
+	}
+
+	// the memory store cannot append to a stream, so the update is rejected
+	memory := leaderboard.New(leaderboard.NewMemoryStore(), key)
+	if _, err := audit.NewRecorder(memory, log).Submit(ctx, "Alfred", 1); err == nil {
+		t.Errorf("Submit() on a memory store succeeded, want error")
+	}
+}
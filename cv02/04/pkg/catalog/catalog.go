@@ -0,0 +1,464 @@
+package catalog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dpb-cv02-04/pkg/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultPrefix is the prefix of the price keys used by cv02/01.txt.
+	DefaultPrefix = "wares:price:"
+	// DefaultLockTTL is the default time a loader holds the lock of a price.
+	DefaultLockTTL = 5 * time.Second
+	// DefaultLockPoll is the default interval of checking for a price loaded by another loader.
+	DefaultLockPoll = 50 * time.Millisecond
+	// DefaultLoadTimeout is the default time limit of loading a price, including waiting for another loader.
+	DefaultLoadTimeout = 10 * time.Second
+	// DefaultNotFoundTTL is the default time the wares missing in the source are remembered.
+	DefaultNotFoundTTL = 10 * time.Second
+	// DefaultScanCount is the default number of keys requested from a single SCAN.
+	DefaultScanCount = 100
+)
+
+// ErrNotFound is returned when the price of a ware is neither in the catalog nor in the source.
+var ErrNotFound = errors.New("price not found")
+
+// Price is the price of a ware.
+type Price struct {
+	Ware  string  `json:"ware"`
+	Price float64 `json:"price"`
+}
+
+// Source loads the prices missing in the catalog, e.g. from a database.
+type Source interface {
+	// LoadPrice returns the price of the ware or ErrNotFound if the ware has no price.
+	LoadPrice(ctx context.Context, ware string) (float64, error)
+}
+
+// SourceFunc is an adapter to use an ordinary function as a Source.
+type SourceFunc func(ctx context.Context, ware string) (float64, error)
+
+// Interface guard for Source.
+var _ Source = SourceFunc(nil)
+
+// LoadPrice calls f(ctx, ware).
+func (f SourceFunc) LoadPrice(ctx context.Context, ware string) (float64, error) {
+	return f(ctx, ware)
+}
+
+// Catalog is a cache of the prices of wares stored in redis strings under a common prefix.
+// With a Source, it is a read-through cache: the missing prices are loaded from the source and cached for TTL.
+// The loads are protected from stampedes when a hot price expires: the concurrent loads of a price
+// in the process are merged into one, and a lock key lets only one process load it at a time.
+// The wares missing in the source are remembered for NotFoundTTL, so they are not loaded on every get.
+type Catalog struct {
+	// TTL is the time to live of the cached prices, the prices do not expire if zero.
+	TTL time.Duration
+	// Source loads the missing prices, the catalog is not read-through if nil.
+	Source Source
+	// LockTTL is the time a loader holds the lock of a price, DefaultLockTTL if zero.
+	// It must be longer than loading a price takes.
+	LockTTL time.Duration
+	// LockPoll is the interval of checking for a price loaded by another loader, DefaultLockPoll if zero.
+	LockPoll time.Duration
+	// LoadTimeout is the time limit of loading a price, DefaultLoadTimeout if zero.
+	// A load is shared by the concurrent gets of the price, so it is not canceled with the context of any of them.
+	LoadTimeout time.Duration
+	// NotFoundTTL is the time the wares missing in the source are remembered, DefaultNotFoundTTL if zero.
+	// The missing wares are not remembered if it is negative.
+	NotFoundTTL time.Duration
+
+	client redis.UniversalClient
+	prefix string
+	loads  flightGroup
+}
+
+// New returns a new Catalog of the prices stored under keys with the prefix, DefaultPrefix if empty.
+// With a redis cluster, the prefix must contain a hash tag for GetMany, e.g. "{wares:price}:".
+func New(client redis.UniversalClient, prefix string) *Catalog {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Catalog{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Key returns the key of the price of the ware.
+func (c *Catalog) Key(ware string) string {
+	return c.prefix + ware
+}
+
+// lockKey returns the key of the lock of loading the price of the ware.
+// It is outside of the prefix, so the lock keys are not listed as prices.
+func (c *Catalog) lockKey(ware string) string {
+	return "lock:" + c.Key(ware)
+}
+
+// missingKey returns the key of the marker of a ware missing in the source, outside of the prefix as well.
+func (c *Catalog) missingKey(ware string) string {
+	return "missing:" + c.Key(ware)
+}
+
+// Set sets the price of the ware, expiring after TTL.
+func (c *Catalog) Set(ctx context.Context, ware string, price float64) error {
+	return c.SetWithTTL(ctx, ware, price, c.TTL)
+}
+
+// SetWithTTL sets the price of the ware, expiring after ttl, or never if ttl is zero.
+func (c *Catalog) SetWithTTL(ctx context.Context, ware string, price float64, ttl time.Duration) error {
+	if err := c.client.Set(ctx, c.Key(ware), formatPrice(price), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set price of %q: %w", ware, err)
+	}
+	return nil
+}
+
+// Get returns the price of the ware, loading it from the source if it is not cached.
+// Returns ErrNotFound if the price is neither cached nor in the source.
+// The get returns when ctx is done, while the load of the price continues for the other gets until LoadTimeout.
+func (c *Catalog) Get(ctx context.Context, ware string) (float64, error) {
+	price, err := c.get(ctx, ware)
+	if errors.Is(err, ErrNotFound) && c.Source != nil {
+		// the concurrent loads of the price in this process are merged into one,
+		// which runs detached from the context of the first get, so its cancellation does not fail the others
+		price, err = c.loads.do(ctx, ware, func() (float64, error) {
+			loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.loadTimeout())
+			defer cancel()
+			return c.load(loadCtx, ware)
+		})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get price of %q: %w", ware, err)
+	}
+	return price, nil
+}
+
+// GetMany returns the prices of the wares using MGET, loading the missing ones from the source.
+// The wares whose price is not found are missing in the returned map.
+func (c *Catalog) GetMany(ctx context.Context, wares ...string) (map[string]float64, error) {
+	if len(wares) == 0 {
+		return map[string]float64{}, nil
+	}
+	keys := make([]string, len(wares))
+	for i, ware := range wares {
+		keys[i] = c.Key(ware)
+	}
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %d prices: %w", len(wares), err)
+	}
+
+	prices := make(map[string]float64, len(wares))
+	for i, value := range values {
+		if value != nil {
+			if prices[wares[i]], err = parsePrice(value.(string)); err != nil {
+				return nil, fmt.Errorf("failed to get price of %q: %w", wares[i], err)
+			}
+			continue
+		}
+		if c.Source == nil {
+			continue
+		}
+		price, err := c.Get(ctx, wares[i])
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		prices[wares[i]] = price
+	}
+	return prices, nil
+}
+
+// TTLOf returns the remaining time to live of the price of the ware, 0 if it does not expire.
+// Returns ErrNotFound if the price is not cached.
+func (c *Catalog) TTLOf(ctx context.Context, ware string) (time.Duration, error) {
+	ttl, err := c.client.PTTL(ctx, c.Key(ware)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get TTL of price of %q: %w", ware, err)
+	}
+	// go-redis returns the replies -2 if the key does not exist and -1 if it has no expiration as nanoseconds
+	switch ttl {
+	case -2:
+		return 0, fmt.Errorf("failed to get TTL of price of %q: %w", ware, ErrNotFound)
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// Expire sets the time to live of the price of the ware. Returns ErrNotFound if the price is not cached.
+func (c *Catalog) Expire(ctx context.Context, ware string, ttl time.Duration) error {
+	ok, err := c.client.PExpire(ctx, c.Key(ware), ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to expire price of %q: %w", ware, err)
+	}
+	if !ok {
+		return fmt.Errorf("failed to expire price of %q: %w", ware, ErrNotFound)
+	}
+	return nil
+}
+
+// Delete removes the price of the ware from the catalog. Returns ErrNotFound if the price is not cached.
+func (c *Catalog) Delete(ctx context.Context, ware string) error {
+	n, err := c.client.Del(ctx, c.Key(ware)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete price of %q: %w", ware, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("failed to delete price of %q: %w", ware, ErrNotFound)
+	}
+	return nil
+}
+
+// List returns all cached prices sorted by ware. The keys are found with SCAN, never KEYS,
+// so listing does not block redis, and the prices are read with pipelined GETs.
+// The prices changed during the listing may be missing or outdated.
+func (c *Catalog) List(ctx context.Context) ([]Price, error) {
+	var prices []Price
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		// every master of a cluster scans only its own keys
+		var mu sync.Mutex
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			masterPrices, err := c.list(ctx, master)
+			mu.Lock()
+			defer mu.Unlock()
+			prices = append(prices, masterPrices...)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if prices, err = c.list(ctx, c.client); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Ware < prices[j].Ware
+	})
+	return prices, nil
+}
+
+// list returns the prices found by SCAN on the client.
+func (c *Catalog) list(ctx context.Context, client redis.UniversalClient) ([]Price, error) {
+	var prices []Price
+	seen := make(map[string]bool)
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, keyspace.EscapeGlob(c.prefix)+"*", DefaultScanCount).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prices: %w", err)
+		}
+		// SCAN may return a key more than once
+		var wares []string
+		for _, key := range keys {
+			ware := strings.TrimPrefix(key, c.prefix)
+			if !seen[ware] {
+				seen[ware] = true
+				wares = append(wares, ware)
+			}
+		}
+
+		cmds := make([]*redis.StringCmd, len(wares))
+		_, err = client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, ware := range wares {
+				cmds[i] = pipe.Get(ctx, c.Key(ware))
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("failed to get prices: %w", err)
+		}
+		for i, cmd := range cmds {
+			value, err := cmd.Result()
+			if errors.Is(err, redis.Nil) {
+				// the price expired after the scan
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get price of %q: %w", wares[i], err)
+			}
+			price, err := parsePrice(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get price of %q: %w", wares[i], err)
+			}
+			prices = append(prices, Price{Ware: wares[i], Price: price})
+		}
+
+		if next == 0 {
+			return prices, nil
+		}
+		cursor = next
+	}
+}
+
+// get returns the cached price of the ware or ErrNotFound.
+func (c *Catalog) get(ctx context.Context, ware string) (float64, error) {
+	value, err := c.client.Get(ctx, c.Key(ware)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return parsePrice(value)
+}
+
+// cached returns the cached price of the ware using GET, or ErrNotFound if it is not cached.
+// The marker of a missing ware is checked in the same pipeline, missing is true if the ware is missing in the source.
+func (c *Catalog) cached(ctx context.Context, ware string) (price float64, missing bool, err error) {
+	var get *redis.StringCmd
+	var exists *redis.IntCmd
+	_, err = c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, c.Key(ware))
+		exists = pipe.Exists(ctx, c.missingKey(ware))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, false, err
+	}
+	value, err := get.Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, exists.Val() > 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	price, err = parsePrice(value)
+	return price, false, err
+}
+
+// load loads the price of the ware from the source and caches it, holding the lock of the price.
+// While another loader holds the lock, it waits for the price loaded by that loader.
+// A ware missing in the source is remembered for NotFoundTTL, it is not loaded again until then.
+func (c *Catalog) load(ctx context.Context, ware string) (float64, error) {
+	// the ware may have been found missing by another loader
+	if price, missing, err := c.cached(ctx, ware); missing || !errors.Is(err, ErrNotFound) {
+		return price, err
+	}
+	token, err := newToken()
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	for {
+		locked, err := c.client.SetNX(ctx, c.lockKey(ware), token, c.lockTTL()).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to lock price: %w", err)
+		}
+		if locked {
+			break
+		}
+
+		// another loader holds the lock, its price is awaited until the lock is released or expires
+		timer := time.NewTimer(c.lockPoll())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+		if price, missing, err := c.cached(ctx, ware); missing || !errors.Is(err, ErrNotFound) {
+			return price, err
+		}
+	}
+	defer func() {
+		// the lock is released only if it was not taken over after expiring
+		_ = unlockScript.Run(context.WithoutCancel(ctx), c.client, []string{c.lockKey(ware)}, token).Err()
+	}()
+
+	// the price may have been loaded, or found missing, by the previous holder of the lock
+	if price, missing, err := c.cached(ctx, ware); missing || !errors.Is(err, ErrNotFound) {
+		return price, err
+	}
+	price, err := c.Source.LoadPrice(ctx, ware)
+	if errors.Is(err, ErrNotFound) && c.notFoundTTL() > 0 {
+		// the marker only saves the loads, so failing to set it does not fail the get
+		_ = c.client.Set(ctx, c.missingKey(ware), "1", c.notFoundTTL()).Err()
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := c.Set(ctx, ware, price); err != nil {
+		return 0, err
+	}
+	return price, nil
+}
+
+// lockTTL returns the time a loader holds the lock of a price.
+func (c *Catalog) lockTTL() time.Duration {
+	if c.LockTTL > 0 {
+		return c.LockTTL
+	}
+	return DefaultLockTTL
+}
+
+// loadTimeout returns the time limit of loading a price.
+func (c *Catalog) loadTimeout() time.Duration {
+	if c.LoadTimeout > 0 {
+		return c.LoadTimeout
+	}
+	return DefaultLoadTimeout
+}
+
+// notFoundTTL returns the time the wares missing in the source are remembered, not remembered if it is not positive.
+func (c *Catalog) notFoundTTL() time.Duration {
+	if c.NotFoundTTL == 0 {
+		return DefaultNotFoundTTL
+	}
+	return c.NotFoundTTL
+}
+
+// lockPoll returns the interval of checking for a price loaded by another loader.
+func (c *Catalog) lockPoll() time.Duration {
+	if c.LockPoll > 0 {
+		return c.LockPoll
+	}
+	return DefaultLockPoll
+}
+
+// unlockScript deletes the lock KEYS[1] if it is still held with the token ARGV[1].
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// formatPrice formats the price exactly with the shortest representation.
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+// parsePrice parses a stored price.
+func parsePrice(value string) (float64, error) {
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price: %q", value)
+	}
+	return price, nil
+}
+
+// newToken returns a new random lock token.
+func newToken() (string, error) {
+	var token [16]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token[:]), nil
+}
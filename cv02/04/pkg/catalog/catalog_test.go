@@ -0,0 +1,269 @@
+package catalog_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/catalog"
+	"dpb-cv02-04/pkg/resptest"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisAddrEnv is the environment variable with the address of a real redis to run the tests against,
+// the same as in the tests of the leaderboard package. The database of the address is flushed by the tests.
+const redisAddrEnv = "LEADERBOARD_TEST_REDIS_ADDR"
+
+// newRedisClient returns a client of the flushed real redis, the test is skipped if its address is not set.
+// The loads release their locks with a lua script, which the in-process test server does not support.
+func newRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skip(redisAddrEnv + " is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := client.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("FLUSHDB failed: %v", err)
+	}
+	return client
+}
+
+// countingSource is a source of the prices counting its loads, the wares without a price are not found.
+type countingSource struct {
+	prices map[string]float64
+	loads  atomic.Int64
+	// loading is called during every load
+	loading func(ware string)
+}
+
+func (s *countingSource) LoadPrice(ctx context.Context, ware string) (float64, error) {
+	s.loads.Add(1)
+	if s.loading != nil {
+		s.loading(ware)
+	}
+	price, ok := s.prices[ware]
+	if !ok {
+		return 0, catalog.ErrNotFound
+	}
+	return price, nil
+}
+
+// assertLoads checks the number of loads from the source.
+func assertLoads(t *testing.T, source *countingSource, want int64) {
+	t.Helper()
+	if n := source.loads.Load(); n != want {
+		t.Errorf("loads = %d, want %d", n, want)
+	}
+}
+
+func TestList(t *testing.T) {
+	server, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	// the prefix is a glob pattern matching other keys unless it is escaped
+	c := catalog.New(client, "wares[1]:price:")
+	want := make([]catalog.Price, 0, 250)
+	// more prices than a single SCAN returns
+	for i := 0; i < 250; i++ {
+		price := catalog.Price{Ware: fmt.Sprintf("ware%03d", i), Price: float64(i) + 0.5}
+		if err := c.SetWithTTL(ctx, price.Ware, price.Price, time.Duration(i%2)*time.Hour); err != nil {
+			t.Fatalf("SetWithTTL(%q) failed: %v", price.Ware, err)
+		}
+		want = append(want, price)
+	}
+	for _, key := range []string{"wares1:price:other", "lock:wares[1]:price:ware000", "missing:wares[1]:price:gone"} {
+		if err := client.Set(ctx, key, "1", 0).Err(); err != nil {
+			t.Fatalf("SET %s failed: %v", key, err)
+		}
+	}
+
+	prices, err := c.List(ctx)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(prices) != len(want) {
+		t.Fatalf("List() = %d prices, want %d", len(prices), len(want))
+	}
+	for i, price := range prices {
+		if price != want[i] {
+			t.Errorf("List()[%d] = %v, want %v", i, price, want[i])
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	source := &countingSource{prices: map[string]float64{"apple": 1.25}}
+	c := catalog.New(client, "")
+	c.Source = source
+	c.TTL = time.Minute
+
+	for i := 0; i < 2; i++ {
+		if price, err := c.Get(ctx, "apple"); err != nil || price != 1.25 {
+			t.Fatalf("Get(apple) = %v, %v, want 1.25", price, err)
+		}
+	}
+	// the loaded price is cached for TTL
+	assertLoads(t, source, 1)
+	if ttl, err := c.TTLOf(ctx, "apple"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTLOf(apple) = %v, %v, want at most a minute", ttl, err)
+	}
+	if n, err := client.Exists(ctx, "lock:"+c.Key("apple")).Result(); err != nil || n != 0 {
+		t.Errorf("EXISTS lock = %d, %v, want the lock released", n, err)
+	}
+}
+
+func TestGetConcurrent(t *testing.T) {
+	client := newRedisClient(t)
+	source := &countingSource{prices: map[string]float64{"apple": 1.25}}
+	source.loading = func(string) { time.Sleep(50 * time.Millisecond) }
+	// two catalogs stand for two processes, which share only the lock
+	catalogs := []*catalog.Catalog{catalog.New(client, ""), catalog.New(client, "")}
+	for _, c := range catalogs {
+		c.Source = source
+		c.LockPoll = 10 * time.Millisecond
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(c *catalog.Catalog) {
+			defer wg.Done()
+			if price, err := c.Get(context.Background(), "apple"); err != nil || price != 1.25 {
+				t.Errorf("Get(apple) = %v, %v, want 1.25", price, err)
+			}
+		}(catalogs[i%2])
+	}
+	wg.Wait()
+	assertLoads(t, source, 1)
+}
+
+func TestGetLocked(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	source := &countingSource{prices: map[string]float64{"apple": 1.25, "pear": 2}}
+	c := catalog.New(client, "")
+	c.Source = source
+	c.LockPoll = 10 * time.Millisecond
+	lockKey := func(ware string) string { return "lock:" + c.Key(ware) }
+
+	// another process holds the lock and sets the price meanwhile
+	if err := client.Set(ctx, lockKey("apple"), "other", time.Minute).Err(); err != nil {
+		t.Fatalf("SET lock failed: %v", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client.Set(ctx, c.Key("apple"), "3", 0)
+	}()
+	if price, err := c.Get(ctx, "apple"); err != nil || price != 3 {
+		t.Errorf("Get(apple) = %v, %v, want the price of the other process 3", price, err)
+	}
+	assertLoads(t, source, 0)
+
+	// the lock of a failed process expires, it is deleted here, since not every test server expires keys in real time
+	if err := client.Set(ctx, lockKey("pear"), "other", time.Minute).Err(); err != nil {
+		t.Fatalf("SET lock failed: %v", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client.Del(ctx, lockKey("pear"))
+	}()
+	if price, err := c.Get(ctx, "pear"); err != nil || price != 2 {
+		t.Errorf("Get(pear) = %v, %v, want 2", price, err)
+	}
+	assertLoads(t, source, 1)
+	if n, err := client.Exists(ctx, lockKey("pear")).Result(); err != nil || n != 0 {
+		t.Errorf("EXISTS lock = %d, %v, want the lock released", n, err)
+	}
+}
+
+func TestGetLockTakenOver(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	source := &countingSource{prices: map[string]float64{"apple": 1.25}}
+	c := catalog.New(client, "")
+	c.Source = source
+	lockKey := "lock:" + c.Key("apple")
+	// the lock expires during the load and another process takes it over
+	source.loading = func(ware string) {
+		token, err := client.Get(ctx, lockKey).Result()
+		if err != nil || len(token) != 32 {
+			t.Errorf("GET lock = %q, %v, want the token of the loader", token, err)
+		}
+		if ttl, err := client.PTTL(ctx, lockKey).Result(); err != nil || ttl <= 0 || ttl > catalog.DefaultLockTTL {
+			t.Errorf("PTTL lock = %v, %v, want at most DefaultLockTTL", ttl, err)
+		}
+		client.Set(ctx, lockKey, "other", time.Minute)
+	}
+
+	if price, err := c.Get(ctx, "apple"); err != nil || price != 1.25 {
+		t.Fatalf("Get(apple) = %v, %v, want 1.25", price, err)
+	}
+	// the lock of the other process is not released
+	if token, err := client.Get(ctx, lockKey).Result(); err != nil || token != "other" {
+		t.Errorf("GET lock = %q, %v, want other", token, err)
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	source := &countingSource{}
+	c := catalog.New(client, "")
+	c.Source = source
+	c.NotFoundTTL = time.Minute
+	missingKey := "missing:" + c.Key("apple")
+
+	for i := 0; i < 2; i++ {
+		if price, err := c.Get(ctx, "apple"); !errors.Is(err, catalog.ErrNotFound) {
+			t.Fatalf("Get(apple) = %v, %v, want ErrNotFound", price, err)
+		}
+	}
+	// the missing ware is remembered for NotFoundTTL
+	assertLoads(t, source, 1)
+	if ttl, err := client.PTTL(ctx, missingKey).Result(); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("PTTL missing = %v, %v, want at most a minute", ttl, err)
+	}
+	// the ware is loaded again once the marker expired
+	if err := client.Del(ctx, missingKey).Err(); err != nil {
+		t.Fatalf("DEL missing failed: %v", err)
+	}
+	if _, err := c.Get(ctx, "apple"); !errors.Is(err, catalog.ErrNotFound) {
+		t.Fatalf("Get(apple) = %v, want ErrNotFound", err)
+	}
+	assertLoads(t, source, 2)
+	// the price set meanwhile is found despite the marker
+	if err := c.Set(ctx, "apple", 1.25); err != nil {
+		t.Fatalf("Set(apple) failed: %v", err)
+	}
+	if price, err := c.Get(ctx, "apple"); err != nil || price != 1.25 {
+		t.Errorf("Get(apple) = %v, %v, want 1.25", price, err)
+	}
+
+	// the missing wares are not remembered with a negative NotFoundTTL
+	c.NotFoundTTL = -1
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(ctx, "pear"); !errors.Is(err, catalog.ErrNotFound) {
+			t.Fatalf("Get(pear) = %v, want ErrNotFound", err)
+		}
+	}
+	assertLoads(t, source, 4)
+	if n, err := client.Exists(ctx, "missing:"+c.Key("pear")).Result(); err != nil || n != 0 {
+		t.Errorf("EXISTS missing = %d, %v, want 0", n, err)
+	}
+}
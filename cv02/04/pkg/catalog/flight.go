@@ -0,0 +1,49 @@
+package catalog
+
+import (
+	"context"
+	"sync"
+)
+
+// flightCall is a load in flight, whose result is shared by all callers.
+type flightCall struct {
+	done  chan struct{}
+	price float64
+	err   error
+}
+
+// flightGroup merges the concurrent loads of the same price into one.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do starts load in a new goroutine unless a load of the ware is in flight, then it waits for the result of the load.
+// Every caller waits only until its own ctx is done, the load keeps running for the other callers,
+// so load must not depend on the ctx of any of the callers.
+func (g *flightGroup) do(ctx context.Context, ware string, load func() (float64, error)) (float64, error) {
+	g.mu.Lock()
+	call, ok := g.calls[ware]
+	if !ok {
+		if g.calls == nil {
+			g.calls = make(map[string]*flightCall)
+		}
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[ware] = call
+		go func() {
+			call.price, call.err = load()
+			g.mu.Lock()
+			delete(g.calls, ware)
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-call.done:
+		return call.price, call.err
+	}
+}
@@ -0,0 +1,112 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// result is the result of a call of flightGroup.do.
+type result struct {
+	price float64
+	err   error
+}
+
+// goDo calls g.do in a new goroutine and returns the channel of its result.
+func goDo(ctx context.Context, g *flightGroup, ware string, load func() (float64, error)) <-chan result {
+	results := make(chan result, 1)
+	go func() {
+		price, err := g.do(ctx, ware, load)
+		results <- result{price, err}
+	}()
+	return results
+}
+
+// receive returns the result from the channel or fails the test after a timeout.
+func receive(t *testing.T, results <-chan result) result {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("do() did not return")
+		return result{}
+	}
+}
+
+func TestFlightGroup(t *testing.T) {
+	var g flightGroup
+	var loads atomic.Int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func() (float64, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return 10, nil
+	}
+
+	ctx := context.Background()
+	first := goDo(ctx, &g, "apple", load)
+	<-started
+	// the concurrent calls of the ware join the load in flight
+	second := goDo(ctx, &g, "apple", load)
+	time.Sleep(10 * time.Millisecond)
+	// another ware is loaded on its own
+	other := goDo(ctx, &g, "pear", func() (float64, error) { return 0, errors.New("failed") })
+	if r := receive(t, other); r.err == nil {
+		t.Errorf("do(pear) = %v, want error", r.price)
+	}
+
+	close(release)
+	for _, results := range []<-chan result{first, second} {
+		if r := receive(t, results); r.err != nil || r.price != 10 {
+			t.Errorf("do(apple) = %v, %v, want 10", r.price, r.err)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loads = %d, want 1", n)
+	}
+
+	// the finished load is not shared with the later calls
+	if price, err := g.do(ctx, "apple", func() (float64, error) { return 20, nil }); err != nil || price != 20 {
+		t.Errorf("do(apple) after the load = %v, %v, want 20", price, err)
+	}
+	if len(g.calls) != 0 {
+		t.Errorf("calls = %v, want none in flight", g.calls)
+	}
+}
+
+func TestFlightGroupCanceled(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func() (float64, error) {
+		close(started)
+		<-release
+		return 10, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := goDo(ctx, &g, "apple", load)
+	<-started
+	second := goDo(context.Background(), &g, "apple", load)
+
+	// the first caller stops waiting, while the load continues for the second one
+	cancel()
+	if r := receive(t, first); !errors.Is(r.err, context.Canceled) {
+		t.Errorf("do() with canceled context = %v, %v, want context.Canceled", r.price, r.err)
+	}
+	select {
+	case r := <-second:
+		t.Fatalf("do() = %v, %v before the load finished", r.price, r.err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	if r := receive(t, second); r.err != nil || r.price != 10 {
+		t.Errorf("do() = %v, %v, want 10", r.price, r.err)
+	}
+}
@@ -0,0 +1,169 @@
+package keyspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultScanCount is the default number of keys requested from a single SCAN.
+const DefaultScanCount = 100
+
+// Separator separates the prefix of a namespace from the rest of the key.
+const Separator = ":"
+
+// HashTag returns the key wrapped in a hash tag, so the keys derived from it by appending suffixes
+// map to the same Cluster slot and may be used together in multi-key commands such as ZUNIONSTORE.
+// A key that already contains a hash tag is returned unchanged.
+func HashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key
+		}
+	}
+	return "{" + key + "}"
+}
+
+// Namespace groups keys under a common prefix, so they can be cleaned up without touching any other keys.
+type Namespace struct {
+	prefix string
+}
+
+// New returns a new Namespace with the given prefix.
+func New(prefix string) Namespace {
+	return Namespace{prefix: prefix}
+}
+
+// NewRun returns a new Namespace unique to the current run, prefixed by base and the current time.
+func NewRun(base string) Namespace {
+	return New(fmt.Sprintf("%s%srun-%d", base, Separator, time.Now().UnixNano()))
+}
+
+// Prefix returns the prefix of the namespace.
+func (ns Namespace) Prefix() string {
+	return ns.prefix
+}
+
+// Key returns the key in the namespace consisting of the parts joined by Separator.
+func (ns Namespace) Key(parts ...string) string {
+	return ns.prefix + Separator + strings.Join(parts, Separator)
+}
+
+// Pattern returns the SCAN pattern matching all keys in the namespace.
+// The glob special characters of the prefix are escaped, so they match only themselves.
+func (ns Namespace) Pattern() string {
+	return EscapeGlob(ns.prefix) + Separator + "*"
+}
+
+// EscapeGlob escapes the glob special characters of the string, so it matches only itself in a SCAN pattern.
+func EscapeGlob(s string) string {
+	var escaped strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// Cleaner deletes the keys of a namespace.
+type Cleaner struct {
+	// DryRun only lists the keys that would be deleted, without deleting them.
+	DryRun bool
+	// ScanCount is the number of keys requested from a single SCAN, DefaultScanCount if zero.
+	ScanCount int64
+
+	client redis.UniversalClient
+}
+
+// NewCleaner returns a new Cleaner deleting the keys with the client.
+func NewCleaner(client redis.UniversalClient) *Cleaner {
+	return &Cleaner{client: client}
+}
+
+// Clean deletes all keys in the namespace and returns them, or only returns them if DryRun is set.
+// The keys are found with SCAN, never KEYS, and deleted with UNLINK, so redis frees their memory in the background.
+// Keys created in the namespace during the cleanup may be kept.
+func (c *Cleaner) Clean(ctx context.Context, ns Namespace) ([]string, error) {
+	if ns.prefix == "" {
+		return nil, errors.New("refusing to clean a namespace with an empty prefix")
+	}
+	cluster, ok := c.client.(*redis.ClusterClient)
+	if !ok {
+		return c.clean(ctx, c.client, ns)
+	}
+
+	// every master of a cluster scans only its own keys, so all of them are cleaned
+	var mu sync.Mutex
+	var cleaned []string
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		keys, err := c.clean(ctx, master, ns)
+		mu.Lock()
+		defer mu.Unlock()
+		cleaned = append(cleaned, keys...)
+		return err
+	})
+	return cleaned, err
+}
+
+// clean deletes the keys in the namespace found by SCAN on the client.
+func (c *Cleaner) clean(ctx context.Context, client redis.UniversalClient, ns Namespace) ([]string, error) {
+	var cleaned []string
+	// SCAN may return a key more than once
+	seen := make(map[string]bool)
+	iter := client.Scan(ctx, 0, ns.Pattern(), c.scanCount()).Iterator()
+	batch := make([]string, 0, c.scanCount())
+	for iter.Next(ctx) {
+		if seen[iter.Val()] {
+			continue
+		}
+		seen[iter.Val()] = true
+		batch = append(batch, iter.Val())
+		if int64(len(batch)) == c.scanCount() {
+			if err := c.unlink(ctx, client, batch); err != nil {
+				return cleaned, err
+			}
+			cleaned = append(cleaned, batch...)
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return cleaned, fmt.Errorf("failed to scan keys of %q: %w", ns.prefix, err)
+	}
+	if err := c.unlink(ctx, client, batch); err != nil {
+		return cleaned, err
+	}
+	return append(cleaned, batch...), nil
+}
+
+// unlink deletes the keys with UNLINK unless DryRun is set.
+// The keys are unlinked one by one in a pipeline, as a cluster rejects multi-key commands spanning several slots.
+func (c *Cleaner) unlink(ctx context.Context, client redis.UniversalClient, keys []string) error {
+	if c.DryRun || len(keys) == 0 {
+		return nil
+	}
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unlink %d keys: %w", len(keys), err)
+	}
+	return nil
+}
+
+// scanCount returns the number of keys requested from a single SCAN.
+func (c *Cleaner) scanCount() int64 {
+	if c.ScanCount > 0 {
+		return c.ScanCount
+	}
+	return DefaultScanCount
+}
@@ -0,0 +1,149 @@
+package keyspace_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"dpb-cv02-04/pkg/keyspace"
+	"dpb-cv02-04/pkg/resptest"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHashTag(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"scoreboard", "{scoreboard}"},
+		{"{scoreboard}", "{scoreboard}"},
+		{"game:{scoreboard}:weekly", "game:{scoreboard}:weekly"},
+		// an empty hash tag is not a hash tag, redis hashes the whole key
+		{"{}scoreboard", "{{}scoreboard}"},
+		{"score{board", "{score{board}"},
+	}
+	for _, test := range tests {
+		if got := keyspace.HashTag(test.key); got != test.want {
+			t.Errorf("HashTag(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"scoreboard", "scoreboard"},
+		{"", ""},
+		{"a*b?c", `a\*b\?c`},
+		{"[abc]", `\[abc\]`},
+		{`back\slash`, `back\\slash`},
+		{"{tag}:ü", "{tag}:ü"},
+	}
+	for _, test := range tests {
+		if got := keyspace.EscapeGlob(test.s); got != test.want {
+			t.Errorf("EscapeGlob(%q) = %q, want %q", test.s, got, test.want)
+		}
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	ns := keyspace.New("test*[1]")
+	if got, want := ns.Key("scores", "weekly"), "test*[1]:scores:weekly"; got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+	if got, want := ns.Pattern(), `test\*\[1\]:*`; got != want {
+		t.Errorf("Pattern() = %q, want %q", got, want)
+	}
+	run := keyspace.NewRun("test")
+	if !strings.HasPrefix(run.Prefix(), "test:run-") || run == keyspace.NewRun("test") {
+		t.Errorf("NewRun() = %q, want a unique namespace prefixed by test:run-", run.Prefix())
+	}
+}
+
+// newClient returns a client of a new resptest server with the keys set.
+func newClient(t *testing.T, keys ...string) *redis.Client {
+	t.Helper()
+	server, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	for _, key := range keys {
+		if err := client.Set(context.Background(), key, "1", 0).Err(); err != nil {
+			t.Fatalf("SET %s failed: %v", key, err)
+		}
+	}
+	return client
+}
+
+// assertKeys checks all keys of the server.
+func assertKeys(t *testing.T, client *redis.Client, want []string) {
+	t.Helper()
+	keys, err := client.Keys(context.Background(), "*").Result()
+	if err != nil {
+		t.Fatalf("KEYS failed: %v", err)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, want) {
+		t.Errorf("KEYS = %q, want %q", keys, want)
+	}
+}
+
+func TestClean(t *testing.T) {
+	ns := keyspace.New("test*[1]")
+	inside := []string{"test*[1]:a", "test*[1]:b", "test*[1]:c:d", "test*[1]:e", "test*[1]:f"}
+	// the keys matched by the prefix as a glob pattern or without the separator
+	outside := []string{"other:a", "test*[1]x:a", "test*1:a", "testx1:a", "testx[1]:a"}
+	slices.Sort(outside)
+	all := append(slices.Clone(inside), outside...)
+	slices.Sort(all)
+	ctx := context.Background()
+
+	t.Run("dry run", func(t *testing.T) {
+		client := newClient(t, all...)
+		cleaner := keyspace.NewCleaner(client)
+		cleaner.DryRun = true
+		cleaner.ScanCount = 2
+		keys, err := cleaner.Clean(ctx, ns)
+		if err != nil {
+			t.Fatalf("Clean() failed: %v", err)
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, inside) {
+			t.Errorf("Clean() = %q, want %q", keys, inside)
+		}
+		assertKeys(t, client, all)
+	})
+
+	t.Run("clean", func(t *testing.T) {
+		client := newClient(t, all...)
+		cleaner := keyspace.NewCleaner(client)
+		// the keys are unlinked in several batches
+		cleaner.ScanCount = 2
+		keys, err := cleaner.Clean(ctx, ns)
+		if err != nil {
+			t.Fatalf("Clean() failed: %v", err)
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, inside) {
+			t.Errorf("Clean() = %q, want %q", keys, inside)
+		}
+		assertKeys(t, client, outside)
+
+		if keys, err := cleaner.Clean(ctx, ns); err != nil || len(keys) != 0 {
+			t.Errorf("Clean() again = %q, %v, want no keys", keys, err)
+		}
+	})
+
+	t.Run("empty prefix", func(t *testing.T) {
+		client := newClient(t, all...)
+		if keys, err := keyspace.NewCleaner(client).Clean(ctx, keyspace.New("")); err == nil {
+			t.Errorf("Clean() = %q, want error", keys)
+		}
+		assertKeys(t, client, all)
+	})
+}
@@ -0,0 +1,120 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Combination combines the leaderboards of a single store into one ranking, e.g. the standings over all game modes.
+// With a redis cluster, the keys of the leaderboards must share a hash tag, e.g. "{scoreboard}:duel" and
+// "{scoreboard}:team", so they can be combined by a single command.
+type Combination struct {
+	// Intersect keeps only the members on all of the leaderboards, instead of the members on any of them.
+	Intersect bool
+	// Aggregate combines the scores of a member on several leaderboards, AggregateSum if empty.
+	Aggregate Aggregate
+	// Weights multiply the scores of the leaderboards in their order before they are aggregated, all 1 if nil.
+	Weights []float64
+	// TTL is the time to live of the combinations stored by Store, RangeTTL if zero.
+	TTL time.Duration
+
+	boards []*Leaderboard
+}
+
+// NewCombination returns a new Combination of the leaderboards.
+// The combined leaderboard has the ranking settings of the first one.
+func NewCombination(boards ...*Leaderboard) *Combination {
+	return &Combination{boards: boards}
+}
+
+// Store stores the combination under dest using ZUNIONSTORE or ZINTERSTORE, replacing dest if it exists,
+// and returns it as a leaderboard. The stored combination expires after the TTL, which is set in the same
+// transaction, and it is not updated when the scores on the combined leaderboards change.
+func (c *Combination) Store(ctx context.Context, dest string) (*Leaderboard, error) {
+	keys, err := c.keys()
+	if err != nil {
+		return nil, err
+	}
+	store := c.boards[0].store
+	if c.Intersect {
+		_, err = store.InterStore(ctx, dest, keys, c.Weights, c.Aggregate, c.ttl())
+	} else {
+		_, err = store.UnionStore(ctx, dest, keys, c.Weights, c.Aggregate, c.ttl())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store combination of %q: %w", keys, err)
+	}
+	return c.boards[0].WithKey(dest), nil
+}
+
+// Compute computes the combination using ZUNION or ZINTER, which need redis 6.2, without storing it in the store.
+// The combination is returned as a leaderboard held in a new MemoryStore under the given key,
+// so it is a snapshot that can be queried without further round trips.
+func (c *Combination) Compute(ctx context.Context, key string) (*Leaderboard, error) {
+	keys, err := c.keys()
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if c.Intersect {
+		entries, err = c.boards[0].store.Inter(ctx, keys, c.Weights, c.Aggregate)
+	} else {
+		entries, err = c.boards[0].store.Union(ctx, keys, c.Weights, c.Aggregate)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute combination of %q: %w", keys, err)
+	}
+
+	combined := *c.boards[0]
+	combined.store = NewMemoryStore()
+	combined.key = key
+	// the scores are stored as computed, they are composite scores already with TieBreakByTime
+	if err := combined.store.AddMany(ctx, key, entries, ""); err != nil {
+		return nil, fmt.Errorf("failed to store computed combination: %w", err)
+	}
+	return &combined, nil
+}
+
+// keys validates the combination and returns the keys of the leaderboards.
+func (c *Combination) keys() ([]string, error) {
+	if len(c.boards) == 0 {
+		return nil, errors.New("no leaderboards to combine")
+	}
+	if c.Weights != nil && len(c.Weights) != len(c.boards) {
+		return nil, fmt.Errorf("got %d weights for %d leaderboards", len(c.Weights), len(c.boards))
+	}
+	keys := make([]string, len(c.boards))
+	first := c.boards[0]
+	for i, lb := range c.boards {
+		if lb.store != first.store {
+			return nil, fmt.Errorf("leaderboard %q is in another store than %q", lb.key, first.key)
+		}
+		if lb.TieBreakByTime != first.TieBreakByTime {
+			return nil, fmt.Errorf("leaderboard %q differs from %q in TieBreakByTime", lb.key, first.key)
+		}
+		keys[i] = lb.key
+	}
+	if !first.TieBreakByTime {
+		return keys, nil
+	}
+	// composite scores of TieBreakByTime cannot be summed or weighted without corrupting the times of achievement
+	if c.Aggregate == "" || c.Aggregate == AggregateSum {
+		return nil, errors.New("scores with TieBreakByTime cannot be summed")
+	}
+	for _, weight := range c.Weights {
+		if weight != 1 {
+			return nil, errors.New("scores with TieBreakByTime cannot be weighted")
+		}
+	}
+	return keys, nil
+}
+
+// ttl returns the time to live of the stored combinations.
+func (c *Combination) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return RangeTTL
+}
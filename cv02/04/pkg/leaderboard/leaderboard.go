@@ -0,0 +1,321 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrMemberNotFound is returned when the member is not on the leaderboard.
+var ErrMemberNotFound = errors.New("member not found")
+
+// Entry represents a single member of the leaderboard.
+// Rank is 1-based, the member with the highest score has rank 1.
+// Score is the score of the member as submitted, without the time of achievement of TieBreakByTime.
+type Entry struct {
+	Rank   int64   `json:"rank"`
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// Leaderboard is a scoreboard stored in a sorted set of a Store.
+type Leaderboard struct {
+	// TieBreakByTime orders the members with equal score by the time they achieved it, the earlier the better.
+	// The scores are stored as composite scores, so the scores must be integers not exceeding MaxTieBreakScore
+	// and the setting must not be changed once the leaderboard holds any members.
+	TieBreakByTime bool
+	// RankingMode determines how the members with equal score are ranked, RankOrdinal by default.
+	RankingMode RankingMode
+	// Now returns the time of achievement of submitted scores, time.Now if nil.
+	Now func() time.Time
+	// Bounds limits the scores set by Update, nil if the scores are not limited.
+	Bounds *Bounds
+	// Changes is the stream the score changes made by Submit, Increment and Update are appended to,
+	// nil if the changes are not recorded, see ChangeStream. The bulk changes, e.g. by AddMany, Remove
+	// or the periodic leaderboards, are never recorded.
+	Changes *ChangeStream
+
+	store Store
+	key   string
+}
+
+// New returns a new Leaderboard stored in the store under the given key.
+func New(store Store, key string) *Leaderboard {
+	return &Leaderboard{
+		store: store,
+		key:   key,
+	}
+}
+
+// Key returns the key of the sorted set backing the leaderboard.
+func (lb *Leaderboard) Key() string {
+	return lb.key
+}
+
+// Submit sets the score of the member, adding the member if it is not on the leaderboard yet.
+// With TieBreakByTime, the score is considered achieved now even if the member already had the same score.
+// With Changes, the score is set by Update without the bounds.
+func (lb *Leaderboard) Submit(ctx context.Context, member string, score float64) error {
+	if lb.Changes != nil {
+		_, err := lb.update(ctx, member, score, UpdateSet, nil, lb.Changes)
+		return err
+	}
+	stored, err := lb.storedScore(score)
+	if err != nil {
+		return fmt.Errorf("failed to submit score of %q: %w", member, err)
+	}
+	if err := lb.store.Add(ctx, lb.key, member, stored); err != nil {
+		return fmt.Errorf("failed to submit score of %q: %w", member, err)
+	}
+	return nil
+}
+
+// Increment adds delta to the score of the member and returns the new score.
+// Members that are not on the leaderboard start with a score of 0.
+// With TieBreakByTime, the score is read and written back with a new time of achievement, which is not atomic,
+// Update with UpdateIncrement increments the score atomically. With Changes, the increment is applied by Update.
+func (lb *Leaderboard) Increment(ctx context.Context, member string, delta float64) (float64, error) {
+	if lb.Changes != nil {
+		result, err := lb.update(ctx, member, delta, UpdateIncrement, nil, lb.Changes)
+		return result.New.Score, err
+	}
+	if lb.TieBreakByTime {
+		return lb.incrementWithTime(ctx, member, delta)
+	}
+	score, err := lb.store.IncrBy(ctx, lb.key, member, delta)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment score of %q: %w", member, err)
+	}
+	return score, nil
+}
+
+// incrementWithTime adds delta to the composite score of the member and updates its time of achievement.
+func (lb *Leaderboard) incrementWithTime(ctx context.Context, member string, delta float64) (float64, error) {
+	var score float64
+	entry, err := lb.store.RevRank(ctx, lb.key, member)
+	switch {
+	case err == nil:
+		score = decodeScore(entry.Score)
+	case !errors.Is(err, ErrMemberNotFound):
+		return 0, fmt.Errorf("failed to increment score of %q: %w", member, err)
+	}
+	score += delta
+	stored, err := encodeScore(score, lb.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment score of %q: %w", member, err)
+	}
+	if err := lb.store.Add(ctx, lb.key, member, stored); err != nil {
+		return 0, fmt.Errorf("failed to increment score of %q: %w", member, err)
+	}
+	return score, nil
+}
+
+// Top returns the n members with the highest score ordered from the best.
+func (lb *Leaderboard) Top(ctx context.Context, n int64) ([]Entry, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of entries: %d", n)
+	}
+	entries, err := lb.store.RevRange(ctx, lb.key, 0, n-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top %d: %w", n, err)
+	}
+	entries, err = lb.rankEntries(ctx, entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank top %d: %w", n, err)
+	}
+	return entries, nil
+}
+
+// Page returns up to limit members starting at the 0-based offset ordered from the best.
+func (lb *Leaderboard) Page(ctx context.Context, offset, limit int64) ([]Entry, error) {
+	entries, _, err := lb.page(ctx, offset, limit)
+	return entries, err
+}
+
+// TimedPage returns the entries of Page with the times their scores were achieved.
+func (lb *Leaderboard) TimedPage(ctx context.Context, offset, limit int64) ([]TimedEntry, error) {
+	entries, achieved, err := lb.page(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	timed := make([]TimedEntry, len(entries))
+	for i, entry := range entries {
+		timed[i].Entry = entry
+		if achieved != nil {
+			timed[i].Achieved = achieved[i]
+		}
+	}
+	return timed, nil
+}
+
+// page returns the entries of Page with the times of achievement decoded from their composite scores,
+// the times are nil without TieBreakByTime.
+func (lb *Leaderboard) page(ctx context.Context, offset, limit int64) ([]Entry, []time.Time, error) {
+	if offset < 0 {
+		return nil, nil, fmt.Errorf("invalid offset: %d", offset)
+	}
+	if limit < 1 {
+		return nil, nil, fmt.Errorf("invalid number of entries: %d", limit)
+	}
+	entries, err := lb.store.RevRange(ctx, lb.key, offset, offset+limit-1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get %d entries from offset %d: %w", limit, offset, err)
+	}
+	var achieved []time.Time
+	if lb.TieBreakByTime {
+		achieved = make([]time.Time, len(entries))
+		for i, entry := range entries {
+			achieved[i] = achievedTime(entry.Score)
+		}
+	}
+	entries, err = lb.rankEntries(ctx, entries)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rank %d entries from offset %d: %w", limit, offset, err)
+	}
+	return entries, achieved, nil
+}
+
+// Size returns the number of members on the leaderboard.
+func (lb *Leaderboard) Size(ctx context.Context) (int64, error) {
+	size, err := lb.store.Count(ctx, lb.key, math.Inf(-1), math.Inf(1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get size: %w", err)
+	}
+	return size, nil
+}
+
+// Rank returns the entry of the member.
+// Returns ErrMemberNotFound if the member is not on the leaderboard.
+func (lb *Leaderboard) Rank(ctx context.Context, member string) (Entry, error) {
+	entry, err := lb.store.RevRank(ctx, lb.key, member)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to get rank of %q: %w", member, err)
+	}
+	entries, err := lb.rankEntries(ctx, []Entry{entry})
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to rank %q: %w", member, err)
+	}
+	return entries[0], nil
+}
+
+// Around returns the window of up to n members above and n members below the member, including the member itself.
+// The window is cut at the edges of the leaderboard, so it is smaller for the members near the top or the bottom.
+// Returns ErrMemberNotFound if the member is not on the leaderboard.
+func (lb *Leaderboard) Around(ctx context.Context, member string, n int64) ([]Entry, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid number of entries: %d", n)
+	}
+	entry, err := lb.store.RevRank(ctx, lb.key, member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rank of %q: %w", member, err)
+	}
+	// ranks are 1-based, the store indexes are 0-based
+	start := max(entry.Rank-1-n, 0)
+	stop := entry.Rank - 1 + n
+	entries, err := lb.store.RevRange(ctx, lb.key, start, stop)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %d entries around %q: %w", n, member, err)
+	}
+	entries, err = lb.rankEntries(ctx, entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank %d entries around %q: %w", n, member, err)
+	}
+	return entries, nil
+}
+
+// CountBetween returns the number of members with a score in the range [min, max].
+func (lb *Leaderboard) CountBetween(ctx context.Context, min, max float64) (int64, error) {
+	if min > max {
+		return 0, fmt.Errorf("invalid score range: [%v, %v]", min, max)
+	}
+	storedMin, storedMax := lb.scoreBounds(min, max)
+	count, err := lb.store.Count(ctx, lb.key, storedMin, storedMax)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count members between %v and %v: %w", min, max, err)
+	}
+	return count, nil
+}
+
+// Remove removes the member from the leaderboard.
+// Returns ErrMemberNotFound if the member is not on the leaderboard.
+func (lb *Leaderboard) Remove(ctx context.Context, member string) error {
+	if err := lb.store.Remove(ctx, lb.key, member); err != nil {
+		return fmt.Errorf("failed to remove %q: %w", member, err)
+	}
+	return nil
+}
+
+// AddMany adds the entries to the leaderboard in one batch, the ranks of the entries are ignored.
+// The scores of the members already on the leaderboard are combined with the aggregate, or replaced if it is empty.
+// With TieBreakByTime, the scores are considered achieved now and AggregateSum is not supported,
+// as the sums of composite scores are meaningless.
+func (lb *Leaderboard) AddMany(ctx context.Context, entries []Entry, aggregate Aggregate) error {
+	timed := make([]TimedEntry, len(entries))
+	for i, entry := range entries {
+		timed[i].Entry = entry
+	}
+	return lb.AddManyTimed(ctx, timed, aggregate)
+}
+
+// AddManyTimed adds the entries like AddMany, with TieBreakByTime the scores are considered achieved
+// at the times of the entries, or now if they are zero. The times are ignored without TieBreakByTime.
+func (lb *Leaderboard) AddManyTimed(ctx context.Context, entries []TimedEntry, aggregate Aggregate) error {
+	if lb.TieBreakByTime && aggregate == AggregateSum {
+		return errors.New("scores with TieBreakByTime cannot be summed")
+	}
+	stored := make([]Entry, len(entries))
+	for i, entry := range entries {
+		score, err := lb.storedScoreAt(entry.Score, entry.Achieved)
+		if err != nil {
+			return fmt.Errorf("failed to add score of %q: %w", entry.Member, err)
+		}
+		stored[i] = Entry{Member: entry.Member, Score: score}
+	}
+	if err := lb.store.AddMany(ctx, lb.key, stored, aggregate); err != nil {
+		return fmt.Errorf("failed to add %d entries: %w", len(entries), err)
+	}
+	return nil
+}
+
+// WithKey returns a copy of the leaderboard with the same settings stored under another key of the same store.
+func (lb *Leaderboard) WithKey(key string) *Leaderboard {
+	copied := *lb
+	copied.key = key
+	return &copied
+}
+
+// Rename moves the leaderboard to another key, replacing the leaderboard stored there, and returns it.
+// Returns ErrKeyNotFound if the leaderboard is empty.
+func (lb *Leaderboard) Rename(ctx context.Context, newKey string) (*Leaderboard, error) {
+	if err := lb.store.Rename(ctx, lb.key, newKey); err != nil {
+		return nil, fmt.Errorf("failed to rename %q to %q: %w", lb.key, newKey, err)
+	}
+	return lb.WithKey(newKey), nil
+}
+
+// Expire sets the time to live of the leaderboard, after which all its members are removed.
+// It has no effect on an empty leaderboard.
+func (lb *Leaderboard) Expire(ctx context.Context, ttl time.Duration) error {
+	if err := lb.store.Expire(ctx, lb.key, ttl); err != nil {
+		return fmt.Errorf("failed to set time to live of %q: %w", lb.key, err)
+	}
+	return nil
+}
+
+// Persist removes the time to live of the leaderboard set by Expire.
+func (lb *Leaderboard) Persist(ctx context.Context) error {
+	if err := lb.store.Persist(ctx, lb.key); err != nil {
+		return fmt.Errorf("failed to remove time to live of %q: %w", lb.key, err)
+	}
+	return nil
+}
+
+// Clear removes all members from the leaderboard.
+func (lb *Leaderboard) Clear(ctx context.Context) error {
+	if err := lb.store.Delete(ctx, lb.key); err != nil {
+		return fmt.Errorf("failed to clear %q: %w", lb.key, err)
+	}
+	return nil
+}
@@ -0,0 +1,1120 @@
+package leaderboardtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+)
+
+// NewStoreFunc returns an empty store for a single test.
+type NewStoreFunc func(t *testing.T) leaderboard.Store
+
+// scoreboard is the data set of the cv02/04 exercise.
+var scoreboard = []leaderboard.Entry{
+	{Member: "Alfred", Score: 888},
+	{Member: "Tom", Score: 123},
+	{Member: "Bob", Score: 111},
+	{Member: "Alice", Score: 222},
+	{Member: "Theresa", Score: 333},
+	{Member: "Jim", Score: 444},
+	{Member: "Tim", Score: 555},
+	{Member: "Martin", Score: 666},
+	{Member: "Joanna", Score: 777},
+	{Member: "Garfield", Score: 889},
+	{Member: "Maurice", Score: 999},
+}
+
+// TestStore runs the conformance tests every Store implementation must pass.
+// The tests check the ordering, ranks and tie semantics of the store against the semantics of redis sorted sets.
+func TestStore(t *testing.T, newStore NewStoreFunc) {
+	TestStoreSkipping(t, newStore, nil)
+}
+
+// TestStoreSkipping runs the conformance tests the same as TestStore, but skips the tests named in skipped
+// with the reason given, e.g. {"Update": "scripts are not supported"} for a store backed by a server without scripts.
+func TestStoreSkipping(t *testing.T, newStore NewStoreFunc, skipped map[string]string) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, newStore NewStoreFunc)
+	}{
+		{"Top", testTop},
+		{"Rank", testRank},
+		{"Around", testAround},
+		{"Ties", testTies},
+		{"RankingModes", testRankingModes},
+		{"TieBreakByTime", testTieBreakByTime},
+		{"Increment", testIncrement},
+		{"CountBetween", testCountBetween},
+		{"Remove", testRemove},
+		{"RevRange", testRevRange},
+		{"Keys", testKeys},
+		{"Periodic", testPeriodic},
+		{"PeriodicTieBreakByTime", testPeriodicTieBreakByTime},
+		{"ChangeMany", testChangeMany},
+		{"Update", testUpdate},
+		{"UpdateTieBreakByTime", testUpdateTieBreakByTime},
+		{"AddMany", testAddMany},
+		{"TimedEntries", testTimedEntries},
+		{"Rename", testRename},
+		{"Stats", testStats},
+		{"Combine", testCombine},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if reason, ok := skipped[test.name]; ok {
+				t.Skip(reason)
+			}
+			test.run(t, newStore)
+		})
+	}
+}
+
+// newScoreboard returns a leaderboard filled with the data set of the exercise.
+func newScoreboard(t *testing.T, store leaderboard.Store) *leaderboard.Leaderboard {
+	t.Helper()
+	lb := leaderboard.New(store, "scoreboard")
+	for _, entry := range scoreboard {
+		if err := lb.Submit(context.Background(), entry.Member, entry.Score); err != nil {
+			t.Fatalf("Submit(%q, %v) failed: %v", entry.Member, entry.Score, err)
+		}
+	}
+	return lb
+}
+
+// assertEntries fails the test if the entries are not equal.
+func assertEntries(t *testing.T, name string, got, want []leaderboard.Entry) {
+	t.Helper()
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func testTop(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+
+	top, err := lb.Top(ctx, 3)
+	if err != nil {
+		t.Fatalf("Top(3) failed: %v", err)
+	}
+	assertEntries(t, "Top(3)", top, []leaderboard.Entry{
+		{Rank: 1, Member: "Maurice", Score: 999},
+		{Rank: 2, Member: "Garfield", Score: 889},
+		{Rank: 3, Member: "Alfred", Score: 888},
+	})
+
+	all, err := lb.Top(ctx, 100)
+	if err != nil {
+		t.Fatalf("Top(100) failed: %v", err)
+	}
+	if len(all) != len(scoreboard) {
+		t.Errorf("len(Top(100)) = %d, want %d", len(all), len(scoreboard))
+	}
+
+	if _, err := lb.Top(ctx, 0); err == nil {
+		t.Errorf("Top(0) succeeded, want error")
+	}
+
+	empty, err := leaderboard.New(newStore(t), "empty").Top(ctx, 3)
+	if err != nil {
+		t.Fatalf("Top(3) of an empty leaderboard failed: %v", err)
+	}
+	assertEntries(t, "Top(3) of an empty leaderboard", empty, nil)
+}
+
+func testRank(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+
+	alfred, err := lb.Rank(ctx, "Alfred")
+	if err != nil {
+		t.Fatalf("Rank(Alfred) failed: %v", err)
+	}
+	if want := (leaderboard.Entry{Rank: 3, Member: "Alfred", Score: 888}); alfred != want {
+		t.Errorf("Rank(Alfred) = %v, want %v", alfred, want)
+	}
+
+	bob, err := lb.Rank(ctx, "Bob")
+	if err != nil {
+		t.Fatalf("Rank(Bob) failed: %v", err)
+	}
+	if want := (leaderboard.Entry{Rank: 11, Member: "Bob", Score: 111}); bob != want {
+		t.Errorf("Rank(Bob) = %v, want %v", bob, want)
+	}
+
+	if _, err := lb.Rank(ctx, "Nobody"); !errors.Is(err, leaderboard.ErrMemberNotFound) {
+		t.Errorf("Rank(Nobody) error = %v, want %v", err, leaderboard.ErrMemberNotFound)
+	}
+}
+
+func testAround(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+
+	cases := []struct {
+		member string
+		n      int64
+		want   []leaderboard.Entry
+	}{
+		{"Alfred", 1, []leaderboard.Entry{
+			{Rank: 2, Member: "Garfield", Score: 889},
+			{Rank: 3, Member: "Alfred", Score: 888},
+			{Rank: 4, Member: "Joanna", Score: 777},
+		}},
+		{"Alfred", 0, []leaderboard.Entry{
+			{Rank: 3, Member: "Alfred", Score: 888},
+		}},
+		{"Maurice", 2, []leaderboard.Entry{
+			{Rank: 1, Member: "Maurice", Score: 999},
+			{Rank: 2, Member: "Garfield", Score: 889},
+			{Rank: 3, Member: "Alfred", Score: 888},
+		}},
+		{"Tom", 2, []leaderboard.Entry{
+			{Rank: 8, Member: "Theresa", Score: 333},
+			{Rank: 9, Member: "Alice", Score: 222},
+			{Rank: 10, Member: "Tom", Score: 123},
+			{Rank: 11, Member: "Bob", Score: 111},
+		}},
+	}
+	for _, c := range cases {
+		got, err := lb.Around(ctx, c.member, c.n)
+		if err != nil {
+			t.Fatalf("Around(%q, %d) failed: %v", c.member, c.n, err)
+		}
+		assertEntries(t, "Around", got, c.want)
+	}
+
+	if _, err := lb.Around(ctx, "Nobody", 1); !errors.Is(err, leaderboard.ErrMemberNotFound) {
+		t.Errorf("Around(Nobody) error = %v, want %v", err, leaderboard.ErrMemberNotFound)
+	}
+	if _, err := lb.Around(ctx, "Alfred", -1); err == nil {
+		t.Errorf("Around(Alfred, -1) succeeded, want error")
+	}
+}
+
+func testTies(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := leaderboard.New(newStore(t), "ties")
+	// equal scores are ordered lexicographically, so in the descending order the greater name comes first
+	for _, member := range []string{"b", "a", "c", "B", "aa"} {
+		if err := lb.Submit(ctx, member, 100); err != nil {
+			t.Fatalf("Submit(%q) failed: %v", member, err)
+		}
+	}
+	if err := lb.Submit(ctx, "z", 50); err != nil {
+		t.Fatalf("Submit(z) failed: %v", err)
+	}
+
+	top, err := lb.Top(ctx, 10)
+	if err != nil {
+		t.Fatalf("Top(10) failed: %v", err)
+	}
+	assertEntries(t, "Top(10)", top, []leaderboard.Entry{
+		{Rank: 1, Member: "c", Score: 100},
+		{Rank: 2, Member: "b", Score: 100},
+		{Rank: 3, Member: "aa", Score: 100},
+		{Rank: 4, Member: "a", Score: 100},
+		{Rank: 5, Member: "B", Score: 100},
+		{Rank: 6, Member: "z", Score: 50},
+	})
+
+	for _, entry := range top {
+		got, err := lb.Rank(ctx, entry.Member)
+		if err != nil {
+			t.Fatalf("Rank(%q) failed: %v", entry.Member, err)
+		}
+		if got != entry {
+			t.Errorf("Rank(%q) = %v, want %v", entry.Member, got, entry)
+		}
+	}
+}
+
+func testRankingModes(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := leaderboard.New(newStore(t), "modes")
+	for _, entry := range []leaderboard.Entry{
+		{Member: "a", Score: 100},
+		{Member: "b", Score: 90},
+		{Member: "c", Score: 90},
+		{Member: "d", Score: 80},
+		{Member: "e", Score: 70},
+	} {
+		if err := lb.Submit(ctx, entry.Member, entry.Score); err != nil {
+			t.Fatalf("Submit(%q) failed: %v", entry.Member, err)
+		}
+	}
+
+	cases := []struct {
+		mode   leaderboard.RankingMode
+		top    []int64
+		around []int64
+	}{
+		{leaderboard.RankOrdinal, []int64{1, 2, 3, 4, 5}, []int64{2, 3, 4}},
+		{leaderboard.RankStandard, []int64{1, 2, 2, 4, 5}, []int64{2, 2, 4}},
+		{leaderboard.RankDense, []int64{1, 2, 2, 3, 4}, []int64{2, 2, 3}},
+	}
+	for _, c := range cases {
+		lb.RankingMode = c.mode
+		top, err := lb.Top(ctx, 10)
+		if err != nil {
+			t.Fatalf("Top(10) in %v mode failed: %v", c.mode, err)
+		}
+		if got := ranksOf(top); !reflect.DeepEqual(got, c.top) {
+			t.Errorf("ranks of Top(10) in %v mode = %v, want %v", c.mode, got, c.top)
+		}
+		// the window starts in the middle of the tie of c and b
+		around, err := lb.Around(ctx, "b", 1)
+		if err != nil {
+			t.Fatalf("Around(b, 1) in %v mode failed: %v", c.mode, err)
+		}
+		if got := ranksOf(around); !reflect.DeepEqual(got, c.around) {
+			t.Errorf("ranks of Around(b, 1) in %v mode = %v, want %v", c.mode, got, c.around)
+		}
+		for _, entry := range top {
+			got, err := lb.Rank(ctx, entry.Member)
+			if err != nil {
+				t.Fatalf("Rank(%q) in %v mode failed: %v", entry.Member, c.mode, err)
+			}
+			if got != entry {
+				t.Errorf("Rank(%q) in %v mode = %v, want %v", entry.Member, c.mode, got, entry)
+			}
+		}
+	}
+}
+
+func testTieBreakByTime(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := leaderboard.New(newStore(t), "tiebreak")
+	lb.TieBreakByTime = true
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	lb.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// Alfred reached 888 first, so he ranks above Bob regardless of the names
+	if err := lb.Submit(ctx, "Alfred", 888); err != nil {
+		t.Fatalf("Submit(Alfred) failed: %v", err)
+	}
+	if err := lb.Submit(ctx, "Bob", 888); err != nil {
+		t.Fatalf("Submit(Bob) failed: %v", err)
+	}
+	if err := lb.Submit(ctx, "Carl", 880); err != nil {
+		t.Fatalf("Submit(Carl) failed: %v", err)
+	}
+	score, err := lb.Increment(ctx, "Carl", 8)
+	if err != nil {
+		t.Fatalf("Increment(Carl, 8) failed: %v", err)
+	}
+	if score != 888 {
+		t.Errorf("Increment(Carl, 8) = %v, want 888", score)
+	}
+	if err := lb.Submit(ctx, "Dave", 1); err != nil {
+		t.Fatalf("Submit(Dave) failed: %v", err)
+	}
+
+	top, err := lb.Top(ctx, 10)
+	if err != nil {
+		t.Fatalf("Top(10) failed: %v", err)
+	}
+	assertEntries(t, "Top(10)", top, []leaderboard.Entry{
+		{Rank: 1, Member: "Alfred", Score: 888},
+		{Rank: 2, Member: "Bob", Score: 888},
+		{Rank: 3, Member: "Carl", Score: 888},
+		{Rank: 4, Member: "Dave", Score: 1},
+	})
+
+	count, err := lb.CountBetween(ctx, 888, 888)
+	if err != nil {
+		t.Fatalf("CountBetween(888, 888) failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("CountBetween(888, 888) = %d, want 3", count)
+	}
+
+	lb.RankingMode = leaderboard.RankStandard
+	dave, err := lb.Rank(ctx, "Dave")
+	if err != nil {
+		t.Fatalf("Rank(Dave) failed: %v", err)
+	}
+	if want := (leaderboard.Entry{Rank: 4, Member: "Dave", Score: 1}); dave != want {
+		t.Errorf("Rank(Dave) = %v, want %v", dave, want)
+	}
+	carl, err := lb.Rank(ctx, "Carl")
+	if err != nil {
+		t.Fatalf("Rank(Carl) failed: %v", err)
+	}
+	if want := (leaderboard.Entry{Rank: 1, Member: "Carl", Score: 888}); carl != want {
+		t.Errorf("Rank(Carl) = %v, want %v", carl, want)
+	}
+
+	if err := lb.Submit(ctx, "Eve", 1.5); !errors.Is(err, leaderboard.ErrInvalidScore) {
+		t.Errorf("Submit(Eve, 1.5) = %v, want ErrInvalidScore", err)
+	}
+	if _, err := lb.Update(ctx, "Eve", leaderboard.MaxTieBreakScore+1, leaderboard.UpdateSet); !errors.Is(err, leaderboard.ErrInvalidScore) {
+		t.Errorf("Update(Eve, MaxTieBreakScore+1) = %v, want ErrInvalidScore", err)
+	}
+}
+
+// ranksOf returns the ranks of the entries.
+func ranksOf(entries []leaderboard.Entry) []int64 {
+	ranks := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		ranks = append(ranks, entry.Rank)
+	}
+	return ranks
+}
+
+func testIncrement(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+
+	score, err := lb.Increment(ctx, "Alfred", 12)
+	if err != nil {
+		t.Fatalf("Increment(Alfred, 12) failed: %v", err)
+	}
+	if score != 900 {
+		t.Errorf("Increment(Alfred, 12) = %v, want 900", score)
+	}
+	alfred, err := lb.Rank(ctx, "Alfred")
+	if err != nil {
+		t.Fatalf("Rank(Alfred) failed: %v", err)
+	}
+	if alfred.Rank != 2 {
+		t.Errorf("Rank(Alfred).Rank = %d after increment, want 2", alfred.Rank)
+	}
+
+	score, err = lb.Increment(ctx, "Newbie", -5)
+	if err != nil {
+		t.Fatalf("Increment(Newbie, -5) failed: %v", err)
+	}
+	if score != -5 {
+		t.Errorf("Increment(Newbie, -5) = %v, want -5", score)
+	}
+	newbie, err := lb.Rank(ctx, "Newbie")
+	if err != nil {
+		t.Fatalf("Rank(Newbie) failed: %v", err)
+	}
+	if newbie.Rank != int64(len(scoreboard))+1 {
+		t.Errorf("Rank(Newbie).Rank = %d, want %d", newbie.Rank, len(scoreboard)+1)
+	}
+}
+
+func testCountBetween(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+
+	cases := []struct {
+		min, max float64
+		want     int64
+	}{
+		{0, 100, 0},
+		{0, 999, 11},
+		{111, 222, 3},
+		{850, 999, 3},
+		{888, 888, 1},
+		{1000, 2000, 0},
+	}
+	for _, c := range cases {
+		got, err := lb.CountBetween(ctx, c.min, c.max)
+		if err != nil {
+			t.Fatalf("CountBetween(%v, %v) failed: %v", c.min, c.max, err)
+		}
+		if got != c.want {
+			t.Errorf("CountBetween(%v, %v) = %d, want %d", c.min, c.max, got, c.want)
+		}
+	}
+
+	if _, err := lb.CountBetween(ctx, 10, 1); err == nil {
+		t.Errorf("CountBetween(10, 1) succeeded, want error")
+	}
+}
+
+func testRemove(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+
+	if err := lb.Remove(ctx, "Maurice"); err != nil {
+		t.Fatalf("Remove(Maurice) failed: %v", err)
+	}
+	if _, err := lb.Rank(ctx, "Maurice"); !errors.Is(err, leaderboard.ErrMemberNotFound) {
+		t.Errorf("Rank(Maurice) error = %v after removal, want %v", err, leaderboard.ErrMemberNotFound)
+	}
+	garfield, err := lb.Rank(ctx, "Garfield")
+	if err != nil {
+		t.Fatalf("Rank(Garfield) failed: %v", err)
+	}
+	if garfield.Rank != 1 {
+		t.Errorf("Rank(Garfield).Rank = %d after removal of Maurice, want 1", garfield.Rank)
+	}
+
+	if err := lb.Remove(ctx, "Maurice"); !errors.Is(err, leaderboard.ErrMemberNotFound) {
+		t.Errorf("second Remove(Maurice) error = %v, want %v", err, leaderboard.ErrMemberNotFound)
+	}
+}
+
+func testRevRange(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := newStore(t)
+	newScoreboard(t, store)
+
+	cases := []struct {
+		start, stop int64
+		want        []leaderboard.Entry
+	}{
+		{0, 1, []leaderboard.Entry{
+			{Rank: 1, Member: "Maurice", Score: 999},
+			{Rank: 2, Member: "Garfield", Score: 889},
+		}},
+		{9, 20, []leaderboard.Entry{
+			{Rank: 10, Member: "Tom", Score: 123},
+			{Rank: 11, Member: "Bob", Score: 111},
+		}},
+		{-2, -1, []leaderboard.Entry{
+			{Rank: 10, Member: "Tom", Score: 123},
+			{Rank: 11, Member: "Bob", Score: 111},
+		}},
+		{-100, 0, []leaderboard.Entry{
+			{Rank: 1, Member: "Maurice", Score: 999},
+		}},
+		{5, 4, nil},
+		{11, 12, nil},
+	}
+	for _, c := range cases {
+		got, err := store.RevRange(ctx, "scoreboard", c.start, c.stop)
+		if err != nil {
+			t.Fatalf("RevRange(%d, %d) failed: %v", c.start, c.stop, err)
+		}
+		assertEntries(t, "RevRange", got, c.want)
+	}
+}
+
+func testKeys(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := newStore(t)
+	first := leaderboard.New(store, "first")
+	second := leaderboard.New(store, "second")
+
+	if err := first.Submit(ctx, "Alfred", 1); err != nil {
+		t.Fatalf("Submit(Alfred) failed: %v", err)
+	}
+	if err := second.Submit(ctx, "Alfred", 2); err != nil {
+		t.Fatalf("Submit(Alfred) failed: %v", err)
+	}
+	if err := first.Remove(ctx, "Alfred"); err != nil {
+		t.Fatalf("Remove(Alfred) failed: %v", err)
+	}
+	alfred, err := second.Rank(ctx, "Alfred")
+	if err != nil {
+		t.Fatalf("Rank(Alfred) of the second leaderboard failed: %v", err)
+	}
+	if alfred.Score != 2 {
+		t.Errorf("Rank(Alfred).Score = %v, want 2", alfred.Score)
+	}
+}
+
+func testPeriodic(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	pb := leaderboard.NewPeriodic(newStore(t), "scoreboard")
+	friday := time.Date(2026, time.October, 16, 20, 0, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+	now := friday
+	pb.Now = func() time.Time { return now }
+
+	if got, want := pb.Key(leaderboard.Daily, saturday), "scoreboard:daily:2026-10-17"; got != want {
+		t.Errorf("Key(Daily) = %q, want %q", got, want)
+	}
+	if got, want := pb.Key(leaderboard.Weekly, saturday), "scoreboard:weekly:2026-W42"; got != want {
+		t.Errorf("Key(Weekly) = %q, want %q", got, want)
+	}
+	if got, want := pb.Key(leaderboard.AllTime, saturday), "scoreboard"; got != want {
+		t.Errorf("Key(AllTime) = %q, want %q", got, want)
+	}
+
+	increments := []struct {
+		at     time.Time
+		member string
+		delta  float64
+	}{
+		{friday, "Alfred", 10},
+		{friday, "Bob", 5},
+		{saturday, "Alfred", 3},
+		{saturday, "Bob", 20},
+	}
+	for _, increment := range increments {
+		now = increment.at
+		if err := pb.Increment(ctx, increment.member, increment.delta); err != nil {
+			t.Fatalf("Increment(%q, %v) failed: %v", increment.member, increment.delta, err)
+		}
+	}
+
+	boards := []struct {
+		name string
+		lb   *leaderboard.Leaderboard
+		want []leaderboard.Entry
+	}{
+		{"daily friday", pb.Board(leaderboard.Daily, friday), []leaderboard.Entry{
+			{Rank: 1, Member: "Alfred", Score: 10},
+			{Rank: 2, Member: "Bob", Score: 5},
+		}},
+		{"daily saturday", pb.Current(leaderboard.Daily), []leaderboard.Entry{
+			{Rank: 1, Member: "Bob", Score: 20},
+			{Rank: 2, Member: "Alfred", Score: 3},
+		}},
+		{"weekly", pb.Current(leaderboard.Weekly), []leaderboard.Entry{
+			{Rank: 1, Member: "Bob", Score: 25},
+			{Rank: 2, Member: "Alfred", Score: 13},
+		}},
+		{"all-time", pb.Current(leaderboard.AllTime), []leaderboard.Entry{
+			{Rank: 1, Member: "Bob", Score: 25},
+			{Rank: 2, Member: "Alfred", Score: 13},
+		}},
+	}
+	for _, board := range boards {
+		top, err := board.lb.Top(ctx, 10)
+		if err != nil {
+			t.Fatalf("Top(10) of %s failed: %v", board.name, err)
+		}
+		assertEntries(t, "Top(10) of "+board.name, top, board.want)
+	}
+
+	ranges := []struct {
+		aggregate leaderboard.Aggregate
+		want      []leaderboard.Entry
+	}{
+		{leaderboard.AggregateSum, []leaderboard.Entry{
+			{Rank: 1, Member: "Bob", Score: 25},
+			{Rank: 2, Member: "Alfred", Score: 13},
+		}},
+		{leaderboard.AggregateMax, []leaderboard.Entry{
+			{Rank: 1, Member: "Bob", Score: 20},
+			{Rank: 2, Member: "Alfred", Score: 10},
+		}},
+		{leaderboard.AggregateMin, []leaderboard.Entry{
+			{Rank: 1, Member: "Bob", Score: 5},
+			{Rank: 2, Member: "Alfred", Score: 3},
+		}},
+	}
+	for _, r := range ranges {
+		lb, err := pb.Range(ctx, friday, saturday, r.aggregate)
+		if err != nil {
+			t.Fatalf("Range(%v) failed: %v", r.aggregate, err)
+		}
+		top, err := lb.Top(ctx, 10)
+		if err != nil {
+			t.Fatalf("Top(10) of Range(%v) failed: %v", r.aggregate, err)
+		}
+		assertEntries(t, fmt.Sprintf("Top(10) of Range(%v)", r.aggregate), top, r.want)
+	}
+
+	if _, err := pb.Range(ctx, saturday, friday, leaderboard.AggregateSum); err == nil {
+		t.Errorf("Range(saturday, friday) succeeded, want error")
+	}
+}
+
+func testUpdate(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+	lb.Bounds = &leaderboard.Bounds{Min: 0, Max: 999}
+
+	cases := []struct {
+		member string
+		value  float64
+		mode   leaderboard.UpdateMode
+		want   leaderboard.UpdateResult
+	}{
+		// the best score is kept
+		{"Alfred", 800, leaderboard.UpdateBest, leaderboard.UpdateResult{
+			Old: leaderboard.Entry{Rank: 3, Member: "Alfred", Score: 888},
+			New: leaderboard.Entry{Rank: 3, Member: "Alfred", Score: 888},
+		}},
+		{"Alfred", 950, leaderboard.UpdateBest, leaderboard.UpdateResult{
+			Old:     leaderboard.Entry{Rank: 3, Member: "Alfred", Score: 888},
+			New:     leaderboard.Entry{Rank: 2, Member: "Alfred", Score: 950},
+			Updated: true,
+		}},
+		// the scores are capped at the maximum
+		{"Bob", 2000, leaderboard.UpdateSet, leaderboard.UpdateResult{
+			Old:     leaderboard.Entry{Rank: 11, Member: "Bob", Score: 111},
+			New:     leaderboard.Entry{Rank: 2, Member: "Bob", Score: 999},
+			Updated: true,
+		}},
+		{"Tom", 900, leaderboard.UpdateIncrement, leaderboard.UpdateResult{
+			Old:     leaderboard.Entry{Rank: 11, Member: "Tom", Score: 123},
+			New:     leaderboard.Entry{Rank: 1, Member: "Tom", Score: 999},
+			Updated: true,
+		}},
+		// new members have no old entry
+		{"Newbie", 1, leaderboard.UpdateBest, leaderboard.UpdateResult{
+			New:     leaderboard.Entry{Rank: 12, Member: "Newbie", Score: 1},
+			Updated: true,
+		}},
+	}
+	for _, c := range cases {
+		got, err := lb.Update(ctx, c.member, c.value, c.mode)
+		if err != nil {
+			t.Fatalf("Update(%q, %v, %v) failed: %v", c.member, c.value, c.mode, err)
+		}
+		if got != c.want {
+			t.Errorf("Update(%q, %v, %v) = %+v, want %+v", c.member, c.value, c.mode, got, c.want)
+		}
+	}
+
+	// the scores below the minimum are rejected
+	got, err := lb.Update(ctx, "Alice", -300, leaderboard.UpdateIncrement)
+	if !errors.Is(err, leaderboard.ErrScoreBelowMin) {
+		t.Errorf("Update(Alice, -300, incr) error = %v, want %v", err, leaderboard.ErrScoreBelowMin)
+	}
+	if got.Updated {
+		t.Errorf("Update(Alice, -300, incr).Updated = true, want false")
+	}
+	alice, err := lb.Rank(ctx, "Alice")
+	if err != nil {
+		t.Fatalf("Rank(Alice) failed: %v", err)
+	}
+	if alice.Score != 222 {
+		t.Errorf("Rank(Alice).Score = %v after rejected update, want 222", alice.Score)
+	}
+	if _, err := lb.Update(ctx, "Ghost", -1, leaderboard.UpdateSet); !errors.Is(err, leaderboard.ErrScoreBelowMin) {
+		t.Errorf("Update(Ghost, -1, set) error = %v, want %v", err, leaderboard.ErrScoreBelowMin)
+	}
+	if _, err := lb.Rank(ctx, "Ghost"); !errors.Is(err, leaderboard.ErrMemberNotFound) {
+		t.Errorf("Rank(Ghost) error = %v after rejected update, want %v", err, leaderboard.ErrMemberNotFound)
+	}
+}
+
+func testUpdateTieBreakByTime(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := leaderboard.New(newStore(t), "tiebreak")
+	lb.TieBreakByTime = true
+	lb.Bounds = &leaderboard.Bounds{Min: 0, Max: 999}
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	lb.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, member := range []string{"Alfred", "Bob"} {
+		if _, err := lb.Update(ctx, member, 500, leaderboard.UpdateSet); err != nil {
+			t.Fatalf("Update(%q, 500, set) failed: %v", member, err)
+		}
+	}
+	// reaching the same score again must not lose the earlier time of achievement
+	result, err := lb.Update(ctx, "Alfred", 500, leaderboard.UpdateBest)
+	if err != nil {
+		t.Fatalf("Update(Alfred, 500, best) failed: %v", err)
+	}
+	if want := (leaderboard.Entry{Rank: 1, Member: "Alfred", Score: 500}); result.Updated || result.New != want {
+		t.Errorf("Update(Alfred, 500, best) = %+v, want not updated %+v", result, want)
+	}
+	result, err = lb.Update(ctx, "Bob", 600, leaderboard.UpdateIncrement)
+	if err != nil {
+		t.Fatalf("Update(Bob, 600, incr) failed: %v", err)
+	}
+	if want := (leaderboard.Entry{Rank: 1, Member: "Bob", Score: 999}); !result.Updated || result.New != want {
+		t.Errorf("Update(Bob, 600, incr) = %+v, want updated %+v", result, want)
+	}
+}
+
+func testAddMany(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	batch := []leaderboard.Entry{
+		{Member: "Alfred", Score: 800},
+		{Member: "Maurice", Score: 1000},
+		{Member: "Zoe", Score: 50},
+	}
+	tests := []struct {
+		aggregate leaderboard.Aggregate
+		want      map[string]float64
+	}{
+		{"", map[string]float64{"Alfred": 800, "Maurice": 1000, "Zoe": 50}},
+		{leaderboard.AggregateMax, map[string]float64{"Alfred": 888, "Maurice": 1000, "Zoe": 50}},
+		{leaderboard.AggregateMin, map[string]float64{"Alfred": 800, "Maurice": 999, "Zoe": 50}},
+		{leaderboard.AggregateSum, map[string]float64{"Alfred": 1688, "Maurice": 1999, "Zoe": 50}},
+	}
+	for _, tt := range tests {
+		lb := newScoreboard(t, newStore(t))
+		if err := lb.AddMany(ctx, batch, tt.aggregate); err != nil {
+			t.Fatalf("AddMany(%q) failed: %v", tt.aggregate, err)
+		}
+		for member, want := range tt.want {
+			entry, err := lb.Rank(ctx, member)
+			if err != nil {
+				t.Fatalf("AddMany(%q): Rank(%s) failed: %v", tt.aggregate, member, err)
+			}
+			if entry.Score != want {
+				t.Errorf("AddMany(%q): Rank(%s).Score = %v, want %v", tt.aggregate, member, entry.Score, want)
+			}
+		}
+		size, err := lb.Size(ctx)
+		if err != nil {
+			t.Fatalf("Size() failed: %v", err)
+		}
+		if size != int64(len(scoreboard)+1) {
+			t.Errorf("AddMany(%q): Size() = %d, want %d", tt.aggregate, size, len(scoreboard)+1)
+		}
+	}
+
+	tieBreak := leaderboard.New(newStore(t), "tiebreak")
+	tieBreak.TieBreakByTime = true
+	if err := tieBreak.AddMany(ctx, batch, leaderboard.AggregateSum); err == nil {
+		t.Errorf("AddMany(SUM) with TieBreakByTime succeeded, want error")
+	}
+}
+
+func testTimedEntries(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := newStore(t)
+	lb := leaderboard.New(store, "tiebreak")
+	lb.TieBreakByTime = true
+	start := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	now := start
+	lb.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	// Bob reached 10 first, so he ranks above Alfred
+	for _, member := range []string{"Bob", "Alfred"} {
+		if err := lb.Submit(ctx, member, 10); err != nil {
+			t.Fatalf("Submit(%s) failed: %v", member, err)
+		}
+	}
+	timed, err := lb.TimedPage(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("TimedPage() failed: %v", err)
+	}
+	want := []leaderboard.TimedEntry{
+		{Entry: leaderboard.Entry{Rank: 1, Member: "Bob", Score: 10}, Achieved: start.Add(time.Second)},
+		{Entry: leaderboard.Entry{Rank: 2, Member: "Alfred", Score: 10}, Achieved: start.Add(2 * time.Second)},
+	}
+	if !reflect.DeepEqual(timed, want) {
+		t.Fatalf("TimedPage() = %v, want %v", timed, want)
+	}
+
+	// the copy keeps the order of the ties even though the entries are added later in the reverse order
+	copied := lb.WithKey("tiebreak:copy")
+	slices.Reverse(timed)
+	if err := copied.AddManyTimed(ctx, timed, ""); err != nil {
+		t.Fatalf("AddManyTimed() failed: %v", err)
+	}
+	copiedTimed, err := copied.TimedPage(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("TimedPage() of the copy failed: %v", err)
+	}
+	if !reflect.DeepEqual(copiedTimed, want) {
+		t.Errorf("TimedPage() of the copy = %v, want %v", copiedTimed, want)
+	}
+
+	// the times are zero without TieBreakByTime
+	plain := newScoreboard(t, store)
+	plainTimed, err := plain.TimedPage(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("TimedPage() without TieBreakByTime failed: %v", err)
+	}
+	if len(plainTimed) != 1 || !plainTimed[0].Achieved.IsZero() {
+		t.Errorf("TimedPage() without TieBreakByTime = %v, want one entry without time", plainTimed)
+	}
+}
+
+func testRename(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := newStore(t)
+	lb := newScoreboard(t, store)
+	other := leaderboard.New(store, "other")
+	if err := other.Submit(ctx, "Zoe", 1); err != nil {
+		t.Fatalf("Submit(Zoe) failed: %v", err)
+	}
+
+	renamed, err := lb.Rename(ctx, "other")
+	if err != nil {
+		t.Fatalf("Rename(other) failed: %v", err)
+	}
+	if renamed.Key() != "other" {
+		t.Errorf("Rename(other).Key() = %q, want %q", renamed.Key(), "other")
+	}
+	top, err := renamed.Top(ctx, 1)
+	if err != nil {
+		t.Fatalf("Top(1) failed: %v", err)
+	}
+	assertEntries(t, "Top(1) after Rename", top, []leaderboard.Entry{{Rank: 1, Member: "Maurice", Score: 999}})
+	if _, err := renamed.Rank(ctx, "Zoe"); !errors.Is(err, leaderboard.ErrMemberNotFound) {
+		t.Errorf("Rank(Zoe) error = %v after Rename replaced the leaderboard, want %v", err, leaderboard.ErrMemberNotFound)
+	}
+	size, err := lb.Size(ctx)
+	if err != nil {
+		t.Fatalf("Size() failed: %v", err)
+	}
+	if size != 0 {
+		t.Errorf("Size() of the renamed leaderboard = %d, want 0", size)
+	}
+
+	if _, err := lb.Rename(ctx, "other"); !errors.Is(err, leaderboard.ErrKeyNotFound) {
+		t.Errorf("Rename() of an empty leaderboard error = %v, want %v", err, leaderboard.ErrKeyNotFound)
+	}
+	if err := renamed.Clear(ctx); err != nil {
+		t.Fatalf("Clear() failed: %v", err)
+	}
+	if size, err := renamed.Size(ctx); err != nil || size != 0 {
+		t.Errorf("Size() after Clear() = %d, %v, want 0", size, err)
+	}
+}
+
+func testStats(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	lb := newScoreboard(t, newStore(t))
+
+	standing, err := lb.Percentile(ctx, "Alfred")
+	if err != nil {
+		t.Fatalf("Percentile(Alfred) failed: %v", err)
+	}
+	want := leaderboard.Standing{Member: "Alfred", Score: 888, Size: 11, Above: 2, Below: 8}
+	if standing != want {
+		t.Errorf("Percentile(Alfred) = %+v, want %+v", standing, want)
+	}
+	if top := standing.TopPercent(); math.Abs(top-300.0/11) > 1e-9 {
+		t.Errorf("Percentile(Alfred).TopPercent() = %v, want %v", top, 300.0/11)
+	}
+	if _, err := lb.Percentile(ctx, "Nobody"); !errors.Is(err, leaderboard.ErrMemberNotFound) {
+		t.Errorf("Percentile(Nobody) error = %v, want %v", err, leaderboard.ErrMemberNotFound)
+	}
+
+	percentiles := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 111},
+		{10, 123},
+		{50, 555},
+		{90, 889},
+		{100, 999},
+	}
+	for _, tt := range percentiles {
+		got, err := lb.ScoreAtPercentile(ctx, tt.p)
+		if err != nil {
+			t.Fatalf("ScoreAtPercentile(%v) failed: %v", tt.p, err)
+		}
+		if got != tt.want {
+			t.Errorf("ScoreAtPercentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if _, err := lb.ScoreAtPercentile(ctx, 101); err == nil {
+		t.Errorf("ScoreAtPercentile(101) succeeded, want error")
+	}
+	empty := lb.WithKey("empty")
+	if _, err := empty.ScoreAtPercentile(ctx, 50); !errors.Is(err, leaderboard.ErrKeyNotFound) {
+		t.Errorf("ScoreAtPercentile(50) of an empty leaderboard error = %v, want %v", err, leaderboard.ErrKeyNotFound)
+	}
+
+	// the buckets are half-open, so Alfred's 888 falls into the last one
+	buckets, err := lb.Histogram(ctx, []float64{100, 500, 888})
+	if err != nil {
+		t.Fatalf("Histogram() failed: %v", err)
+	}
+	wantBuckets := []leaderboard.Bucket{
+		{Min: math.Inf(-1), Max: 100, Count: 0},
+		{Min: 100, Max: 500, Count: 5},
+		{Min: 500, Max: 888, Count: 3},
+		{Min: 888, Max: math.Inf(1), Count: 3},
+	}
+	if !reflect.DeepEqual(buckets, wantBuckets) {
+		t.Errorf("Histogram() = %v, want %v", buckets, wantBuckets)
+	}
+	if _, err := lb.Histogram(ctx, []float64{500, 100}); err == nil {
+		t.Errorf("Histogram() with decreasing boundaries succeeded, want error")
+	}
+
+	tieBreak := leaderboard.New(newStore(t), "tiebreak")
+	tieBreak.TieBreakByTime = true
+	for _, entry := range scoreboard {
+		if err := tieBreak.Submit(ctx, entry.Member, entry.Score); err != nil {
+			t.Fatalf("Submit(%q, %v) failed: %v", entry.Member, entry.Score, err)
+		}
+	}
+	if err := tieBreak.Submit(ctx, "Bob", 888); err != nil {
+		t.Fatalf("Submit(Bob) failed: %v", err)
+	}
+	standing, err = tieBreak.Percentile(ctx, "Bob")
+	if err != nil {
+		t.Fatalf("Percentile(Bob) with TieBreakByTime failed: %v", err)
+	}
+	want = leaderboard.Standing{Member: "Bob", Score: 888, Size: 11, Above: 2, Below: 7}
+	if standing != want {
+		t.Errorf("Percentile(Bob) with TieBreakByTime = %+v, want %+v", standing, want)
+	}
+	buckets, err = tieBreak.Histogram(ctx, []float64{500, 888})
+	if err != nil {
+		t.Fatalf("Histogram() with TieBreakByTime failed: %v", err)
+	}
+	if buckets[1].Count != 3 || buckets[2].Count != 4 {
+		t.Errorf("Histogram() with TieBreakByTime = %v, want counts 3 and 4 in the upper buckets", buckets)
+	}
+	if got, err := tieBreak.ScoreAtPercentile(ctx, 100); err != nil || got != 999 {
+		t.Errorf("ScoreAtPercentile(100) with TieBreakByTime = %v, %v, want 999", got, err)
+	}
+}
+
+func testCombine(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := newStore(t)
+	duel := leaderboard.New(store, "{modes}:duel")
+	team := leaderboard.New(store, "{modes}:team")
+	for _, entry := range []leaderboard.Entry{{Member: "Alfred", Score: 10}, {Member: "Bob", Score: 20}, {Member: "Carl", Score: 30}} {
+		if err := duel.Submit(ctx, entry.Member, entry.Score); err != nil {
+			t.Fatalf("Submit(%q) failed: %v", entry.Member, err)
+		}
+	}
+	for _, entry := range []leaderboard.Entry{{Member: "Bob", Score: 5}, {Member: "Carl", Score: 1}, {Member: "Dave", Score: 40}} {
+		if err := team.Submit(ctx, entry.Member, entry.Score); err != nil {
+			t.Fatalf("Submit(%q) failed: %v", entry.Member, err)
+		}
+	}
+
+	cases := []struct {
+		name      string
+		intersect bool
+		aggregate leaderboard.Aggregate
+		weights   []float64
+		want      []leaderboard.Entry
+	}{
+		{"weighted union", false, "", []float64{2, 1}, []leaderboard.Entry{
+			{Rank: 1, Member: "Carl", Score: 61},
+			{Rank: 2, Member: "Bob", Score: 45},
+			{Rank: 3, Member: "Dave", Score: 40},
+			{Rank: 4, Member: "Alfred", Score: 20},
+		}},
+		{"union max", false, leaderboard.AggregateMax, nil, []leaderboard.Entry{
+			{Rank: 1, Member: "Dave", Score: 40},
+			{Rank: 2, Member: "Carl", Score: 30},
+			{Rank: 3, Member: "Bob", Score: 20},
+			{Rank: 4, Member: "Alfred", Score: 10},
+		}},
+		{"intersection min", true, leaderboard.AggregateMin, nil, []leaderboard.Entry{
+			{Rank: 1, Member: "Bob", Score: 5},
+			{Rank: 2, Member: "Carl", Score: 1},
+		}},
+	}
+	for _, tt := range cases {
+		combination := leaderboard.NewCombination(duel, team)
+		combination.Intersect = tt.intersect
+		combination.Aggregate = tt.aggregate
+		combination.Weights = tt.weights
+
+		stored, err := combination.Store(ctx, "{modes}:combined")
+		if err != nil {
+			t.Fatalf("Store() of %s failed: %v", tt.name, err)
+		}
+		computed, err := combination.Compute(ctx, "combined")
+		if err != nil {
+			t.Fatalf("Compute() of %s failed: %v", tt.name, err)
+		}
+		for _, lb := range []*leaderboard.Leaderboard{stored, computed} {
+			top, err := lb.Top(ctx, 10)
+			if err != nil {
+				t.Fatalf("Top(10) of %s failed: %v", tt.name, err)
+			}
+			assertEntries(t, fmt.Sprintf("Top(10) of %s %q", tt.name, lb.Key()), top, tt.want)
+			bob, err := lb.Rank(ctx, "Bob")
+			if err != nil {
+				t.Fatalf("Rank(Bob) of %s failed: %v", tt.name, err)
+			}
+			for _, entry := range tt.want {
+				if entry.Member == "Bob" && bob != entry {
+					t.Errorf("Rank(Bob) of %s %q = %v, want %v", tt.name, lb.Key(), bob, entry)
+				}
+			}
+		}
+	}
+
+	if _, err := leaderboard.NewCombination().Store(ctx, "{modes}:combined"); err == nil {
+		t.Errorf("Store() of no leaderboards succeeded, want error")
+	}
+	weighted := leaderboard.NewCombination(duel, team)
+	weighted.Weights = []float64{1}
+	if _, err := weighted.Store(ctx, "{modes}:combined"); err == nil {
+		t.Errorf("Store() with 1 weight for 2 leaderboards succeeded, want error")
+	}
+	tieBreak := duel.WithKey("{modes}:tiebreak")
+	tieBreak.TieBreakByTime = true
+	if _, err := leaderboard.NewCombination(tieBreak).Compute(ctx, "combined"); err == nil {
+		t.Errorf("Compute() summing scores with TieBreakByTime succeeded, want error")
+	}
+}
+
+func testPeriodicTieBreakByTime(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	pb := leaderboard.NewPeriodic(newStore(t), "scoreboard", leaderboard.AllTime, leaderboard.Daily)
+	pb.TieBreakByTime = true
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+	pb.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// Bob reaches 10 after Alfred on both leaderboards, so he ranks below him
+	if err := pb.Submit(ctx, "Bob", 7); err != nil {
+		t.Fatalf("Submit(Bob) failed: %v", err)
+	}
+	if err := pb.Submit(ctx, "Alfred", 10); err != nil {
+		t.Fatalf("Submit(Alfred) failed: %v", err)
+	}
+	if err := pb.Increment(ctx, "Bob", 3); err != nil {
+		t.Fatalf("Increment(Bob, 3) failed: %v", err)
+	}
+	for _, period := range pb.Periods() {
+		top, err := pb.Current(period).Top(ctx, 10)
+		if err != nil {
+			t.Fatalf("Top(10) of %v failed: %v", period, err)
+		}
+		assertEntries(t, fmt.Sprintf("Top(10) of %v", period), top, []leaderboard.Entry{
+			{Rank: 1, Member: "Alfred", Score: 10},
+			{Rank: 2, Member: "Bob", Score: 10},
+		})
+	}
+
+	if err := pb.Submit(ctx, "Eve", 1.5); err == nil {
+		t.Errorf("Submit(Eve, 1.5) succeeded, want error")
+	}
+}
+
+func testChangeMany(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := newStore(t)
+	if err := store.Add(ctx, "weekly", "Alfred", 1); err != nil {
+		t.Fatalf("Add(weekly) failed: %v", err)
+	}
+	scores, err := store.ChangeMany(ctx, "Alfred", []leaderboard.ScoreChange{
+		{Key: "all-time", Score: 10},
+		{Key: "weekly", Score: 5, Increment: true, TTL: time.Hour},
+		{Key: "daily", Score: 2, Increment: true, TTL: time.Hour},
+		{Key: "daily", Score: 3, Increment: true, TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("ChangeMany() failed: %v", err)
+	}
+	if want := []float64{10, 6, 2, 5}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("ChangeMany() = %v, want %v", scores, want)
+	}
+	for key, want := range map[string]float64{"all-time": 10, "weekly": 6, "daily": 5} {
+		entry, err := store.RevRank(ctx, key, "Alfred")
+		if err != nil {
+			t.Fatalf("RevRank(%s, Alfred) failed: %v", key, err)
+		}
+		if entry.Score != want {
+			t.Errorf("RevRank(%s, Alfred) score = %v, want %v", key, entry.Score, want)
+		}
+	}
+
+	if scores, err := store.ChangeMany(ctx, "Alfred", nil); err != nil || len(scores) != 0 {
+		t.Errorf("ChangeMany(nil) = %v, %v, want no scores", scores, err)
+	}
+}
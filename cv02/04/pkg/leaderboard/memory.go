@@ -0,0 +1,496 @@
+package leaderboard
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sortedSet is an in-memory sorted set, a skiplist for ordering and a map for score lookups.
+type sortedSet struct {
+	scores map[string]float64
+	list   *skiplist
+}
+
+// newSortedSet returns a new empty sorted set.
+func newSortedSet() *sortedSet {
+	return &sortedSet{
+		scores: make(map[string]float64),
+		list:   newSkiplist(),
+	}
+}
+
+// set sets the score of the member.
+func (z *sortedSet) set(member string, score float64) {
+	if oldScore, ok := z.scores[member]; ok {
+		if oldScore == score {
+			return
+		}
+		z.list.delete(oldScore, member)
+	}
+	z.scores[member] = score
+	z.list.insert(score, member)
+}
+
+// remove removes the member, returns false if the member is not in the set.
+func (z *sortedSet) remove(member string) bool {
+	score, ok := z.scores[member]
+	if !ok {
+		return false
+	}
+	z.list.delete(score, member)
+	delete(z.scores, member)
+	return true
+}
+
+// entry returns the entry of the member with its rank in the descending order.
+// The member must be in the set.
+func (z *sortedSet) entry(member string) Entry {
+	score := z.scores[member]
+	return Entry{
+		Rank:   z.list.length - z.list.rank(score, member) + 1,
+		Member: member,
+		Score:  score,
+	}
+}
+
+// MemoryStore implements Store using in-memory skiplists.
+// It orders and ranks members the same way as redis, so it can be used in place of RedisStore in tests.
+// Expired sorted sets are deleted lazily when they are accessed.
+type MemoryStore struct {
+	mu      sync.Mutex
+	sets    map[string]*sortedSet
+	expires map[string]time.Time
+}
+
+// Interface guard for Store.
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sets:    make(map[string]*sortedSet),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// Add sets the score of the member.
+func (s *MemoryStore) Add(ctx context.Context, key, member string, score float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getOrCreate(key).set(member, score)
+	return nil
+}
+
+// AddMany adds the entries, combining the scores of the existing members with the aggregate.
+func (s *MemoryStore) AddMany(ctx context.Context, key string, entries []Entry, aggregate Aggregate) error {
+	if _, err := aggregateScores(0, 0, aggregate); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := s.getOrCreate(key)
+	for _, entry := range entries {
+		score := entry.Score
+		if previous, ok := set.scores[entry.Member]; ok && aggregate != "" {
+			score, _ = aggregateScores(previous, score, aggregate)
+		}
+		set.set(entry.Member, score)
+	}
+	return nil
+}
+
+// IncrBy adds delta to the score of the member.
+func (s *MemoryStore) IncrBy(ctx context.Context, key, member string, delta float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := s.getOrCreate(key)
+	score := set.scores[member] + delta
+	set.set(member, score)
+	return score, nil
+}
+
+// ChangeMany applies the changes with the store locked.
+func (s *MemoryStore) ChangeMany(ctx context.Context, member string, changes []ScoreChange) ([]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scores := make([]float64, len(changes))
+	for i, change := range changes {
+		set := s.getOrCreate(change.Key)
+		score := change.Score
+		if change.Increment {
+			score += set.scores[member]
+		}
+		set.set(member, score)
+		if change.TTL > 0 {
+			s.expires[change.Key] = time.Now().Add(change.TTL)
+		}
+		scores[i] = score
+	}
+	return scores, nil
+}
+
+// RevRange returns the members between start and stop ordered by descending score.
+func (s *MemoryStore) RevRange(ctx context.Context, key string, start, stop int64) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.lookup(key)
+	if !ok {
+		return []Entry{}, nil
+	}
+
+	// resolve the indexes the same way as ZREVRANGE
+	length := set.list.length
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if stop < 0 {
+		stop = length + stop
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop || start >= length {
+		return []Entry{}, nil
+	}
+
+	entries := make([]Entry, 0, stop-start+1)
+	// the descending index i corresponds to the ascending rank length-i
+	node := set.list.byRank(length - start)
+	for rank := start + 1; rank <= stop+1 && node != nil; rank++ {
+		entries = append(entries, Entry{
+			Rank:   rank,
+			Member: node.member,
+			Score:  node.score,
+		})
+		node = node.backward
+	}
+	return entries, nil
+}
+
+// RevRank returns the entry of the member.
+func (s *MemoryStore) RevRank(ctx context.Context, key, member string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.lookup(key)
+	if !ok {
+		return Entry{}, ErrMemberNotFound
+	}
+	if _, ok := set.scores[member]; !ok {
+		return Entry{}, ErrMemberNotFound
+	}
+	return set.entry(member), nil
+}
+
+// Count returns the number of members with a score in the range [min, max].
+func (s *MemoryStore) Count(ctx context.Context, key string, min, max float64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.lookup(key)
+	if !ok || min > max {
+		return 0, nil
+	}
+	return set.list.countBelow(max, true) - set.list.countBelow(min, false), nil
+}
+
+// CountMany returns the number of members in each of the score ranges under a single lock.
+func (s *MemoryStore) CountMany(ctx context.Context, key string, ranges []ScoreRange) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make([]int64, len(ranges))
+	set, ok := s.lookup(key)
+	if !ok {
+		return counts, nil
+	}
+	for i, r := range ranges {
+		if r.Min <= r.Max {
+			counts[i] = set.list.countBelow(r.Max, true) - set.list.countBelow(r.Min, false)
+		}
+	}
+	return counts, nil
+}
+
+// Remove removes the member.
+func (s *MemoryStore) Remove(ctx context.Context, key, member string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.lookup(key)
+	if !ok || !set.remove(member) {
+		return ErrMemberNotFound
+	}
+	// redis deletes empty sorted sets
+	if set.list.length == 0 {
+		s.delete(key)
+	}
+	return nil
+}
+
+// Rename renames the sorted set, keeping its time to live.
+func (s *MemoryStore) Rename(ctx context.Context, key, newKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set, ok := s.lookup(key)
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if key == newKey {
+		return nil
+	}
+	expiresAt, expires := s.expires[key]
+	s.delete(key)
+	s.delete(newKey)
+	s.sets[newKey] = set
+	if expires {
+		s.expires[newKey] = expiresAt
+	}
+	return nil
+}
+
+// Delete deletes the sorted set.
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delete(key)
+	return nil
+}
+
+// Expire sets the time to live of the sorted set.
+func (s *MemoryStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookup(key); !ok {
+		return nil
+	}
+	// a non-positive ttl deletes the key right away the same way as EXPIRE
+	if ttl <= 0 {
+		s.delete(key)
+		return nil
+	}
+	s.expires[key] = time.Now().Add(ttl)
+	return nil
+}
+
+// Persist removes the time to live of the sorted set.
+func (s *MemoryStore) Persist(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookup(key); ok {
+		delete(s.expires, key)
+	}
+	return nil
+}
+
+// UnionStore stores the union of the sorted sets under dest.
+func (s *MemoryStore) UnionStore(ctx context.Context, dest string, keys []string, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	union, err := s.combine(keys, weights, aggregate, false)
+	if err != nil {
+		return 0, err
+	}
+	return s.store(dest, union, ttl), nil
+}
+
+// InterStore stores the intersection of the sorted sets under dest.
+func (s *MemoryStore) InterStore(ctx context.Context, dest string, keys []string, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inter, err := s.combine(keys, weights, aggregate, true)
+	if err != nil {
+		return 0, err
+	}
+	return s.store(dest, inter, ttl), nil
+}
+
+// Union returns the union of the sorted sets.
+func (s *MemoryStore) Union(ctx context.Context, keys []string, weights []float64, aggregate Aggregate) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	union, err := s.combine(keys, weights, aggregate, false)
+	if err != nil {
+		return nil, err
+	}
+	return rankScores(union), nil
+}
+
+// Inter returns the intersection of the sorted sets.
+func (s *MemoryStore) Inter(ctx context.Context, keys []string, weights []float64, aggregate Aggregate) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inter, err := s.combine(keys, weights, aggregate, true)
+	if err != nil {
+		return nil, err
+	}
+	return rankScores(inter), nil
+}
+
+// combine returns the weighted and aggregated scores of the members of any of the sorted sets,
+// or only of the members of all of them if intersect is set. The caller must hold the lock.
+func (s *MemoryStore) combine(keys []string, weights []float64, aggregate Aggregate, intersect bool) (map[string]float64, error) {
+	if weights != nil && len(weights) != len(keys) {
+		return nil, fmt.Errorf("got %d weights for %d keys", len(weights), len(keys))
+	}
+	if _, err := aggregateScores(0, 0, aggregate); err != nil {
+		return nil, err
+	}
+
+	combined := make(map[string]float64)
+	occurrences := make(map[string]int)
+	for i, key := range keys {
+		set, ok := s.lookup(key)
+		if !ok {
+			// the intersection with a missing sorted set is empty
+			if intersect {
+				return map[string]float64{}, nil
+			}
+			continue
+		}
+		weight := 1.0
+		if weights != nil {
+			weight = weights[i]
+		}
+		for member, score := range set.scores {
+			score *= weight
+			// redis treats inf * 0 as 0
+			if math.IsNaN(score) {
+				score = 0
+			}
+			occurrences[member]++
+			previous, ok := combined[member]
+			if !ok {
+				combined[member] = score
+				continue
+			}
+			combined[member], _ = aggregateScores(previous, score, aggregate)
+		}
+	}
+	if intersect {
+		for member, count := range occurrences {
+			if count < len(keys) {
+				delete(combined, member)
+			}
+		}
+	}
+	return combined, nil
+}
+
+// store replaces dest with the scores and returns their number. The caller must hold the lock.
+func (s *MemoryStore) store(dest string, scores map[string]float64, ttl time.Duration) int64 {
+	// the destination is replaced, redis deletes it if the result is empty
+	s.delete(dest)
+	if len(scores) == 0 {
+		return 0
+	}
+	set := s.getOrCreate(dest)
+	for member, score := range scores {
+		set.set(member, score)
+	}
+	if ttl > 0 {
+		s.expires[dest] = time.Now().Add(ttl)
+	}
+	return int64(len(scores))
+}
+
+// rankScores returns the scores as entries ordered and ranked the same way as RevRange.
+func rankScores(scores map[string]float64) []Entry {
+	entries := make([]Entry, 0, len(scores))
+	for member, score := range scores {
+		entries = append(entries, Entry{Member: member, Score: score})
+	}
+	// members with equal score are in reverse lexicographic order, as in ZREVRANGE
+	slices.SortFunc(entries, func(a, b Entry) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return strings.Compare(b.Member, a.Member)
+	})
+	for i := range entries {
+		entries[i].Rank = int64(i) + 1
+	}
+	return entries
+}
+
+// Apply atomically applies the conditional update to the score of the member.
+func (s *MemoryStore) Apply(ctx context.Context, key, member string, update ScoreUpdate) (UpdateResult, error) {
+	if update.Stream != nil {
+		return UpdateResult{}, errors.New("change streams are not supported")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result UpdateResult
+	set := s.getOrCreate(key)
+	stored, exists := set.scores[member]
+	if exists {
+		result.Old = set.entry(member)
+	}
+	newStored, updated, err := applyUpdate(stored, exists, update)
+	if updated {
+		set.set(member, newStored)
+		result.Updated = true
+	}
+	if _, ok := set.scores[member]; ok {
+		result.New = set.entry(member)
+	}
+	// redis does not keep empty sorted sets
+	if set.list.length == 0 {
+		s.delete(key)
+	}
+	return result, err
+}
+
+// aggregateScores combines the two scores of a member by the aggregate, SUM being the default.
+func aggregateScores(a, b float64, aggregate Aggregate) (float64, error) {
+	switch aggregate {
+	case AggregateSum, "":
+		sum := a + b
+		// redis treats inf + -inf as 0
+		if math.IsNaN(sum) {
+			return 0, nil
+		}
+		return sum, nil
+	case AggregateMin:
+		return math.Min(a, b), nil
+	case AggregateMax:
+		return math.Max(a, b), nil
+	default:
+		return 0, fmt.Errorf("unsupported aggregate: %q", aggregate)
+	}
+}
+
+// lookup returns the sorted set under the key, deleting it first if it has expired.
+// The caller must hold the lock.
+func (s *MemoryStore) lookup(key string) (*sortedSet, bool) {
+	if expiresAt, ok := s.expires[key]; ok && !time.Now().Before(expiresAt) {
+		s.delete(key)
+	}
+	set, ok := s.sets[key]
+	return set, ok
+}
+
+// delete deletes the sorted set under the key together with its time to live.
+// The caller must hold the lock.
+func (s *MemoryStore) delete(key string) {
+	delete(s.sets, key)
+	delete(s.expires, key)
+}
+
+// getOrCreate returns the sorted set under the key, creating it if it does not exist.
+// The caller must hold the lock.
+func (s *MemoryStore) getOrCreate(key string) *sortedSet {
+	set, ok := s.lookup(key)
+	if !ok {
+		set = newSortedSet()
+		s.sets[key] = set
+	}
+	return set
+}
@@ -0,0 +1,14 @@
+package leaderboard_test
+
+import (
+	"testing"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/leaderboard/leaderboardtest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	leaderboardtest.TestStore(t, func(t *testing.T) leaderboard.Store {
+		return leaderboard.NewMemoryStore()
+	})
+}
@@ -0,0 +1,255 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Period is the time period covered by a leaderboard.
+type Period int
+
+const (
+	// AllTime is the lifetime leaderboard stored under the base key itself.
+	AllTime Period = iota
+	// Daily is the leaderboard of a single day, e.g. "scoreboard:daily:2026-10-17".
+	Daily
+	// Weekly is the leaderboard of a single ISO week, e.g. "scoreboard:weekly:2026-W42".
+	Weekly
+)
+
+// String returns the name of the period.
+func (p Period) String() string {
+	switch p {
+	case AllTime:
+		return "all-time"
+	case Daily:
+		return "daily"
+	case Weekly:
+		return "weekly"
+	default:
+		return fmt.Sprintf("Period(%d)", int(p))
+	}
+}
+
+const (
+	// DefaultRetention is how long the period keys are kept after their period ends by default.
+	DefaultRetention = 30 * 24 * time.Hour
+	// RangeTTL is the time to live of the keys holding aggregated date ranges.
+	RangeTTL = time.Minute
+	// MaxRangeDays is the maximum number of days aggregated by PeriodicLeaderboard.Range.
+	MaxRangeDays = 366
+)
+
+// PeriodicLeaderboard maintains leaderboards of several periods updated together on each score change.
+// The period keys expire after their period ends, so the leaderboards roll over to new keys automatically.
+type PeriodicLeaderboard struct {
+	// TieBreakByTime is passed to the leaderboards of the periods, see Leaderboard.TieBreakByTime.
+	TieBreakByTime bool
+	// RankingMode is passed to the leaderboards of the periods, see Leaderboard.RankingMode.
+	RankingMode RankingMode
+	// Now returns the current time deciding the current periods, time.Now if nil.
+	Now func() time.Time
+	// Location is the time zone of the period boundaries, time.UTC if nil.
+	Location *time.Location
+	// Retention is how long the period keys are kept after their period ends, DefaultRetention if zero.
+	Retention time.Duration
+
+	store   Store
+	key     string
+	periods []Period
+}
+
+// NewPeriodic returns a new PeriodicLeaderboard with the periods stored under keys derived from the base key.
+// If no periods are given, the all-time, daily and weekly leaderboards are maintained.
+// With a redis cluster, the base key must contain a hash tag, e.g. "{scoreboard}", so the derived keys can be updated
+// in a single transaction and Range can union them.
+func NewPeriodic(store Store, key string, periods ...Period) *PeriodicLeaderboard {
+	if len(periods) == 0 {
+		periods = []Period{AllTime, Daily, Weekly}
+	}
+	return &PeriodicLeaderboard{
+		store:   store,
+		key:     key,
+		periods: periods,
+	}
+}
+
+// Periods returns the periods maintained by the leaderboard.
+func (p *PeriodicLeaderboard) Periods() []Period {
+	return p.periods
+}
+
+// Key returns the key of the leaderboard of the period containing the time t.
+func (p *PeriodicLeaderboard) Key(period Period, t time.Time) string {
+	t = t.In(p.location())
+	switch period {
+	case Daily:
+		return fmt.Sprintf("%s:daily:%s", p.key, t.Format(time.DateOnly))
+	case Weekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%s:weekly:%d-W%02d", p.key, year, week)
+	default:
+		return p.key
+	}
+}
+
+// Board returns the leaderboard of the period containing the time t.
+func (p *PeriodicLeaderboard) Board(period Period, t time.Time) *Leaderboard {
+	return p.newBoard(p.Key(period, t))
+}
+
+// Current returns the leaderboard of the current period.
+func (p *PeriodicLeaderboard) Current(period Period) *Leaderboard {
+	return p.Board(period, p.now())
+}
+
+// Submit sets the score of the member on the leaderboards of all the current periods.
+// The scores and the expirations of the period keys are written in a single transaction.
+func (p *PeriodicLeaderboard) Submit(ctx context.Context, member string, score float64) error {
+	now := p.now()
+	stored := score
+	if p.TieBreakByTime {
+		var err error
+		if stored, err = encodeScore(score, now); err != nil {
+			return fmt.Errorf("failed to submit score of %q: %w", member, err)
+		}
+	}
+	changes := make([]ScoreChange, len(p.periods))
+	for i, period := range p.periods {
+		changes[i] = ScoreChange{Key: p.Key(period, now), Score: stored, TTL: p.ttl(period, now)}
+	}
+	if _, err := p.store.ChangeMany(ctx, member, changes); err != nil {
+		return fmt.Errorf("failed to submit score of %q: %w", member, err)
+	}
+	return nil
+}
+
+// Increment adds delta to the score of the member on the leaderboards of all the current periods.
+// The scores and the expirations of the period keys are written in a single transaction.
+// With TieBreakByTime, the scores of the periods are read before they are written back with a new time of achievement,
+// which is not atomic, see Leaderboard.Increment.
+func (p *PeriodicLeaderboard) Increment(ctx context.Context, member string, delta float64) error {
+	now := p.now()
+	changes := make([]ScoreChange, len(p.periods))
+	for i, period := range p.periods {
+		changes[i] = ScoreChange{Key: p.Key(period, now), Score: delta, Increment: true, TTL: p.ttl(period, now)}
+		if !p.TieBreakByTime {
+			continue
+		}
+		var score float64
+		entry, err := p.store.RevRank(ctx, changes[i].Key, member)
+		switch {
+		case err == nil:
+			score = decodeScore(entry.Score)
+		case !errors.Is(err, ErrMemberNotFound):
+			return fmt.Errorf("failed to increment %v score of %q: %w", period, member, err)
+		}
+		stored, err := encodeScore(score+delta, now)
+		if err != nil {
+			return fmt.Errorf("failed to increment %v score of %q: %w", period, member, err)
+		}
+		changes[i].Score = stored
+		changes[i].Increment = false
+	}
+	if _, err := p.store.ChangeMany(ctx, member, changes); err != nil {
+		return fmt.Errorf("failed to increment score of %q: %w", member, err)
+	}
+	return nil
+}
+
+// Range aggregates the daily leaderboards of the days from the day of from to the day of to (inclusive).
+// The aggregate is stored under a temporary key expiring after RangeTTL and returned as a leaderboard.
+// Only the days within the retention are aggregated, older daily leaderboards have expired already.
+func (p *PeriodicLeaderboard) Range(ctx context.Context, from, to time.Time, aggregate Aggregate) (*Leaderboard, error) {
+	if !slices.Contains(p.periods, Daily) {
+		return nil, errors.New("daily leaderboards are not maintained")
+	}
+	// composite scores of TieBreakByTime cannot be summed without corrupting the times of achievement
+	if p.TieBreakByTime && aggregate == AggregateSum {
+		return nil, errors.New("scores with TieBreakByTime cannot be summed")
+	}
+
+	from = startOfDay(from.In(p.location()))
+	to = startOfDay(to.In(p.location()))
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid date range: %s - %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	var keys []string
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if len(keys) == MaxRangeDays {
+			return nil, fmt.Errorf("date range exceeds %d days", MaxRangeDays)
+		}
+		keys = append(keys, p.Key(Daily, day))
+	}
+
+	dest := fmt.Sprintf("%s:range:%s:%s:%s", p.key, from.Format(time.DateOnly), to.Format(time.DateOnly), aggregate)
+	if _, err := p.store.UnionStore(ctx, dest, keys, nil, aggregate, RangeTTL); err != nil {
+		return nil, fmt.Errorf("failed to aggregate date range: %w", err)
+	}
+	return p.newBoard(dest), nil
+}
+
+// ttl returns the time to live of the key of the period containing the time t.
+// The all-time leaderboard never expires, so its time to live is zero.
+func (p *PeriodicLeaderboard) ttl(period Period, t time.Time) time.Duration {
+	if period == AllTime {
+		return 0
+	}
+	retention := p.Retention
+	if retention == 0 {
+		retention = DefaultRetention
+	}
+	return p.periodEnd(period, t).Add(retention).Sub(t)
+}
+
+// periodEnd returns the end of the period containing the time t.
+func (p *PeriodicLeaderboard) periodEnd(period Period, t time.Time) time.Time {
+	day := startOfDay(t.In(p.location()))
+	switch period {
+	case Daily:
+		return day.AddDate(0, 0, 1)
+	case Weekly:
+		// ISO weeks start on monday
+		daysToMonday := (8 - int(day.Weekday())) % 7
+		if daysToMonday == 0 {
+			daysToMonday = 7
+		}
+		return day.AddDate(0, 0, daysToMonday)
+	default:
+		return t
+	}
+}
+
+// newBoard returns a leaderboard under the key with the settings of the periodic leaderboard.
+func (p *PeriodicLeaderboard) newBoard(key string) *Leaderboard {
+	lb := New(p.store, key)
+	lb.TieBreakByTime = p.TieBreakByTime
+	lb.RankingMode = p.RankingMode
+	lb.Now = p.Now
+	return lb
+}
+
+// now returns the current time of the periodic leaderboard.
+func (p *PeriodicLeaderboard) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// location returns the time zone of the period boundaries.
+func (p *PeriodicLeaderboard) location() *time.Location {
+	if p.Location != nil {
+		return p.Location
+	}
+	return time.UTC
+}
+
+// startOfDay returns the midnight starting the day of the time t in its location.
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,222 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// RankingMode determines how members with equal score are ranked.
+type RankingMode int
+
+const (
+	// RankOrdinal gives every member a unique rank in the order of the sorted set ("1,2,3,4").
+	RankOrdinal RankingMode = iota
+	// RankStandard gives members with equal score the same rank and leaves a gap after them ("1,2,2,4").
+	RankStandard
+	// RankDense gives members with equal score the same rank without leaving a gap after them ("1,2,2,3").
+	RankDense
+)
+
+// String returns the name of the ranking mode.
+func (m RankingMode) String() string {
+	switch m {
+	case RankOrdinal:
+		return "ordinal"
+	case RankStandard:
+		return "standard"
+	case RankDense:
+		return "dense"
+	default:
+		return fmt.Sprintf("RankingMode(%d)", int(m))
+	}
+}
+
+const (
+	// tieBreakBits is the number of low bits of a composite score holding the time of achievement.
+	tieBreakBits = 32
+	// tieBreakShift is the multiplier of the score in a composite score.
+	tieBreakShift float64 = 1 << tieBreakBits
+	// maxTieBreakTime is the maximum time of achievement in seconds since TieBreakEpoch.
+	maxTieBreakTime = 1<<tieBreakBits - 1
+	// rankPageSize is the number of entries fetched at once when counting distinct scores.
+	rankPageSize = 100
+)
+
+// ErrInvalidScore is returned when the score cannot be stored on the leaderboard,
+// e.g. a fractional score or a score above MaxTieBreakScore with TieBreakByTime.
+var ErrInvalidScore = errors.New("invalid score")
+
+// TimedEntry is an entry with the time its score was achieved, e.g. to copy a leaderboard with TieBreakByTime
+// without losing the order of the ties. The time is known only with TieBreakByTime and zero otherwise,
+// it has a resolution of one second and the times before TieBreakEpoch are encoded as TieBreakEpoch.
+type TimedEntry struct {
+	Entry
+	Achieved time.Time
+}
+
+// MaxTieBreakScore is the maximum absolute score of a leaderboard with TieBreakByTime.
+// Composite scores must fit into the 53 bits of the float64 mantissa to stay exact.
+const MaxTieBreakScore = 1<<(53-tieBreakBits) - 1
+
+// TieBreakEpoch is the start of the time range encoded in composite scores.
+// Times of achievement are encoded with a resolution of one second, up to 136 years after the epoch.
+var TieBreakEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// encodeScore encodes the score and the time of achievement into a composite score.
+// The earlier the time of achievement, the higher the composite score, so earlier members rank higher.
+func encodeScore(score float64, at time.Time) (float64, error) {
+	if err := validateTieBreakScore(score); err != nil {
+		return 0, err
+	}
+	return score*tieBreakShift + float64(tieBreakTime(at)), nil
+}
+
+// validateTieBreakScore returns an error if the score cannot be encoded in a composite score.
+func validateTieBreakScore(score float64) error {
+	if score != math.Trunc(score) || math.Abs(score) > MaxTieBreakScore {
+		return fmt.Errorf("%w: %v must be an integer in the range [%d, %d]", ErrInvalidScore, score, -MaxTieBreakScore, MaxTieBreakScore)
+	}
+	return nil
+}
+
+// tieBreakTime returns the time of achievement encoded in the low bits of a composite score.
+func tieBreakTime(at time.Time) int64 {
+	elapsed := int64(at.Sub(TieBreakEpoch) / time.Second)
+	elapsed = min(max(elapsed, 0), maxTieBreakTime)
+	return maxTieBreakTime - elapsed
+}
+
+// achievedTime returns the time of achievement encoded in the composite score, with a resolution of one second.
+func achievedTime(composite float64) time.Time {
+	tieBreak := int64(composite - decodeScore(composite)*tieBreakShift)
+	return TieBreakEpoch.Add(time.Duration(maxTieBreakTime-tieBreak) * time.Second)
+}
+
+// decodeScore returns the score encoded in the composite score.
+func decodeScore(composite float64) float64 {
+	return math.Floor(composite / tieBreakShift)
+}
+
+// now returns the current time of the leaderboard.
+func (lb *Leaderboard) now() time.Time {
+	if lb.Now != nil {
+		return lb.Now()
+	}
+	return time.Now()
+}
+
+// storedScore returns the score to store in the sorted set for the score achieved now.
+func (lb *Leaderboard) storedScore(score float64) (float64, error) {
+	if !lb.TieBreakByTime {
+		return score, nil
+	}
+	return encodeScore(score, lb.now())
+}
+
+// storedScoreAt returns the score stored in the sorted set for the score achieved at the time, now if it is zero.
+func (lb *Leaderboard) storedScoreAt(score float64, at time.Time) (float64, error) {
+	if !lb.TieBreakByTime || at.IsZero() {
+		return lb.storedScore(score)
+	}
+	return encodeScore(score, at)
+}
+
+// humanScore returns the score of the member from the score stored in the sorted set.
+func (lb *Leaderboard) humanScore(stored float64) float64 {
+	if !lb.TieBreakByTime {
+		return stored
+	}
+	return decodeScore(stored)
+}
+
+// scoreBounds returns the stored score range containing the scores in the range [min, max].
+func (lb *Leaderboard) scoreBounds(min, max float64) (float64, float64) {
+	if !lb.TieBreakByTime {
+		return min, max
+	}
+	return math.Ceil(min) * tieBreakShift, math.Floor(max)*tieBreakShift + maxTieBreakTime
+}
+
+// countAbove returns the number of members with a score higher than the given score.
+func (lb *Leaderboard) countAbove(ctx context.Context, score float64) (int64, error) {
+	min := math.Nextafter(score, math.Inf(1))
+	if lb.TieBreakByTime {
+		min = (score + 1) * tieBreakShift
+	}
+	return lb.store.Count(ctx, lb.key, min, math.Inf(1))
+}
+
+// countDistinctAbove returns the number of distinct scores higher than the given score.
+// The members above are walked page by page, so the cost grows with the rank of the score.
+func (lb *Leaderboard) countDistinctAbove(ctx context.Context, score float64) (int64, error) {
+	var distinct int64
+	previous := math.NaN()
+	for start := int64(0); ; start += rankPageSize {
+		entries, err := lb.store.RevRange(ctx, lb.key, start, start+rankPageSize-1)
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			entryScore := lb.humanScore(entry.Score)
+			if entryScore <= score {
+				return distinct, nil
+			}
+			if entryScore != previous {
+				distinct++
+				previous = entryScore
+			}
+		}
+		if len(entries) < rankPageSize {
+			return distinct, nil
+		}
+	}
+}
+
+// rankOf returns the rank of the given score in the ranking mode of the leaderboard.
+// It is not defined for RankOrdinal, where the rank depends on the member as well.
+func (lb *Leaderboard) rankOf(ctx context.Context, score float64) (int64, error) {
+	var above int64
+	var err error
+	switch lb.RankingMode {
+	case RankStandard:
+		above, err = lb.countAbove(ctx, score)
+	case RankDense:
+		above, err = lb.countDistinctAbove(ctx, score)
+	default:
+		return 0, fmt.Errorf("unsupported ranking mode: %v", lb.RankingMode)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return above + 1, nil
+}
+
+// rankEntries decodes the scores of consecutive entries returned by the store and ranks them by the ranking mode.
+func (lb *Leaderboard) rankEntries(ctx context.Context, entries []Entry) ([]Entry, error) {
+	for i := range entries {
+		entries[i].Score = lb.humanScore(entries[i].Score)
+	}
+	if lb.RankingMode == RankOrdinal || len(entries) == 0 {
+		return entries, nil
+	}
+
+	// only the rank of the first entry must be queried, the others follow from the order of the entries
+	firstRank, err := lb.rankOf(ctx, entries[0].Score)
+	if err != nil {
+		return nil, err
+	}
+	entries[0].Rank = firstRank
+	for i := 1; i < len(entries); i++ {
+		switch {
+		case entries[i].Score == entries[i-1].Score:
+			entries[i].Rank = entries[i-1].Rank
+		case lb.RankingMode == RankDense:
+			entries[i].Rank = entries[i-1].Rank + 1
+		}
+		// in RankStandard the rank of the first member with a lower score is its ordinal rank
+	}
+	return entries, nil
+}
@@ -0,0 +1,431 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore implements Store using redis sorted sets.
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+// Interface guard for Store.
+var _ Store = (*RedisStore)(nil)
+
+// NewRedisStore returns a new RedisStore using the given client.
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Add sets the score of the member using ZADD.
+func (s *RedisStore) Add(ctx context.Context, key, member string, score float64) error {
+	return s.client.ZAdd(ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+// addBatchSize is the maximum number of members added by a single ZADD of AddMany.
+const addBatchSize = 1000
+
+// AddMany adds the entries in a single pipeline using ZADD, with GT for max and LT for min, or ZINCRBY for sum.
+func (s *RedisStore) AddMany(ctx context.Context, key string, entries []Entry, aggregate Aggregate) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	args := redis.ZAddArgs{}
+	switch aggregate {
+	case "", AggregateSum:
+	case AggregateMax:
+		args.GT = true
+	case AggregateMin:
+		args.LT = true
+	default:
+		return fmt.Errorf("unsupported aggregate: %q", aggregate)
+	}
+
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if aggregate == AggregateSum {
+			for _, entry := range entries {
+				pipe.ZIncrBy(ctx, key, entry.Score, entry.Member)
+			}
+			return nil
+		}
+		for start := 0; start < len(entries); start += addBatchSize {
+			batch := entries[start:min(start+addBatchSize, len(entries))]
+			args.Members = make([]redis.Z, len(batch))
+			for i, entry := range batch {
+				args.Members[i] = redis.Z{Score: entry.Score, Member: entry.Member}
+			}
+			pipe.ZAddArgs(ctx, key, args)
+		}
+		return nil
+	})
+	return err
+}
+
+// IncrBy adds delta to the score of the member using ZINCRBY.
+func (s *RedisStore) IncrBy(ctx context.Context, key, member string, delta float64) (float64, error) {
+	return s.client.ZIncrBy(ctx, key, delta, member).Result()
+}
+
+// ChangeMany applies the changes using ZADD or ZINCRBY and EXPIRE in a single MULTI/EXEC transaction.
+// With a redis cluster, all the keys must map to the same slot.
+func (s *RedisStore) ChangeMany(ctx context.Context, member string, changes []ScoreChange) ([]float64, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.FloatCmd, len(changes))
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, change := range changes {
+			if change.Increment {
+				cmds[i] = pipe.ZIncrBy(ctx, change.Key, change.Score, member)
+			} else {
+				pipe.ZAdd(ctx, change.Key, redis.Z{Score: change.Score, Member: member})
+			}
+			if change.TTL > 0 {
+				pipe.Expire(ctx, change.Key, change.TTL)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]float64, len(changes))
+	for i, change := range changes {
+		scores[i] = change.Score
+		if cmds[i] != nil {
+			scores[i] = cmds[i].Val()
+		}
+	}
+	return scores, nil
+}
+
+// RevRange returns the members between start and stop using ZREVRANGE.
+func (s *RedisStore) RevRange(ctx context.Context, key string, start, stop int64) ([]Entry, error) {
+	members, err := s.client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	// negative start index must be resolved to get the absolute ranks
+	if start < 0 {
+		card, err := s.client.ZCard(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		start = max(card+start, 0)
+	}
+	return toEntries(members, start+1), nil
+}
+
+// RevRank returns the entry of the member using ZREVRANK and ZSCORE.
+func (s *RedisStore) RevRank(ctx context.Context, key, member string) (Entry, error) {
+	// ZREVRANK WITHSCORE needs redis 7.2, so the rank and the score are pipelined instead
+	var rankCmd *redis.IntCmd
+	var scoreCmd *redis.FloatCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		rankCmd = pipe.ZRevRank(ctx, key, member)
+		scoreCmd = pipe.ZScore(ctx, key, member)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return Entry{}, ErrMemberNotFound
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Rank:   rankCmd.Val() + 1,
+		Member: member,
+		Score:  scoreCmd.Val(),
+	}, nil
+}
+
+// Count returns the number of members in the score range using ZCOUNT.
+func (s *RedisStore) Count(ctx context.Context, key string, min, max float64) (int64, error) {
+	return s.client.ZCount(ctx, key, formatScore(min), formatScore(max)).Result()
+}
+
+// CountMany returns the number of members in each of the score ranges using ZCOUNT in a single pipeline.
+func (s *RedisStore) CountMany(ctx context.Context, key string, ranges []ScoreRange) ([]int64, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.IntCmd, len(ranges))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, r := range ranges {
+			cmds[i] = pipe.ZCount(ctx, key, formatScore(r.Min), formatScore(r.Max))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int64, len(cmds))
+	for i, cmd := range cmds {
+		counts[i] = cmd.Val()
+	}
+	return counts, nil
+}
+
+// Remove removes the member using ZREM.
+func (s *RedisStore) Remove(ctx context.Context, key, member string) error {
+	removed, err := s.client.ZRem(ctx, key, member).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// Rename renames the sorted set using RENAME. With a redis cluster, both keys must be in the same slot.
+func (s *RedisStore) Rename(ctx context.Context, key, newKey string) error {
+	err := s.client.Rename(ctx, key, newKey).Err()
+	// redis replies with an error instead of nil if the key does not exist
+	if err != nil && strings.Contains(err.Error(), "no such key") {
+		return ErrKeyNotFound
+	}
+	return err
+}
+
+// Delete deletes the sorted set using UNLINK.
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Unlink(ctx, key).Err()
+}
+
+// Expire sets the time to live of the sorted set using EXPIRE.
+func (s *RedisStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.Expire(ctx, key, ttl).Err()
+}
+
+// Persist removes the time to live of the sorted set using PERSIST.
+func (s *RedisStore) Persist(ctx context.Context, key string) error {
+	return s.client.Persist(ctx, key).Err()
+}
+
+// UnionStore stores the union of the sorted sets using ZUNIONSTORE and EXPIRE in a transaction.
+func (s *RedisStore) UnionStore(ctx context.Context, dest string, keys []string, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	return s.storeExpiring(ctx, dest, ttl, func(pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZUnionStore(ctx, dest, &redis.ZStore{
+			Keys:      keys,
+			Weights:   weights,
+			Aggregate: string(aggregate),
+		})
+	})
+}
+
+// InterStore stores the intersection of the sorted sets using ZINTERSTORE and EXPIRE in a transaction.
+func (s *RedisStore) InterStore(ctx context.Context, dest string, keys []string, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	return s.storeExpiring(ctx, dest, ttl, func(pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZInterStore(ctx, dest, &redis.ZStore{
+			Keys:      keys,
+			Weights:   weights,
+			Aggregate: string(aggregate),
+		})
+	})
+}
+
+// storeExpiring runs the command storing dest and sets the time to live of dest in a single MULTI/EXEC round trip,
+// so dest never exists without its time to live. It returns the reply of the command.
+func (s *RedisStore) storeExpiring(ctx context.Context, dest string, ttl time.Duration, store func(pipe redis.Pipeliner) *redis.IntCmd) (int64, error) {
+	var cmd *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		cmd = store(pipe)
+		if ttl > 0 {
+			pipe.Expire(ctx, dest, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+// Union returns the union of the sorted sets using ZUNION, available since redis 6.2.
+func (s *RedisStore) Union(ctx context.Context, keys []string, weights []float64, aggregate Aggregate) ([]Entry, error) {
+	members, err := s.client.ZUnionWithScores(ctx, redis.ZStore{
+		Keys:      keys,
+		Weights:   weights,
+		Aggregate: string(aggregate),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	// ZUNION orders by ascending score like ZRANGE, reversing it gives the order of ZREVRANGE
+	slices.Reverse(members)
+	return toEntries(members, 1), nil
+}
+
+// Inter returns the intersection of the sorted sets using ZINTER, available since redis 6.2.
+func (s *RedisStore) Inter(ctx context.Context, keys []string, weights []float64, aggregate Aggregate) ([]Entry, error) {
+	members, err := s.client.ZInterWithScores(ctx, &redis.ZStore{
+		Keys:      keys,
+		Weights:   weights,
+		Aggregate: string(aggregate),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	// ZINTER orders by ascending score like ZRANGE, reversing it gives the order of ZREVRANGE
+	slices.Reverse(members)
+	return toEntries(members, 1), nil
+}
+
+// applyScript applies a conditional update to the score of a member, mirroring applyUpdate.
+// KEYS[1] is the sorted set, ARGV holds the member, the value, the update mode, the bounds and the time of achievement,
+// the bounds and the time of achievement are empty if not used. The optional KEYS[2] is the change stream
+// the update is appended to with XADD, trimmed to about ARGV[7] records unless it is empty.
+// It returns the status (0 rejected, 1 kept, 2 updated) with the old and the new score and rank of the member.
+var applyScript = redis.NewScript(`
+local key, member = KEYS[1], ARGV[1]
+local value, mode = tonumber(ARGV[2]), ARGV[3]
+local min, max, tiebreak = tonumber(ARGV[4]), tonumber(ARGV[5]), tonumber(ARGV[6])
+local shift = 4294967296
+
+local oldStored = redis.call('ZSCORE', key, member)
+local oldRank = redis.call('ZREVRANK', key, member)
+local current = 0
+if oldStored then
+	current = tonumber(oldStored)
+	if tiebreak then
+		current = math.floor(current / shift)
+	end
+end
+
+local score = value
+if mode == 'incr' then
+	score = score + current
+end
+if min and score < min then
+	return {0, oldStored, oldRank, oldStored, oldRank}
+end
+if max and score > max then
+	score = max
+end
+if mode == 'best' and oldStored and score <= current then
+	return {1, oldStored, oldRank, oldStored, oldRank}
+end
+
+if KEYS[2] then
+	local xadd = {'XADD', KEYS[2]}
+	if tonumber(ARGV[7]) then
+		table.insert(xadd, 'MAXLEN')
+		table.insert(xadd, '~')
+		table.insert(xadd, ARGV[7])
+	end
+	table.insert(xadd, '*')
+	for _, field in ipairs({'member', member, 'mode', mode, 'value', ARGV[2],
+		'delta', string.format('%.17g', score - current), 'score', string.format('%.17g', score)}) do
+		table.insert(xadd, field)
+	end
+	redis.call(unpack(xadd))
+end
+
+if tiebreak then
+	score = score * shift + tiebreak
+end
+redis.call('ZADD', key, string.format('%.17g', score), member)
+return {2, oldStored, oldRank, redis.call('ZSCORE', key, member), redis.call('ZREVRANK', key, member)}
+`)
+
+// LoadScripts loads the scripts used by the store into the script cache of redis.
+// Loading is optional, the scripts are sent with EVAL when they are missing in the cache.
+func (s *RedisStore) LoadScripts(ctx context.Context) error {
+	return applyScript.Load(ctx, s.client).Err()
+}
+
+// Apply atomically applies the conditional update to the score of the member using a lua script,
+// which also appends the change to the change stream of the update.
+// The script is run with EVALSHA, falling back to EVAL if it is not cached yet.
+func (s *RedisStore) Apply(ctx context.Context, key, member string, update ScoreUpdate) (UpdateResult, error) {
+	args := []interface{}{member, formatScore(update.Value), update.Mode.String(), "", "", ""}
+	if update.Bounds != nil {
+		args[3] = formatScore(update.Bounds.Min)
+		args[4] = formatScore(update.Bounds.Max)
+	}
+	if update.Composite {
+		args[5] = update.TieBreak
+	}
+	keys := []string{key}
+	if update.Stream != nil {
+		keys = append(keys, update.Stream.Key)
+		args = append(args, "")
+		if update.Stream.MaxLen > 0 {
+			args[6] = update.Stream.MaxLen
+		}
+	}
+	reply, err := applyScript.Run(ctx, s.client, keys, args...).Slice()
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	if len(reply) != 5 {
+		return UpdateResult{}, fmt.Errorf("unexpected script reply: %v", reply)
+	}
+
+	var result UpdateResult
+	if result.Old, err = parseScriptEntry(member, reply[1], reply[2]); err != nil {
+		return UpdateResult{}, err
+	}
+	if result.New, err = parseScriptEntry(member, reply[3], reply[4]); err != nil {
+		return UpdateResult{}, err
+	}
+	switch reply[0] {
+	case int64(0):
+		return result, ErrScoreBelowMin
+	case int64(2):
+		result.Updated = true
+	}
+	return result, nil
+}
+
+// parseScriptEntry parses the score and the 0-based rank returned by a script into an entry.
+// Returns an empty entry if the member was not in the sorted set.
+func parseScriptEntry(member string, score, rank interface{}) (Entry, error) {
+	if score == nil || rank == nil {
+		return Entry{}, nil
+	}
+	scoreStr, ok := score.(string)
+	if !ok {
+		return Entry{}, fmt.Errorf("unexpected script score: %v", score)
+	}
+	parsedScore, err := strconv.ParseFloat(scoreStr, 64)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to parse script score: %w", err)
+	}
+	parsedRank, ok := rank.(int64)
+	if !ok {
+		return Entry{}, fmt.Errorf("unexpected script rank: %v", rank)
+	}
+	return Entry{
+		Rank:   parsedRank + 1,
+		Member: member,
+		Score:  parsedScore,
+	}, nil
+}
+
+// toEntries converts redis sorted set members to entries ranked from firstRank.
+func toEntries(members []redis.Z, firstRank int64) []Entry {
+	entries := make([]Entry, 0, len(members))
+	for i, member := range members {
+		entries = append(entries, Entry{
+			Rank:   firstRank + int64(i),
+			Member: fmt.Sprint(member.Member),
+			Score:  member.Score,
+		})
+	}
+	return entries
+}
+
+// formatScore formats the score as a redis sorted set score bound.
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
@@ -0,0 +1,124 @@
+package leaderboard_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/leaderboard/leaderboardtest"
+	"dpb-cv02-04/pkg/resptest"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisAddrEnv is the environment variable with the address of a real redis to run the tests against.
+// The database of the address is flushed before every test.
+const redisAddrEnv = "LEADERBOARD_TEST_REDIS_ADDR"
+
+// TestRedisStore runs the conformance tests against the in-process resptest server,
+// which does not run scripts, so the tests of the conditional updates are skipped.
+func TestRedisStore(t *testing.T) {
+	server, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	const noScripts = "resptest does not support lua scripts, set " + redisAddrEnv + " to run it against redis"
+	leaderboardtest.TestStoreSkipping(t, func(t *testing.T) leaderboard.Store {
+		server.FlushAll()
+		return leaderboard.NewRedisStore(client)
+	}, map[string]string{
+		"Update":               noScripts,
+		"UpdateTieBreakByTime": noScripts,
+	})
+}
+
+// TestRedisStoreServer runs all conformance tests against the redis given by redisAddrEnv.
+func TestRedisStoreServer(t *testing.T) {
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skip(redisAddrEnv + " is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+
+	leaderboardtest.TestStore(t, func(t *testing.T) leaderboard.Store {
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatalf("FLUSHDB failed: %v", err)
+		}
+		return leaderboard.NewRedisStore(client)
+	})
+}
+
+// pipelineHook records the names of the commands of the pipelines, including MULTI and EXEC of the transactions.
+type pipelineHook struct {
+	pipelines [][]string
+}
+
+// Interface guard for redis.Hook.
+var _ redis.Hook = (*pipelineHook)(nil)
+
+func (h *pipelineHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *pipelineHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (h *pipelineHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			names[i] = cmd.Name()
+		}
+		h.pipelines = append(h.pipelines, names)
+		return next(ctx, cmds)
+	}
+}
+
+// TestRedisStoreCombinationTTL checks that a stored combination is stored together with its time to live.
+func TestRedisStoreCombinationTTL(t *testing.T) {
+	server, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	store := leaderboard.NewRedisStore(client)
+	duel := leaderboard.New(store, "{scoreboard}:duel")
+	team := leaderboard.New(store, "{scoreboard}:team")
+	for _, lb := range []*leaderboard.Leaderboard{duel, team} {
+		if err := lb.Submit(ctx, "Alfred", 100); err != nil {
+			t.Fatalf("Submit() failed: %v", err)
+		}
+	}
+	for _, name := range []string{"zunionstore", "zinterstore"} {
+		hook := &pipelineHook{}
+		client.AddHook(hook)
+		combination := leaderboard.NewCombination(duel, team)
+		combination.Intersect = name == "zinterstore"
+		combination.TTL = time.Hour
+		dest := "{scoreboard}:" + name
+		if _, err := combination.Store(ctx, dest); err != nil {
+			t.Fatalf("Store() with %s failed: %v", name, err)
+		}
+
+		want := []string{"multi", name, "expire", "exec"}
+		if len(hook.pipelines) != 1 || !slices.Equal(hook.pipelines[0], want) {
+			t.Errorf("pipelines of Store() = %q, want %q", hook.pipelines, want)
+		}
+		if ttl, err := client.TTL(ctx, dest).Result(); err != nil || ttl <= 0 || ttl > time.Hour {
+			t.Errorf("TTL %s = %v, %v, want at most an hour", dest, ttl, err)
+		}
+	}
+}
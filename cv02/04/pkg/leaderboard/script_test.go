@@ -0,0 +1,200 @@
+package leaderboard_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scriptHook answers the scripts run by EVALSHA with a canned reply and records their arguments,
+// so the encoding of the script arguments is tested without a redis running the scripts.
+type scriptHook struct {
+	reply interface{}
+	calls [][]string
+}
+
+// Interface guard for redis.Hook.
+var _ redis.Hook = (*scriptHook)(nil)
+
+func (h *scriptHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *scriptHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() != "evalsha" {
+			return next(ctx, cmd)
+		}
+		// the SHA of the script is not part of the encoding
+		var args []string
+		for _, arg := range cmd.Args()[2:] {
+			args = append(args, fmt.Sprint(arg))
+		}
+		h.calls = append(h.calls, args)
+		cmd.(*redis.Cmd).SetVal(h.reply)
+		return nil
+	}
+}
+
+func (h *scriptHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRedisStoreApplyArgs(t *testing.T) {
+	// the times of achievement are encoded as 2^32 - 1 minus the seconds since TieBreakEpoch
+	now := leaderboard.TieBreakEpoch.Add(100 * time.Second)
+	updated := []interface{}{int64(2), nil, nil, "100", int64(0)}
+	tests := []struct {
+		name   string
+		update func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error)
+		want   []string
+	}{
+		{
+			name: "set",
+			update: func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error) {
+				return lb.Update(ctx, "Alfred", 100, leaderboard.UpdateSet)
+			},
+			want: []string{"1", "{scoreboard}", "Alfred", "100", "set", "", "", ""},
+		},
+		{
+			name: "fraction",
+			update: func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error) {
+				return lb.Update(ctx, "Alfred", 0.1, leaderboard.UpdateIncrement)
+			},
+			want: []string{"1", "{scoreboard}", "Alfred", "0.1", "incr", "", "", ""},
+		},
+		{
+			name: "bounds",
+			update: func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error) {
+				lb.Bounds = &leaderboard.Bounds{Min: -10, Max: 999.5}
+				return lb.Update(ctx, "Alfred", 100, leaderboard.UpdateIncrement)
+			},
+			want: []string{"1", "{scoreboard}", "Alfred", "100", "incr", "-10", "999.5", ""},
+		},
+		{
+			name: "tie break by time",
+			update: func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error) {
+				lb.TieBreakByTime = true
+				lb.Now = func() time.Time { return now }
+				return lb.Update(ctx, "Alfred", 100, leaderboard.UpdateBest)
+			},
+			want: []string{"1", "{scoreboard}", "Alfred", "100", "best", "", "", "4294967195"},
+		},
+		{
+			name: "stream",
+			update: func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error) {
+				return lb.UpdateRecorded(ctx, "Alfred", 100, leaderboard.UpdateSet, leaderboard.ChangeStream{Key: "{scoreboard}:history"})
+			},
+			want: []string{"2", "{scoreboard}", "{scoreboard}:history", "Alfred", "100", "set", "", "", "", ""},
+		},
+		{
+			name: "stream with max length",
+			update: func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error) {
+				lb.Changes = &leaderboard.ChangeStream{Key: "{scoreboard}:history", MaxLen: 1000}
+				return lb.Update(ctx, "Alfred", 100, leaderboard.UpdateSet)
+			},
+			want: []string{"2", "{scoreboard}", "{scoreboard}:history", "Alfred", "100", "set", "", "", "", "1000"},
+		},
+		{
+			name: "submit with changes",
+			update: func(ctx context.Context, lb *leaderboard.Leaderboard) (leaderboard.UpdateResult, error) {
+				// Submit ignores the bounds
+				lb.Bounds = &leaderboard.Bounds{Min: 0, Max: 50}
+				lb.Changes = &leaderboard.ChangeStream{Key: "{scoreboard}:history"}
+				return leaderboard.UpdateResult{}, lb.Submit(ctx, "Alfred", 100)
+			},
+			want: []string{"2", "{scoreboard}", "{scoreboard}:history", "Alfred", "100", "set", "", "", "", ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hook := &scriptHook{reply: updated}
+			// the hook answers the scripts, so the client never connects
+			client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+			t.Cleanup(func() { client.Close() })
+			client.AddHook(hook)
+
+			lb := leaderboard.New(leaderboard.NewRedisStore(client), "{scoreboard}")
+			if _, err := test.update(context.Background(), lb); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+			if len(hook.calls) != 1 || !slices.Equal(hook.calls[0], test.want) {
+				t.Errorf("EVALSHA arguments = %q, want %q", hook.calls, test.want)
+			}
+		})
+	}
+}
+
+// errMalformedReply is the expected error of a malformed script reply, matching any error.
+var errMalformedReply = errors.New("malformed reply")
+
+func TestRedisStoreApplyReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   interface{}
+		want    leaderboard.UpdateResult
+		wantErr error
+	}{
+		{
+			name:  "added",
+			reply: []interface{}{int64(2), nil, nil, "100", int64(0)},
+			want:  leaderboard.UpdateResult{New: leaderboard.Entry{Rank: 1, Member: "Alfred", Score: 100}, Updated: true},
+		},
+		{
+			name:  "updated",
+			reply: []interface{}{int64(2), "50", int64(3), "100", int64(1)},
+			want: leaderboard.UpdateResult{
+				Old:     leaderboard.Entry{Rank: 4, Member: "Alfred", Score: 50},
+				New:     leaderboard.Entry{Rank: 2, Member: "Alfred", Score: 100},
+				Updated: true,
+			},
+		},
+		{
+			name:  "kept",
+			reply: []interface{}{int64(1), "50", int64(0), "50", int64(0)},
+			want: leaderboard.UpdateResult{
+				Old: leaderboard.Entry{Rank: 1, Member: "Alfred", Score: 50},
+				New: leaderboard.Entry{Rank: 1, Member: "Alfred", Score: 50},
+			},
+		},
+		{
+			name:    "rejected",
+			reply:   []interface{}{int64(0), "50", int64(0), "50", int64(0)},
+			wantErr: leaderboard.ErrScoreBelowMin,
+		},
+		{
+			name:    "malformed",
+			reply:   []interface{}{int64(2), nil, nil, "100"},
+			wantErr: errMalformedReply,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+			t.Cleanup(func() { client.Close() })
+			client.AddHook(&scriptHook{reply: test.reply})
+
+			lb := leaderboard.New(leaderboard.NewRedisStore(client), "{scoreboard}")
+			result, err := lb.Update(context.Background(), "Alfred", 100, leaderboard.UpdateSet)
+			switch {
+			case test.wantErr == nil:
+				if err != nil || result != test.want {
+					t.Errorf("Update() = %v, %v, want %v", result, err, test.want)
+				}
+			case test.wantErr == errMalformedReply:
+				if err == nil {
+					t.Errorf("Update() = %v, want error", result)
+				}
+			case !errors.Is(err, test.wantErr):
+				t.Errorf("Update() = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
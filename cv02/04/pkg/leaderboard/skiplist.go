@@ -0,0 +1,203 @@
+package leaderboard
+
+import "math/rand"
+
+const (
+	// skiplistMaxLevel is the maximum number of levels of the skiplist, enough for 2^64 elements.
+	skiplistMaxLevel = 32
+	// skiplistP is the probability of a node being promoted to the next level.
+	skiplistP = 0.25
+)
+
+// skiplistLevel is a single level of a skiplist node.
+// Span is the number of nodes skipped by following the forward pointer, used to calculate ranks.
+type skiplistLevel struct {
+	forward *skiplistNode
+	span    int64
+}
+
+// skiplistNode is a node of the skiplist holding a single member.
+type skiplistNode struct {
+	member   string
+	score    float64
+	backward *skiplistNode
+	levels   []skiplistLevel
+}
+
+// skiplist is an indexable skiplist ordered by score and then by member, modelled after the redis zskiplist.
+// Ranks are 1-based and ascending, the member with the lowest score has rank 1.
+type skiplist struct {
+	head   *skiplistNode
+	tail   *skiplistNode
+	length int64
+	level  int
+}
+
+// newSkiplist returns a new empty skiplist.
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:  &skiplistNode{levels: make([]skiplistLevel, skiplistMaxLevel)},
+		level: 1,
+	}
+}
+
+// lessThan returns true if the node is ordered before the given score and member.
+func (n *skiplistNode) lessThan(score float64, member string) bool {
+	return n.score < score || (n.score == score && n.member < member)
+}
+
+// lessOrEqual returns true if the node is ordered before or equal to the given score and member.
+func (n *skiplistNode) lessOrEqual(score float64, member string) bool {
+	return n.score < score || (n.score == score && n.member <= member)
+}
+
+// randomLevel returns a random level for a new node.
+func randomLevel() int {
+	level := 1
+	for level < skiplistMaxLevel && rand.Float64() < skiplistP {
+		level++
+	}
+	return level
+}
+
+// insert inserts the member with the given score.
+// The member must not be in the skiplist already.
+func (sl *skiplist) insert(score float64, member string) {
+	var update [skiplistMaxLevel]*skiplistNode
+	var rank [skiplistMaxLevel]int64
+
+	// find the insert position on every level and the rank of the nodes before it
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.levels[i].forward != nil && x.levels[i].forward.lessThan(score, member) {
+			rank[i] += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			update[i] = sl.head
+			update[i].levels[i].span = sl.length
+		}
+		sl.level = level
+	}
+
+	// link the new node and fix the spans
+	x = &skiplistNode{member: member, score: score, levels: make([]skiplistLevel, level)}
+	for i := 0; i < level; i++ {
+		x.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = x
+		x.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	// the levels above the new node now skip one more node
+	for i := level; i < sl.level; i++ {
+		update[i].levels[i].span++
+	}
+
+	if update[0] != sl.head {
+		x.backward = update[0]
+	}
+	if x.levels[0].forward != nil {
+		x.levels[0].forward.backward = x
+	} else {
+		sl.tail = x
+	}
+	sl.length++
+}
+
+// delete removes the member with the given score.
+// Returns false if the member with the score is not in the skiplist.
+func (sl *skiplist) delete(score float64, member string) bool {
+	var update [skiplistMaxLevel]*skiplistNode
+
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.lessThan(score, member) {
+			x = x.levels[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.levels[0].forward
+	if x == nil || x.score != score || x.member != member {
+		return false
+	}
+
+	// unlink the node and fix the spans
+	for i := 0; i < sl.level; i++ {
+		if update[i].levels[i].forward == x {
+			update[i].levels[i].span += x.levels[i].span - 1
+			update[i].levels[i].forward = x.levels[i].forward
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+	if x.levels[0].forward != nil {
+		x.levels[0].forward.backward = x.backward
+	} else {
+		sl.tail = x.backward
+	}
+	for sl.level > 1 && sl.head.levels[sl.level-1].forward == nil {
+		sl.level--
+	}
+	sl.length--
+	return true
+}
+
+// rank returns the 1-based ascending rank of the member with the given score.
+// Returns 0 if the member with the score is not in the skiplist.
+func (sl *skiplist) rank(score float64, member string) int64 {
+	var rank int64
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && x.levels[i].forward.lessOrEqual(score, member) {
+			rank += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if x != sl.head && x.score == score && x.member == member {
+			return rank
+		}
+	}
+	return 0
+}
+
+// byRank returns the node with the given 1-based ascending rank.
+// Returns nil if the rank is out of range.
+func (sl *skiplist) byRank(rank int64) *skiplistNode {
+	if rank < 1 || rank > sl.length {
+		return nil
+	}
+	var traversed int64
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && traversed+x.levels[i].span <= rank {
+			traversed += x.levels[i].span
+			x = x.levels[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+// countBelow returns the number of nodes with a score lower than the given score.
+// If inclusive is true, the nodes with a score equal to the given score are counted as well.
+func (sl *skiplist) countBelow(score float64, inclusive bool) int64 {
+	var count int64
+	x := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for x.levels[i].forward != nil && (x.levels[i].forward.score < score || (inclusive && x.levels[i].forward.score == score)) {
+			count += x.levels[i].span
+			x = x.levels[i].forward
+		}
+	}
+	return count
+}
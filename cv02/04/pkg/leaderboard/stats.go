@@ -0,0 +1,155 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Standing is the position of a member relative to the whole leaderboard.
+type Standing struct {
+	Member string
+	Score  float64
+	// Size is the number of members on the leaderboard.
+	Size int64
+	// Above is the number of members with a higher score.
+	Above int64
+	// Below is the number of members with a lower score.
+	Below int64
+}
+
+// TopPercent returns the smallest share of the best members, in percent, that includes the member,
+// e.g. 7 for a member in the top 7%. Members tied with the member are not counted as better.
+func (s Standing) TopPercent() float64 {
+	if s.Size == 0 {
+		return 0
+	}
+	return float64(s.Above+1) / float64(s.Size) * 100
+}
+
+// Percentile returns the share of the members with a lower score than the member, in percent.
+func (s Standing) Percentile() float64 {
+	if s.Size == 0 {
+		return 0
+	}
+	return float64(s.Below) / float64(s.Size) * 100
+}
+
+// Bucket is a bucket of a histogram, counting the members with a score in the range [Min, Max).
+// The first bucket starts at -Inf and the last bucket ends at +Inf, including +Inf.
+type Bucket struct {
+	Min   float64
+	Max   float64
+	Count int64
+}
+
+// LinearBoundaries returns count bucket boundaries for Histogram, starting at start and width apart.
+func LinearBoundaries(start, width float64, count int) []float64 {
+	boundaries := make([]float64, count)
+	for i := range boundaries {
+		boundaries[i] = start + float64(i)*width
+	}
+	return boundaries
+}
+
+// Percentile returns the standing of the member among all members of the leaderboard.
+// The counts are read in a single round trip after the score of the member.
+// Returns ErrMemberNotFound if the member is not on the leaderboard.
+func (lb *Leaderboard) Percentile(ctx context.Context, member string) (Standing, error) {
+	entry, err := lb.store.RevRank(ctx, lb.key, member)
+	if err != nil {
+		return Standing{}, fmt.Errorf("failed to get score of %q: %w", member, err)
+	}
+	score := lb.humanScore(entry.Score)
+	counts, err := lb.countRanges(ctx, []ScoreRange{
+		{Min: math.Nextafter(score, math.Inf(1)), Max: math.Inf(1)},
+		{Min: math.Inf(-1), Max: math.Nextafter(score, math.Inf(-1))},
+		{Min: math.Inf(-1), Max: math.Inf(1)},
+	})
+	if err != nil {
+		return Standing{}, fmt.Errorf("failed to get percentile of %q: %w", member, err)
+	}
+	return Standing{
+		Member: member,
+		Score:  score,
+		Above:  counts[0],
+		Below:  counts[1],
+		Size:   counts[2],
+	}, nil
+}
+
+// ScoreAtPercentile returns the lowest score such that at least p percent of the members have that score or lower,
+// using the nearest-rank method. Percentile 0 returns the lowest and percentile 100 the highest score.
+// Returns ErrKeyNotFound if the leaderboard is empty.
+func (lb *Leaderboard) ScoreAtPercentile(ctx context.Context, p float64) (float64, error) {
+	if !(p >= 0 && p <= 100) {
+		return 0, fmt.Errorf("invalid percentile: %v", p)
+	}
+	size, err := lb.Size(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 {
+		return 0, fmt.Errorf("failed to get score at percentile %v: %w", p, ErrKeyNotFound)
+	}
+	// the nearest rank counts from the lowest score, the store indexes from the highest one
+	nearest := max(int64(math.Ceil(p/100*float64(size))), 1)
+	index := size - nearest
+	entries, err := lb.store.RevRange(ctx, lb.key, index, index)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get score at percentile %v: %w", p, err)
+	}
+	// the member may have been removed since the size was read
+	if len(entries) == 0 {
+		return 0, fmt.Errorf("failed to get score at percentile %v: %w", p, ErrKeyNotFound)
+	}
+	return lb.humanScore(entries[0].Score), nil
+}
+
+// Histogram returns the number of members in the buckets between the boundaries, which must be increasing.
+// The n boundaries split the scores into n+1 buckets, the first one below the first boundary
+// and the last one at or above the last boundary, so the counts add up to the size of the leaderboard.
+// All buckets are counted in a single round trip.
+func (lb *Leaderboard) Histogram(ctx context.Context, boundaries []float64) ([]Bucket, error) {
+	for i, boundary := range boundaries {
+		if math.IsNaN(boundary) || math.IsInf(boundary, 0) {
+			return nil, fmt.Errorf("invalid bucket boundary: %v", boundary)
+		}
+		if i > 0 && boundary <= boundaries[i-1] {
+			return nil, fmt.Errorf("bucket boundaries must be increasing: %v after %v", boundary, boundaries[i-1])
+		}
+	}
+
+	buckets := make([]Bucket, len(boundaries)+1)
+	ranges := make([]ScoreRange, len(buckets))
+	for i := range buckets {
+		buckets[i].Min = math.Inf(-1)
+		if i > 0 {
+			buckets[i].Min = boundaries[i-1]
+		}
+		buckets[i].Max = math.Inf(1)
+		ranges[i] = ScoreRange{Min: buckets[i].Min, Max: math.Inf(1)}
+		if i < len(boundaries) {
+			buckets[i].Max = boundaries[i]
+			// the buckets are half-open, the store ranges are closed
+			ranges[i].Max = math.Nextafter(boundaries[i], math.Inf(-1))
+		}
+	}
+	counts, err := lb.countRanges(ctx, ranges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get histogram: %w", err)
+	}
+	for i := range buckets {
+		buckets[i].Count = counts[i]
+	}
+	return buckets, nil
+}
+
+// countRanges returns the number of members with a score in each of the ranges of scores of the members.
+func (lb *Leaderboard) countRanges(ctx context.Context, ranges []ScoreRange) ([]int64, error) {
+	stored := make([]ScoreRange, len(ranges))
+	for i, r := range ranges {
+		stored[i].Min, stored[i].Max = lb.scoreBounds(r.Min, r.Max)
+	}
+	return lb.store.CountMany(ctx, lb.key, stored)
+}
@@ -0,0 +1,96 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrKeyNotFound is returned when the sorted set does not exist.
+var ErrKeyNotFound = errors.New("key not found")
+
+// Aggregate determines how the scores of a member present in several sorted sets are combined.
+type Aggregate string
+
+const (
+	// AggregateSum sums the scores of the member.
+	AggregateSum Aggregate = "SUM"
+	// AggregateMin takes the lowest score of the member.
+	AggregateMin Aggregate = "MIN"
+	// AggregateMax takes the highest score of the member.
+	AggregateMax Aggregate = "MAX"
+)
+
+// ScoreRange is a range of scores [Min, Max], either of the bounds may be infinite.
+type ScoreRange struct {
+	Min float64
+	Max float64
+}
+
+// ScoreChange is a change of the score of a member in a sorted set applied by Store.ChangeMany.
+type ScoreChange struct {
+	// Key is the sorted set to change.
+	Key string
+	// Score is the new score of the member, or the delta added to its score if Increment is set.
+	Score     float64
+	Increment bool
+	// TTL is the time to live set on the sorted set after the change, a non-positive TTL leaves it unchanged.
+	TTL time.Duration
+}
+
+// Store is a storage of sorted sets backing the leaderboards.
+// Members with equal score are ordered lexicographically by their name, the same way redis orders them.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Add sets the score of the member, adding the member if it is not in the sorted set yet.
+	Add(ctx context.Context, key, member string, score float64) error
+	// AddMany adds the entries to the sorted set, the ranks of the entries are ignored.
+	// The scores of the members already in the sorted set are combined with the aggregate,
+	// or replaced if the aggregate is empty. The entries are added in order, but not atomically.
+	AddMany(ctx context.Context, key string, entries []Entry, aggregate Aggregate) error
+	// IncrBy adds delta to the score of the member and returns the new score.
+	// Members that are not in the sorted set start with a score of 0.
+	IncrBy(ctx context.Context, key, member string, delta float64) (float64, error)
+	// ChangeMany applies the changes to the score of the member in order together with their time to live
+	// in a single transaction, so no other client sees only some of them, and returns the new scores in the order of the changes.
+	ChangeMany(ctx context.Context, member string, changes []ScoreChange) ([]float64, error)
+	// RevRange returns the members between the 0-based indexes start and stop (inclusive) ordered by descending score.
+	// Negative indexes count from the end of the sorted set, -1 being the member with the lowest score.
+	RevRange(ctx context.Context, key string, start, stop int64) ([]Entry, error)
+	// RevRank returns the entry of the member with its rank in the descending order.
+	// Returns ErrMemberNotFound if the member is not in the sorted set.
+	RevRank(ctx context.Context, key, member string) (Entry, error)
+	// Count returns the number of members with a score in the range [min, max].
+	Count(ctx context.Context, key string, min, max float64) (int64, error)
+	// CountMany returns the number of members with a score in each of the ranges, in the order of the ranges.
+	// The counts are read together, so they are consistent with each other if the store can batch them.
+	CountMany(ctx context.Context, key string, ranges []ScoreRange) ([]int64, error)
+	// Remove removes the member from the sorted set.
+	// Returns ErrMemberNotFound if the member is not in the sorted set.
+	Remove(ctx context.Context, key, member string) error
+	// Rename renames the sorted set to newKey, replacing newKey if it exists.
+	// Returns ErrKeyNotFound if the sorted set does not exist.
+	Rename(ctx context.Context, key, newKey string) error
+	// Delete deletes the sorted set, it is not an error if it does not exist.
+	Delete(ctx context.Context, key string) error
+	// Expire sets the time to live of the sorted set, after which the sorted set is deleted.
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// Persist removes the time to live of the sorted set, it is not an error if it does not exist.
+	Persist(ctx context.Context, key string) error
+	// UnionStore stores the union of the sorted sets under dest, replacing dest if it exists, and returns its size.
+	// The scores are multiplied by the weights of their sorted sets before they are aggregated, nil weights are all 1.
+	// The stored sorted set expires after ttl together with the store, it does not expire if ttl is not positive.
+	UnionStore(ctx context.Context, dest string, keys []string, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error)
+	// InterStore stores the intersection of the sorted sets under dest, the same way as UnionStore,
+	// keeping only the members present in all of the sorted sets.
+	InterStore(ctx context.Context, dest string, keys []string, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error)
+	// Union returns the union of the sorted sets computed the same way as UnionStore without storing it,
+	// ordered and ranked the same way as RevRange.
+	Union(ctx context.Context, keys []string, weights []float64, aggregate Aggregate) ([]Entry, error)
+	// Inter returns the intersection of the sorted sets computed the same way as InterStore without storing it,
+	// ordered and ranked the same way as RevRange.
+	Inter(ctx context.Context, keys []string, weights []float64, aggregate Aggregate) ([]Entry, error)
+	// Apply atomically applies the conditional update to the score of the member.
+	// Returns the result together with ErrScoreBelowMin if the update is rejected by its bounds.
+	Apply(ctx context.Context, key, member string, update ScoreUpdate) (UpdateResult, error)
+}
@@ -0,0 +1,169 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrScoreBelowMin is returned when a conditional update would set a score lower than the minimum score.
+var ErrScoreBelowMin = errors.New("score below minimum")
+
+// UpdateMode determines how a conditional update changes the score of a member.
+type UpdateMode int
+
+const (
+	// UpdateSet replaces the score of the member.
+	UpdateSet UpdateMode = iota
+	// UpdateIncrement adds the value to the score of the member.
+	UpdateIncrement
+	// UpdateBest replaces the score of the member only if the new score is higher, keeping the best score (GT).
+	UpdateBest
+)
+
+// String returns the name of the update mode.
+func (m UpdateMode) String() string {
+	switch m {
+	case UpdateSet:
+		return "set"
+	case UpdateIncrement:
+		return "incr"
+	case UpdateBest:
+		return "best"
+	default:
+		return fmt.Sprintf("UpdateMode(%d)", int(m))
+	}
+}
+
+// ParseUpdateMode returns the update mode with the given name, see UpdateMode.String.
+func ParseUpdateMode(name string) (UpdateMode, error) {
+	for _, mode := range []UpdateMode{UpdateSet, UpdateIncrement, UpdateBest} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid update mode: %q", name)
+}
+
+// Bounds limits the scores of a leaderboard to the range [Min, Max].
+type Bounds struct {
+	Min float64
+	Max float64
+}
+
+// ScoreUpdate is a conditional update of the score of a member applied atomically by Store.Apply.
+// The new score is capped at Bounds.Max and rejected with ErrScoreBelowMin if it is lower than Bounds.Min.
+type ScoreUpdate struct {
+	// Mode determines how the value changes the score.
+	Mode UpdateMode
+	// Value is the new score or the delta of UpdateIncrement.
+	Value float64
+	// Bounds limits the new score, nil if the score is not limited.
+	Bounds *Bounds
+	// Composite is true if the stored scores are composite scores of TieBreakByTime.
+	// The value and the bounds are then applied to the decoded scores and TieBreak is the encoded time of achievement.
+	Composite bool
+	// TieBreak is the encoded time of achievement of the new score if Composite is true.
+	TieBreak int64
+	// Stream is the stream the change is appended to together with the update, nil if the change is not recorded.
+	Stream *ChangeStream
+}
+
+// ChangeStream is a redis stream the score changes are appended to by Store.Apply in the same script as the update,
+// so a change is recorded if and only if it is applied. Only RedisStore supports change streams.
+// Every change is appended with the fields member, mode, value, delta and score of the decoded scores, see audit.Record.
+type ChangeStream struct {
+	// Key is the key of the stream, with a redis cluster it must map to the same slot as the leaderboard.
+	Key string
+	// MaxLen trims the stream to approximately MaxLen records on every append, no trimming if zero.
+	MaxLen int64
+}
+
+// UpdateResult is the result of a conditional update.
+// The ranks are ordinal, the old entry has rank 0 if the member was not on the leaderboard.
+type UpdateResult struct {
+	Old     Entry `json:"old"`
+	New     Entry `json:"new"`
+	Updated bool  `json:"updated"`
+}
+
+// Updater applies conditional score updates.
+// It is implemented by Leaderboard and by the wrappers adding side effects to its updates.
+type Updater interface {
+	// Update changes the score of the member by the mode, see Leaderboard.Update.
+	Update(ctx context.Context, member string, value float64, mode UpdateMode) (UpdateResult, error)
+}
+
+// Interface guard for Updater.
+var _ Updater = (*Leaderboard)(nil)
+
+// Update atomically changes the score of the member by the mode, enforcing the bounds of the leaderboard.
+// It returns the old and the new entry of the member, Updated is false if UpdateBest kept the old score.
+// Returns ErrScoreBelowMin if the new score would be lower than the minimum score, the score is not changed then.
+// The change is appended to Changes if it is set.
+func (lb *Leaderboard) Update(ctx context.Context, member string, value float64, mode UpdateMode) (UpdateResult, error) {
+	return lb.update(ctx, member, value, mode, lb.Bounds, lb.Changes)
+}
+
+// UpdateRecorded changes the score of the member like Update and appends the change to the stream atomically
+// with the update if the score is updated, instead of Changes.
+func (lb *Leaderboard) UpdateRecorded(ctx context.Context, member string, value float64, mode UpdateMode, stream ChangeStream) (UpdateResult, error) {
+	return lb.update(ctx, member, value, mode, lb.Bounds, &stream)
+}
+
+// update applies the conditional update within the bounds, appending the change to the stream if it is not nil.
+func (lb *Leaderboard) update(ctx context.Context, member string, value float64, mode UpdateMode, bounds *Bounds, stream *ChangeStream) (UpdateResult, error) {
+	update := ScoreUpdate{
+		Mode:   mode,
+		Value:  value,
+		Bounds: bounds,
+		Stream: stream,
+	}
+	if lb.TieBreakByTime {
+		if err := validateTieBreakScore(value); err != nil {
+			return UpdateResult{}, fmt.Errorf("failed to update score of %q: %w", member, err)
+		}
+		update.Composite = true
+		update.TieBreak = tieBreakTime(lb.now())
+	}
+
+	result, err := lb.store.Apply(ctx, lb.key, member, update)
+	if err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to update score of %q: %w", member, err)
+	}
+	result.Old.Score = lb.humanScore(result.Old.Score)
+	result.New.Score = lb.humanScore(result.New.Score)
+	return result, nil
+}
+
+// applyUpdate applies the update to the current stored score and returns the new stored score.
+// It returns false if the stored score must be kept and ErrScoreBelowMin if the update is rejected.
+// It mirrors applyScript of RedisStore for the stores that apply the updates in Go.
+func applyUpdate(stored float64, exists bool, update ScoreUpdate) (float64, bool, error) {
+	var current float64
+	if exists {
+		current = stored
+		if update.Composite {
+			current = decodeScore(stored)
+		}
+	}
+
+	score := update.Value
+	if update.Mode == UpdateIncrement {
+		score += current
+	}
+	if update.Bounds != nil {
+		if score < update.Bounds.Min {
+			return stored, false, ErrScoreBelowMin
+		}
+		score = min(score, update.Bounds.Max)
+	}
+	if update.Mode == UpdateBest && exists && score <= current {
+		return stored, false, nil
+	}
+
+	if update.Composite {
+		return score*tieBreakShift + float64(update.TieBreak), true, nil
+	}
+	return score, true, nil
+}
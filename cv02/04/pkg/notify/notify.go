@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultMaxOvertaken is the default maximum number of overtaken members listed in an event.
+const DefaultMaxOvertaken = 100
+
+// RankChange is the event published when a score update changes the rank of a member.
+// OldRank is 0 if the member was not on the leaderboard before the update.
+// Overtaken lists the members the member moved above, best first, limited by Notifier.MaxOvertaken.
+type RankChange struct {
+	Member    string    `json:"member"`
+	OldRank   int64     `json:"oldRank"`
+	NewRank   int64     `json:"newRank"`
+	Score     float64   `json:"score"`
+	Overtaken []string  `json:"overtaken,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Publisher publishes rank change events.
+type Publisher interface {
+	// Publish publishes the event.
+	Publish(ctx context.Context, event RankChange) error
+}
+
+// PublisherFunc is an adapter to use an ordinary function as a Publisher.
+type PublisherFunc func(ctx context.Context, event RankChange) error
+
+// Interface guard for Publisher.
+var _ Publisher = PublisherFunc(nil)
+
+// Publish calls f(ctx, event).
+func (f PublisherFunc) Publish(ctx context.Context, event RankChange) error {
+	return f(ctx, event)
+}
+
+// RedisPublisher implements Publisher by publishing JSON encoded events to a redis pub/sub channel.
+type RedisPublisher struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// Interface guard for Publisher.
+var _ Publisher = (*RedisPublisher)(nil)
+
+// NewRedisPublisher returns a new RedisPublisher publishing to the channel.
+func NewRedisPublisher(client redis.UniversalClient, channel string) *RedisPublisher {
+	return &RedisPublisher{
+		client:  client,
+		channel: channel,
+	}
+}
+
+// Publish publishes the event to the channel using PUBLISH.
+func (p *RedisPublisher) Publish(ctx context.Context, event RankChange) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if err := p.client.Publish(ctx, p.channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish event to %q: %w", p.channel, err)
+	}
+	return nil
+}
+
+// Pager reads a page of the leaderboard, it is implemented by leaderboard.Leaderboard.
+type Pager interface {
+	// Page returns limit members starting at the 0-based offset ordered from the best.
+	Page(ctx context.Context, offset, limit int64) ([]leaderboard.Entry, error)
+}
+
+// Interface guard for Pager.
+var _ Pager = (*leaderboard.Leaderboard)(nil)
+
+// Notifier updates scores with an updater and publishes an event whenever an update changes the rank of a member.
+type Notifier struct {
+	// MaxOvertaken is the maximum number of overtaken members listed in an event, DefaultMaxOvertaken if zero.
+	MaxOvertaken int64
+	// Now returns the time of the events, time.Now if nil.
+	Now func() time.Time
+
+	updater   leaderboard.Updater
+	pager     Pager
+	publisher Publisher
+}
+
+// Interface guard for leaderboard.Updater.
+var _ leaderboard.Updater = (*Notifier)(nil)
+
+// NewNotifier returns a new Notifier updating the scores with the updater, e.g. a leaderboard or an audit recorder,
+// reading the overtaken members from the pager of the same leaderboard and publishing the events with the publisher.
+func NewNotifier(updater leaderboard.Updater, pager Pager, publisher Publisher) *Notifier {
+	return &Notifier{
+		updater:   updater,
+		pager:     pager,
+		publisher: publisher,
+	}
+}
+
+// Submit sets the score of the member, see Update.
+func (n *Notifier) Submit(ctx context.Context, member string, score float64) (leaderboard.UpdateResult, error) {
+	return n.Update(ctx, member, score, leaderboard.UpdateSet)
+}
+
+// Increment adds delta to the score of the member, see Update.
+func (n *Notifier) Increment(ctx context.Context, member string, delta float64) (leaderboard.UpdateResult, error) {
+	return n.Update(ctx, member, delta, leaderboard.UpdateIncrement)
+}
+
+// Update updates the score of the member with the updater and publishes a RankChange event
+// if the rank of the member changed. The update is committed before the event is published,
+// so a failure to publish the event is logged and not returned, otherwise a retry would apply the update twice.
+func (n *Notifier) Update(ctx context.Context, member string, value float64, mode leaderboard.UpdateMode) (leaderboard.UpdateResult, error) {
+	result, err := n.updater.Update(ctx, member, value, mode)
+	if err != nil {
+		return result, err
+	}
+	if result.Old.Rank == result.New.Rank {
+		return result, nil
+	}
+	if err := n.notify(ctx, member, result); err != nil {
+		slog.Error("Failed to publish rank change", "member", member, "oldRank", result.Old.Rank, "newRank", result.New.Rank, "error", err)
+	}
+	return result, nil
+}
+
+// notify publishes the RankChange event of the update result of the member.
+func (n *Notifier) notify(ctx context.Context, member string, result leaderboard.UpdateResult) error {
+	event := RankChange{
+		Member:  member,
+		OldRank: result.Old.Rank,
+		NewRank: result.New.Rank,
+		Score:   result.New.Score,
+		Time:    n.now(),
+	}
+	// the members overtaken by a climbing member are now right below it
+	if result.Old.Rank > result.New.Rank {
+		overtaken, err := n.pager.Page(ctx, result.New.Rank, min(result.Old.Rank-result.New.Rank, n.maxOvertaken()))
+		if err != nil {
+			return fmt.Errorf("failed to get members overtaken by %q: %w", member, err)
+		}
+		for _, entry := range overtaken {
+			event.Overtaken = append(event.Overtaken, entry.Member)
+		}
+	}
+
+	if err := n.publisher.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to notify about rank change of %q: %w", member, err)
+	}
+	return nil
+}
+
+// maxOvertaken returns the maximum number of overtaken members listed in an event.
+func (n *Notifier) maxOvertaken() int64 {
+	if n.MaxOvertaken > 0 {
+		return n.MaxOvertaken
+	}
+	return DefaultMaxOvertaken
+}
+
+// now returns the current time of the notifier.
+func (n *Notifier) now() time.Time {
+	if n.Now != nil {
+		return n.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,111 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/notify"
+)
+
+// newNotifier returns a notifier of a leaderboard in a memory store with the scores of the players,
+// collecting the published events into events.
+func newNotifier(t *testing.T, scores map[string]float64, events *[]notify.RankChange) (*notify.Notifier, *leaderboard.Leaderboard) {
+	t.Helper()
+	lb := leaderboard.New(leaderboard.NewMemoryStore(), "{scoreboard}")
+	for member, score := range scores {
+		if err := lb.Submit(context.Background(), member, score); err != nil {
+			t.Fatalf("Submit(%q, %v) failed: %v", member, score, err)
+		}
+	}
+	publisher := notify.PublisherFunc(func(ctx context.Context, event notify.RankChange) error {
+		*events = append(*events, event)
+		return nil
+	})
+	return notify.NewNotifier(lb, lb, publisher), lb
+}
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var events []notify.RankChange
+	notifier, _ := newNotifier(t, map[string]float64{"Alfred": 400, "Bob": 300, "Cecil": 200, "Dave": 100}, &events)
+	notifier.Now = func() time.Time { return now }
+
+	// Dave climbs from the 4th to the 2nd rank over Bob and Cecil
+	if _, err := notifier.Increment(ctx, "Dave", 250); err != nil {
+		t.Fatalf("Increment(Dave, 250) failed: %v", err)
+	}
+	// the ranks do not change
+	if _, err := notifier.Increment(ctx, "Alfred", 10); err != nil {
+		t.Fatalf("Increment(Alfred, 10) failed: %v", err)
+	}
+	if _, err := notifier.Update(ctx, "Bob", 100, leaderboard.UpdateBest); err != nil {
+		t.Fatalf("Update(Bob, 100, best) failed: %v", err)
+	}
+	// Cecil drops below Eve, a new member, who overtakes nobody else
+	if _, err := notifier.Submit(ctx, "Eve", 250); err != nil {
+		t.Fatalf("Submit(Eve, 250) failed: %v", err)
+	}
+
+	want := []notify.RankChange{
+		{Member: "Dave", OldRank: 4, NewRank: 2, Score: 350, Overtaken: []string{"Bob", "Cecil"}, Time: now},
+		{Member: "Eve", OldRank: 0, NewRank: 4, Score: 250, Time: now},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i, event := range events {
+		if event.Member != want[i].Member || event.OldRank != want[i].OldRank || event.NewRank != want[i].NewRank ||
+			event.Score != want[i].Score || !slices.Equal(event.Overtaken, want[i].Overtaken) || !event.Time.Equal(want[i].Time) {
+			t.Errorf("event %d = %v, want %v", i, event, want[i])
+		}
+	}
+}
+
+func TestNotifierMaxOvertaken(t *testing.T) {
+	var events []notify.RankChange
+	notifier, _ := newNotifier(t, map[string]float64{"Alfred": 400, "Bob": 300, "Cecil": 200, "Dave": 100}, &events)
+	notifier.MaxOvertaken = 2
+
+	if _, err := notifier.Submit(context.Background(), "Dave", 500); err != nil {
+		t.Fatalf("Submit(Dave, 500) failed: %v", err)
+	}
+	if len(events) != 1 || !slices.Equal(events[0].Overtaken, []string{"Alfred", "Bob"}) {
+		t.Errorf("events = %v, want Dave overtaking [Alfred Bob]", events)
+	}
+}
+
+func TestNotifierPublishFailure(t *testing.T) {
+	ctx := context.Background()
+	lb := leaderboard.New(leaderboard.NewMemoryStore(), "{scoreboard}")
+	if err := lb.Submit(ctx, "Alfred", 100); err != nil {
+		t.Fatalf("Submit(Alfred, 100) failed: %v", err)
+	}
+	published := 0
+	publisher := notify.PublisherFunc(func(ctx context.Context, event notify.RankChange) error {
+		published++
+		return errors.New("connection refused")
+	})
+	notifier := notify.NewNotifier(lb, lb, publisher)
+
+	// the update is committed, so the failure to publish the event does not fail it
+	result, err := notifier.Submit(ctx, "Bob", 200)
+	if err != nil {
+		t.Fatalf("Submit(Bob, 200) = %v, want no error", err)
+	}
+	want := leaderboard.UpdateResult{New: leaderboard.Entry{Rank: 1, Member: "Bob", Score: 200}, Updated: true}
+	if result != want {
+		t.Errorf("Submit(Bob, 200) = %v, want %v", result, want)
+	}
+	if published != 1 {
+		t.Errorf("published %d events, want 1", published)
+	}
+	entry, err := lb.Rank(ctx, "Bob")
+	if err != nil || entry.Score != 200 {
+		t.Errorf("Rank(Bob) = %v, %v, want score 200", entry, err)
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultMinBackoff is the default delay before the first resubscription after a failure.
+	DefaultMinBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is the default maximum delay between resubscriptions.
+	DefaultMaxBackoff = 10 * time.Second
+)
+
+// Subscriber receives rank change events from a redis pub/sub channel.
+// Redis pub/sub does not store messages, so the events published while the subscriber is reconnecting are lost.
+type Subscriber struct {
+	// MinBackoff is the delay before the first resubscription after a failure, DefaultMinBackoff if zero.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between resubscriptions, DefaultMaxBackoff if zero.
+	MaxBackoff time.Duration
+
+	client  redis.UniversalClient
+	channel string
+}
+
+// NewSubscriber returns a new Subscriber of the channel.
+func NewSubscriber(client redis.UniversalClient, channel string) *Subscriber {
+	return &Subscriber{
+		client:  client,
+		channel: channel,
+	}
+}
+
+// Run subscribes to the channel and calls handle for every received event until the context is done.
+// When the subscription fails, it resubscribes with an exponential backoff. Malformed events are logged and skipped.
+// It always returns the error of the context.
+func (s *Subscriber) Run(ctx context.Context, handle func(event RankChange)) error {
+	backoff := s.minBackoff()
+	for {
+		subscribed, err := s.receive(ctx, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// the backoff grows only while the subscription cannot be established
+		if subscribed {
+			backoff = s.minBackoff()
+		}
+		slog.Warn("Subscription failed, resubscribing", "channel", s.channel, "backoff", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, s.maxBackoff())
+	}
+}
+
+// receive subscribes to the channel and handles the events until receiving fails.
+// It returns true if the subscription was established before the failure.
+func (s *Subscriber) receive(ctx context.Context, handle func(event RankChange)) (bool, error) {
+	pubsub := s.client.Subscribe(ctx, s.channel)
+	defer pubsub.Close()
+	// wait for the confirmation of the subscription
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return false, err
+	}
+
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			return true, err
+		}
+		var event RankChange
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			slog.Warn("Skipping malformed event", "channel", s.channel, "error", err)
+			continue
+		}
+		handle(event)
+	}
+}
+
+// minBackoff returns the delay before the first resubscription.
+func (s *Subscriber) minBackoff() time.Duration {
+	if s.MinBackoff > 0 {
+		return s.MinBackoff
+	}
+	return DefaultMinBackoff
+}
+
+// maxBackoff returns the maximum delay between resubscriptions.
+func (s *Subscriber) maxBackoff() time.Duration {
+	if s.MaxBackoff > 0 {
+		return s.MaxBackoff
+	}
+	return DefaultMaxBackoff
+}
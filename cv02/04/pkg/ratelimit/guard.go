@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+
+	"dpb-cv02-04/pkg/leaderboard"
+)
+
+// Guard consults a limiter before every score update and rejects the updates over the limits.
+type Guard struct {
+	updater leaderboard.Updater
+	limiter Limiter
+}
+
+// Interface guard for leaderboard.Updater.
+var _ leaderboard.Updater = (*Guard)(nil)
+
+// NewGuard returns a new Guard updating the scores with the updater, e.g. a leaderboard or an audit recorder,
+// if the limiter allows the update.
+func NewGuard(updater leaderboard.Updater, limiter Limiter) *Guard {
+	return &Guard{
+		updater: updater,
+		limiter: limiter,
+	}
+}
+
+// Submit sets the score of the member, see Update.
+func (g *Guard) Submit(ctx context.Context, member string, score float64) (leaderboard.UpdateResult, error) {
+	return g.Update(ctx, member, score, leaderboard.UpdateSet)
+}
+
+// Increment adds delta to the score of the member, see Update.
+func (g *Guard) Increment(ctx context.Context, member string, delta float64) (leaderboard.UpdateResult, error) {
+	return g.Update(ctx, member, delta, leaderboard.UpdateIncrement)
+}
+
+// Update updates the score of the member with the updater if the limiter allows it.
+// Returns a *LimitError wrapping ErrLimited if the update is rejected.
+// Every update counts against the limits, including the updates that do not change the score.
+func (g *Guard) Update(ctx context.Context, member string, value float64, mode leaderboard.UpdateMode) (leaderboard.UpdateResult, error) {
+	decision, err := g.limiter.Allow(ctx, member)
+	if err != nil {
+		return leaderboard.UpdateResult{}, err
+	}
+	if !decision.Allowed {
+		return leaderboard.UpdateResult{}, &LimitError{Member: member, RetryAfter: decision.RetryAfter}
+	}
+	return g.updater.Update(ctx, member, value, mode)
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter implements Limiter with sliding window logs held in memory, with the same semantics as RedisLimiter.
+// It limits a single process only and is intended for tests and local development.
+type MemoryLimiter struct {
+	// Now returns the current time of the events, time.Now if nil.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	limits  Limits
+	members map[string][]time.Time
+	global  []time.Time
+}
+
+// Interface guard for Limiter.
+var _ Limiter = (*MemoryLimiter)(nil)
+
+// NewMemoryLimiter returns a new MemoryLimiter with the limits.
+func NewMemoryLimiter(limits Limits) *MemoryLimiter {
+	return &MemoryLimiter{
+		limits:  limits,
+		members: make(map[string][]time.Time),
+	}
+}
+
+// Allow decides whether an event of the member is allowed.
+func (l *MemoryLimiter) Allow(ctx context.Context, member string) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	decision := Decision{Allowed: true, Remaining: -1}
+	if l.limits.PerMember.Enabled() {
+		l.members[member] = prune(l.members[member], now, l.limits.PerMember.Window)
+		decide(&decision, l.members[member], now, l.limits.PerMember)
+	}
+	if l.limits.Global.Enabled() {
+		l.global = prune(l.global, now, l.limits.Global.Window)
+		decide(&decision, l.global, now, l.limits.Global)
+	}
+	if !decision.Allowed {
+		decision.Remaining = 0
+		if len(l.members[member]) == 0 {
+			delete(l.members, member)
+		}
+		return decision, nil
+	}
+
+	if l.limits.PerMember.Enabled() {
+		l.members[member] = append(l.members[member], now)
+	}
+	if l.limits.Global.Enabled() {
+		l.global = append(l.global, now)
+	}
+	return decision, nil
+}
+
+// prune removes the events that left the window from the log ordered by time.
+func prune(events []time.Time, now time.Time, window time.Duration) []time.Time {
+	start := 0
+	for start < len(events) && !events[start].After(now.Add(-window)) {
+		start++
+	}
+	return events[start:]
+}
+
+// decide applies the limit with the pruned log of events to the decision.
+func decide(decision *Decision, events []time.Time, now time.Time, limit Limit) {
+	count := int64(len(events))
+	if count >= limit.Events {
+		decision.Allowed = false
+		// the event is allowed once enough of the oldest events leave the window
+		decision.RetryAfter = max(decision.RetryAfter, events[count-limit.Events].Add(limit.Window).Sub(now))
+	}
+	if remaining := max(limit.Events-count-1, 0); decision.Remaining < 0 || remaining < decision.Remaining {
+		decision.Remaining = remaining
+	}
+}
+
+// now returns the current time.
+func (l *MemoryLimiter) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
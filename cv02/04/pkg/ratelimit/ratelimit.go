@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLimited is returned when an event is rejected by a rate limit.
+var ErrLimited = errors.New("rate limit exceeded")
+
+// Limit allows at most Events events in any sliding window of length Window.
+// The zero Limit allows everything.
+type Limit struct {
+	Events int64
+	Window time.Duration
+}
+
+// Enabled reports whether the limit limits anything.
+func (l Limit) Enabled() bool {
+	return l.Events > 0 && l.Window > 0
+}
+
+// String returns the limit in the format of ParseLimit, an empty string for the zero Limit.
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf("%d/%v", l.Events, l.Window)
+}
+
+// ParseLimit parses a limit in the format "events/window", e.g. "10/1m" or "5/s".
+// An empty string is the zero Limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" {
+		return Limit{}, nil
+	}
+	eventsStr, windowStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit: %q", s)
+	}
+	events, err := strconv.ParseInt(eventsStr, 10, 64)
+	if err != nil || events <= 0 {
+		return Limit{}, fmt.Errorf("invalid number of events: %q", eventsStr)
+	}
+	// a unit alone is a window of one unit, e.g. "5/s"
+	if windowStr != "" && (windowStr[0] < '0' || windowStr[0] > '9') {
+		windowStr = "1" + windowStr
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil || window <= 0 {
+		return Limit{}, fmt.Errorf("invalid window: %q", windowStr)
+	}
+	return Limit{Events: events, Window: window}, nil
+}
+
+// Limits are the limits applied together to every event, a zero Limit is not applied.
+type Limits struct {
+	// PerMember limits the events of each member separately.
+	PerMember Limit
+	// Global limits the events of all members together.
+	Global Limit
+}
+
+// Decision is the decision of a limiter about a single event.
+type Decision struct {
+	// Allowed reports whether the event was allowed and counted.
+	Allowed bool
+	// Remaining is the number of events still allowed by the tightest limit, -1 if no limit is enabled.
+	Remaining int64
+	// RetryAfter is the time after which the event would be allowed, zero if it was allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether the events of the members are allowed.
+// Implementations must be safe for concurrent use.
+type Limiter interface {
+	// Allow decides whether an event of the member is allowed by all the limits at once.
+	// An allowed event is counted against all the limits, a rejected event is not counted against any of them.
+	Allow(ctx context.Context, member string) (Decision, error)
+}
+
+// LimitError is the error of a rejected event carrying the time after which it would be allowed.
+type LimitError struct {
+	Member     string
+	RetryAfter time.Duration
+}
+
+// Error returns the message of the error.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit of %q exceeded, retry after %v", e.Member, e.RetryAfter)
+}
+
+// Unwrap returns ErrLimited, so errors.Is(err, ErrLimited) reports the rejected events.
+func (e *LimitError) Unwrap() error {
+	return ErrLimited
+}
@@ -0,0 +1,233 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/ratelimit"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisAddrEnv is the environment variable with the address of a real redis to run the tests against,
+// the same as in the tests of the leaderboard package. The database of the address is flushed by the tests.
+const redisAddrEnv = "LEADERBOARD_TEST_REDIS_ADDR"
+
+// start is the time of the first event of the tests.
+var start = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// event is an event of a member at an offset from start with the expected decision.
+type event struct {
+	member string
+	at     time.Duration
+	want   ratelimit.Decision
+}
+
+// allowed returns the decision allowing an event with the remaining number of events.
+func allowed(remaining int64) ratelimit.Decision {
+	return ratelimit.Decision{Allowed: true, Remaining: remaining}
+}
+
+// rejected returns the decision rejecting an event until retryAfter.
+func rejected(retryAfter time.Duration) ratelimit.Decision {
+	return ratelimit.Decision{RetryAfter: retryAfter}
+}
+
+var limiterTests = []struct {
+	name   string
+	limits ratelimit.Limits
+	events []event
+}{
+	{
+		name:   "no limits",
+		limits: ratelimit.Limits{},
+		events: []event{
+			{"Alfred", 0, allowed(-1)},
+			{"Alfred", 0, allowed(-1)},
+		},
+	},
+	{
+		name:   "window boundary",
+		limits: ratelimit.Limits{PerMember: ratelimit.Limit{Events: 2, Window: time.Minute}},
+		events: []event{
+			{"Alfred", 0, allowed(1)},
+			{"Alfred", 10 * time.Second, allowed(0)},
+			{"Alfred", 30 * time.Second, rejected(30 * time.Second)},
+			{"Alfred", time.Minute - time.Millisecond, rejected(time.Millisecond)},
+			// the first event leaves the window exactly after Window
+			{"Alfred", time.Minute, allowed(0)},
+			{"Alfred", time.Minute + 5*time.Second, rejected(5 * time.Second)},
+			{"Alfred", time.Minute + 10*time.Second, allowed(0)},
+		},
+	},
+	{
+		name:   "per member",
+		limits: ratelimit.Limits{PerMember: ratelimit.Limit{Events: 1, Window: time.Second}},
+		events: []event{
+			{"Alfred", 0, allowed(0)},
+			{"Bob", 0, allowed(0)},
+			{"Alfred", 500 * time.Millisecond, rejected(500 * time.Millisecond)},
+			{"Cecil", 500 * time.Millisecond, allowed(0)},
+			{"Alfred", time.Second, allowed(0)},
+		},
+	},
+	{
+		name:   "global",
+		limits: ratelimit.Limits{Global: ratelimit.Limit{Events: 3, Window: 10 * time.Second}},
+		events: []event{
+			{"Alfred", 0, allowed(2)},
+			{"Bob", time.Second, allowed(1)},
+			{"Cecil", 2 * time.Second, allowed(0)},
+			{"Dave", 3 * time.Second, rejected(7 * time.Second)},
+			{"Alfred", 10 * time.Second, allowed(0)},
+		},
+	},
+	{
+		name: "per member and global",
+		limits: ratelimit.Limits{
+			PerMember: ratelimit.Limit{Events: 2, Window: 10 * time.Second},
+			Global:    ratelimit.Limit{Events: 3, Window: time.Second},
+		},
+		events: []event{
+			// the remaining number of events is of the tightest limit
+			{"Alfred", 0, allowed(1)},
+			{"Bob", 0, allowed(1)},
+			{"Alfred", 100 * time.Millisecond, allowed(0)},
+			// both limits reject the event, the later retry wins
+			{"Alfred", 200 * time.Millisecond, rejected(9800 * time.Millisecond)},
+			// the global limit alone rejects the event
+			{"Bob", 300 * time.Millisecond, rejected(700 * time.Millisecond)},
+			{"Bob", time.Second, allowed(0)},
+			{"Cecil", 1100 * time.Millisecond, allowed(1)},
+			// the rejected event of Alfred was not counted against its limit
+			{"Alfred", 10 * time.Second, allowed(0)},
+		},
+	},
+	{
+		name:   "rejected events are not counted",
+		limits: ratelimit.Limits{PerMember: ratelimit.Limit{Events: 1, Window: time.Second}},
+		events: []event{
+			{"Alfred", 0, allowed(0)},
+			{"Alfred", 200 * time.Millisecond, rejected(800 * time.Millisecond)},
+			{"Alfred", 900 * time.Millisecond, rejected(100 * time.Millisecond)},
+			{"Alfred", time.Second, allowed(0)},
+		},
+	},
+}
+
+// testLimiter runs the limiter tests with the limiters returned by newLimiter with the time of the events.
+func testLimiter(t *testing.T, newLimiter func(t *testing.T, limits ratelimit.Limits, now func() time.Time) ratelimit.Limiter) {
+	for _, test := range limiterTests {
+		t.Run(test.name, func(t *testing.T) {
+			var now time.Time
+			limiter := newLimiter(t, test.limits, func() time.Time { return now })
+			for _, event := range test.events {
+				now = start.Add(event.at)
+				decision, err := limiter.Allow(context.Background(), event.member)
+				if err != nil {
+					t.Fatalf("Allow(%q) at %v failed: %v", event.member, event.at, err)
+				}
+				if decision != event.want {
+					t.Errorf("Allow(%q) at %v = %+v, want %+v", event.member, event.at, decision, event.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	testLimiter(t, func(t *testing.T, limits ratelimit.Limits, now func() time.Time) ratelimit.Limiter {
+		limiter := ratelimit.NewMemoryLimiter(limits)
+		limiter.Now = now
+		return limiter
+	})
+}
+
+// TestRedisLimiter checks that the script of RedisLimiter agrees with MemoryLimiter.
+// It runs only if the address of a real redis is set, since the limiter uses lua scripts.
+func TestRedisLimiter(t *testing.T) {
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skip(redisAddrEnv + " is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	testLimiter(t, func(t *testing.T, limits ratelimit.Limits, now func() time.Time) ratelimit.Limiter {
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Fatalf("FLUSHDB failed: %v", err)
+		}
+		limiter := ratelimit.NewRedisLimiter(client, "limits", limits)
+		limiter.Now = now
+		return limiter
+	})
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    ratelimit.Limit
+		wantErr bool
+	}{
+		{"", ratelimit.Limit{}, false},
+		{"5/s", ratelimit.Limit{Events: 5, Window: time.Second}, false},
+		{"10/1m", ratelimit.Limit{Events: 10, Window: time.Minute}, false},
+		{"100/1h30m", ratelimit.Limit{Events: 100, Window: 90 * time.Minute}, false},
+		{"0/s", ratelimit.Limit{}, true},
+		{"-1/s", ratelimit.Limit{}, true},
+		{"5/0s", ratelimit.Limit{}, true},
+		{"5/", ratelimit.Limit{}, true},
+		{"5", ratelimit.Limit{}, true},
+		{"x", ratelimit.Limit{}, true},
+		{"x/s", ratelimit.Limit{}, true},
+		{"5/fortnight", ratelimit.Limit{}, true},
+	}
+	for _, test := range tests {
+		limit, err := ratelimit.ParseLimit(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseLimit(%q) error = %v, want error %v", test.s, err, test.wantErr)
+			continue
+		}
+		if limit != test.want {
+			t.Errorf("ParseLimit(%q) = %v, want %v", test.s, limit, test.want)
+		}
+		// the limits are formatted back in a parsable form
+		if err == nil {
+			if parsed, err := ratelimit.ParseLimit(limit.String()); err != nil || parsed != limit {
+				t.Errorf("ParseLimit(%q) = %v, %v, want %v", limit.String(), parsed, err, limit)
+			}
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	now := start
+	lb := leaderboard.New(leaderboard.NewMemoryStore(), "{scoreboard}")
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.Limits{PerMember: ratelimit.Limit{Events: 1, Window: time.Minute}})
+	limiter.Now = func() time.Time { return now }
+	guard := ratelimit.NewGuard(lb, limiter)
+
+	if _, err := guard.Submit(ctx, "Alfred", 100); err != nil {
+		t.Fatalf("Submit(Alfred, 100) failed: %v", err)
+	}
+	now = now.Add(20 * time.Second)
+	_, err := guard.Increment(ctx, "Alfred", 10)
+	if !errors.Is(err, ratelimit.ErrLimited) {
+		t.Fatalf("Increment(Alfred, 10) = %v, want ErrLimited", err)
+	}
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Member != "Alfred" || limitErr.RetryAfter != 40*time.Second {
+		t.Errorf("Increment(Alfred, 10) = %#v, want *LimitError of Alfred retrying after 40s", err)
+	}
+	// the rejected update is not applied
+	if entry, err := lb.Rank(ctx, "Alfred"); err != nil || entry.Score != 100 {
+		t.Errorf("Rank(Alfred) = %v, %v, want score 100", entry, err)
+	}
+	if _, err := guard.Increment(ctx, "Bob", 10); err != nil {
+		t.Errorf("Increment(Bob, 10) failed: %v", err)
+	}
+}
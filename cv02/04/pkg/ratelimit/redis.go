@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"dpb-cv02-04/pkg/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLimiter implements Limiter with sliding window logs stored in redis sorted sets.
+// Every allowed event is a member of the sorted sets of the limits scored by its time, so a window
+// is counted exactly with ZCARD after the events older than the window are removed.
+// All keys share the hash tag of the name, so the limits of a member and the global limit are checked
+// atomically by a single script, also with a cluster.
+type RedisLimiter struct {
+	// Now returns the current time of the events, time.Now if nil.
+	Now func() time.Time
+
+	client redis.UniversalClient
+	base   string
+	limits Limits
+}
+
+// Interface guard for Limiter.
+var _ Limiter = (*RedisLimiter)(nil)
+
+// NewRedisLimiter returns a new RedisLimiter with the limits stored under keys derived from the name.
+func NewRedisLimiter(client redis.UniversalClient, name string, limits Limits) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		base:   keyspace.HashTag(name),
+		limits: limits,
+	}
+}
+
+// MemberKey returns the key of the window of the member.
+func (l *RedisLimiter) MemberKey(member string) string {
+	return l.base + ":member:" + member
+}
+
+// GlobalKey returns the key of the global window.
+func (l *RedisLimiter) GlobalKey() string {
+	return l.base + ":global"
+}
+
+// allowScript checks the windows of the limits and records the event in all of them if all allow it.
+// KEYS are the windows, ARGV holds the current time in microseconds and the ID of the event,
+// followed by the number of events and the length in microseconds of the window of each key.
+// It returns whether the event was allowed, the remaining number of events and the retry-after in microseconds.
+var allowScript = redis.NewScript(`
+local now, id = tonumber(ARGV[1]), ARGV[2]
+local allowed, remaining, retry = 1, -1, 0
+for i, key in ipairs(KEYS) do
+	local events, window = tonumber(ARGV[2 * i + 1]), tonumber(ARGV[2 * i + 2])
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+	local count = redis.call('ZCARD', key)
+	if count >= events then
+		allowed = 0
+		-- the event is allowed once enough of the oldest events leave the window
+		local oldest = redis.call('ZRANGE', key, count - events, count - events, 'WITHSCORES')
+		retry = math.max(retry, tonumber(oldest[2]) + window - now)
+	end
+	if remaining < 0 or events - count - 1 < remaining then
+		remaining = math.max(events - count - 1, 0)
+	end
+end
+if allowed == 0 then
+	return {0, 0, retry}
+end
+for i, key in ipairs(KEYS) do
+	local window = tonumber(ARGV[2 * i + 2])
+	redis.call('ZADD', key, now, id)
+	redis.call('PEXPIRE', key, math.ceil(window / 1000))
+end
+return {1, remaining, 0}
+`)
+
+// Allow decides whether an event of the member is allowed using a lua script.
+func (l *RedisLimiter) Allow(ctx context.Context, member string) (Decision, error) {
+	id, err := newID()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to generate event ID: %w", err)
+	}
+	now := l.now().UnixMicro()
+	var keys []string
+	args := []interface{}{now, id}
+	if l.limits.PerMember.Enabled() {
+		keys = append(keys, l.MemberKey(member))
+		args = append(args, l.limits.PerMember.Events, l.limits.PerMember.Window.Microseconds())
+	}
+	if l.limits.Global.Enabled() {
+		keys = append(keys, l.GlobalKey())
+		args = append(args, l.limits.Global.Events, l.limits.Global.Window.Microseconds())
+	}
+	if len(keys) == 0 {
+		return Decision{Allowed: true, Remaining: -1}, nil
+	}
+
+	reply, err := allowScript.Run(ctx, l.client, keys, args...).Int64Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to check rate limit of %q: %w", member, err)
+	}
+	if len(reply) != 3 {
+		return Decision{}, fmt.Errorf("unexpected script reply: %v", reply)
+	}
+	return Decision{
+		Allowed:    reply[0] == 1,
+		Remaining:  reply[1],
+		RetryAfter: time.Duration(reply[2]) * time.Microsecond,
+	}, nil
+}
+
+// now returns the current time.
+func (l *RedisLimiter) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+// newID returns a new random event ID, so events at the same time are counted separately.
+func newID() (string, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
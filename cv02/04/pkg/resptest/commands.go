@@ -0,0 +1,524 @@
+package resptest
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the redis version reported by HELLO.
+const Version = "7.2.0"
+
+// defaultScanCount is the number of keys visited by a single SCAN if no COUNT is given, the same as in redis.
+const defaultScanCount = 10
+
+// command is an implemented command. The arity counts the command name,
+// a negative arity -n means at least n arguments, the same as in the COMMAND reply of redis.
+type command struct {
+	arity int
+	run   func(d *db, c *client, args []string)
+}
+
+// commands are the implemented commands by their lowercase name.
+var commands = map[string]command{
+	// connection
+	"ping":     {-1, cmdPing},
+	"echo":     {2, cmdEcho},
+	"hello":    {-1, cmdHello},
+	"select":   {2, cmdSelect},
+	"client":   {-2, cmdClient},
+	"quit":     {-1, cmdQuit},
+	"flushall": {-1, cmdFlush},
+	"flushdb":  {-1, cmdFlush},
+
+	// keys
+	"del":    {-2, cmdDel},
+	"unlink": {-2, cmdDel},
+	"rename": {3, cmdRename},
+	"exists": {-2, cmdExists},
+	"type":   {2, cmdType},
+	"expire": {-3, cmdExpire},
+	"ttl":    {2, cmdTTL},
+	"pttl":   {2, cmdPTTL},
+	"keys":   {2, cmdKeys},
+	"scan":   {-2, cmdScan},
+
+	// strings
+	"set":   {-3, cmdSet},
+	"setnx": {3, cmdSetNX},
+	"get":   {2, cmdGet},
+
+	// lists
+	"rpush":  {-3, cmdRPush},
+	"lpush":  {-3, cmdLPush},
+	"lrange": {4, cmdLRange},
+	"llen":   {2, cmdLLen},
+	"lmove":  {5, cmdLMove},
+
+	// sorted sets
+	"zadd":             {-4, cmdZAdd},
+	"zincrby":          {4, cmdZIncrBy},
+	"zscore":           {3, cmdZScore},
+	"zcard":            {2, cmdZCard},
+	"zrem":             {-3, cmdZRem},
+	"zcount":           {4, cmdZCount},
+	"zrank":            {-3, cmdZRank},
+	"zrevrank":         {-3, cmdZRevRank},
+	"zrange":           {-4, cmdZRange},
+	"zrevrange":        {-4, cmdZRevRange},
+	"zrangebyscore":    {-4, cmdZRangeByScore},
+	"zrevrangebyscore": {-4, cmdZRevRangeByScore},
+	"zunionstore":      {-4, cmdZUnionStore},
+	"zinterstore":      {-4, cmdZInterStore},
+	"zunion":           {-3, cmdZUnion},
+	"zinter":           {-3, cmdZInter},
+}
+
+// cmdPing implements PING [message].
+func cmdPing(d *db, c *client, args []string) {
+	switch len(args) {
+	case 0:
+		c.w.simple("PONG")
+	case 1:
+		c.w.bulk(args[0])
+	default:
+		c.w.error("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+// cmdEcho implements ECHO message.
+func cmdEcho(d *db, c *client, args []string) {
+	c.w.bulk(args[0])
+}
+
+// cmdHello implements HELLO [protover [AUTH username password] [SETNAME clientname]].
+// There are no users, so any credentials are accepted.
+func cmdHello(d *db, c *client, args []string) {
+	proto := c.w.proto
+	if len(args) > 0 {
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			c.w.error("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if version != 2 && version != 3 {
+			c.w.error("NOPROTO unsupported protocol version")
+			return
+		}
+		proto = version
+	}
+	name := c.name
+	for i := 1; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(args[i], "AUTH") && i+2 < len(args):
+			i += 2
+		case strings.EqualFold(args[i], "SETNAME") && i+1 < len(args):
+			name = args[i+1]
+			i++
+		default:
+			c.w.error(fmt.Sprintf("ERR Syntax error in HELLO option '%s'", args[i]))
+			return
+		}
+	}
+
+	c.w.proto = proto
+	c.name = name
+	c.w.mapHeader(7)
+	c.w.bulk("server")
+	c.w.bulk("redis")
+	c.w.bulk("version")
+	c.w.bulk(Version)
+	c.w.bulk("proto")
+	c.w.int(int64(proto))
+	c.w.bulk("id")
+	c.w.int(c.id)
+	c.w.bulk("mode")
+	c.w.bulk("standalone")
+	c.w.bulk("role")
+	c.w.bulk("master")
+	c.w.bulk("modules")
+	c.w.array(0)
+}
+
+// cmdSelect implements SELECT index, only the database 0 exists.
+func cmdSelect(d *db, c *client, args []string) {
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		c.w.error(errNotInteger)
+		return
+	}
+	if index != 0 {
+		c.w.error("ERR DB index is out of range")
+		return
+	}
+	c.w.ok()
+}
+
+// cmdClient implements CLIENT ID, CLIENT GETNAME, CLIENT SETNAME name and CLIENT SETINFO attr value,
+// which the clients send when they connect.
+func cmdClient(d *db, c *client, args []string) {
+	switch sub := strings.ToLower(args[0]); {
+	case sub == "id" && len(args) == 1:
+		c.w.int(c.id)
+	case sub == "getname" && len(args) == 1:
+		if c.name == "" {
+			c.w.null()
+			return
+		}
+		c.w.bulk(c.name)
+	case sub == "setname" && len(args) == 2:
+		c.name = args[1]
+		c.w.ok()
+	case sub == "setinfo" && len(args) == 3:
+		c.w.ok()
+	default:
+		c.w.error(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'", args[0]))
+	}
+}
+
+// cmdQuit implements QUIT, the connection is closed after the reply.
+func cmdQuit(d *db, c *client, args []string) {
+	c.quit = true
+	c.w.ok()
+}
+
+// cmdFlush implements FLUSHALL and FLUSHDB [ASYNC|SYNC], the keys are always deleted synchronously.
+func cmdFlush(d *db, c *client, args []string) {
+	if len(args) > 1 || (len(args) == 1 && !strings.EqualFold(args[0], "ASYNC") && !strings.EqualFold(args[0], "SYNC")) {
+		c.w.error(errSyntax)
+		return
+	}
+	d.flush()
+	c.w.ok()
+}
+
+// cmdDel implements DEL and UNLINK key [key ...], the keys are always deleted synchronously.
+func cmdDel(d *db, c *client, args []string) {
+	var deleted int64
+	for _, key := range args {
+		if _, ok := d.lookup(key); ok && d.delete(key) {
+			deleted++
+		}
+	}
+	c.w.int(deleted)
+}
+
+// cmdRename implements RENAME key newkey, the time to live of the key moves with it.
+func cmdRename(d *db, c *client, args []string) {
+	value, ok := d.lookup(args[0])
+	if !ok {
+		c.w.error("ERR no such key")
+		return
+	}
+	if args[0] == args[1] {
+		c.w.ok()
+		return
+	}
+	expiresAt, hasTTL := d.expires[args[0]]
+	d.delete(args[0])
+	d.set(args[1], value)
+	if hasTTL {
+		d.expires[args[1]] = expiresAt
+	}
+	c.w.ok()
+}
+
+// cmdExists implements EXISTS key [key ...], a key given multiple times is counted multiple times.
+func cmdExists(d *db, c *client, args []string) {
+	var count int64
+	for _, key := range args {
+		if _, ok := d.lookup(key); ok {
+			count++
+		}
+	}
+	c.w.int(count)
+}
+
+// cmdType implements TYPE key.
+func cmdType(d *db, c *client, args []string) {
+	value, _ := d.lookup(args[0])
+	c.w.simple(typeName(value))
+}
+
+// cmdExpire implements EXPIRE key seconds [NX|XX|GT|LT].
+// A key without a time to live counts as an infinite time to live for GT and LT.
+func cmdExpire(d *db, c *client, args []string) {
+	seconds, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		c.w.error(errNotInteger)
+		return
+	}
+	var condition string
+	switch len(args) {
+	case 2:
+	case 3:
+		condition = strings.ToUpper(args[2])
+		if condition != "NX" && condition != "XX" && condition != "GT" && condition != "LT" {
+			c.w.error("ERR Unsupported option " + args[2])
+			return
+		}
+	default:
+		c.w.error(errSyntax)
+		return
+	}
+
+	key := args[0]
+	if _, ok := d.lookup(key); !ok {
+		c.w.int(0)
+		return
+	}
+	expiresAt := time.Now().Add(time.Duration(seconds) * time.Second)
+	current, hasTTL := d.expires[key]
+	switch condition {
+	case "NX":
+		if hasTTL {
+			c.w.int(0)
+			return
+		}
+	case "XX":
+		if !hasTTL {
+			c.w.int(0)
+			return
+		}
+	case "GT":
+		if !hasTTL || !expiresAt.After(current) {
+			c.w.int(0)
+			return
+		}
+	case "LT":
+		if hasTTL && !expiresAt.Before(current) {
+			c.w.int(0)
+			return
+		}
+	}
+	// a time to live in the past deletes the key right away, the same as in redis
+	if seconds <= 0 {
+		d.delete(key)
+	} else {
+		d.expires[key] = expiresAt
+	}
+	c.w.int(1)
+}
+
+// cmdTTL implements TTL key, the time to live is rounded to seconds.
+func cmdTTL(d *db, c *client, args []string) {
+	ttl, ok := remainingTTL(d, args[0])
+	if !ok {
+		c.w.int(ttl)
+		return
+	}
+	c.w.int((ttl + 500) / 1000)
+}
+
+// cmdPTTL implements PTTL key.
+func cmdPTTL(d *db, c *client, args []string) {
+	ttl, _ := remainingTTL(d, args[0])
+	c.w.int(ttl)
+}
+
+// remainingTTL returns the time to live of the key in milliseconds and true,
+// or -2 if the key does not exist and -1 if it has no time to live with false.
+func remainingTTL(d *db, key string) (int64, bool) {
+	if _, ok := d.lookup(key); !ok {
+		return -2, false
+	}
+	expiresAt, ok := d.expires[key]
+	if !ok {
+		return -1, false
+	}
+	return max(time.Until(expiresAt).Milliseconds(), 0), true
+}
+
+// cmdKeys implements KEYS pattern.
+func cmdKeys(d *db, c *client, args []string) {
+	var matched []string
+	for _, key := range d.keys() {
+		if matchGlob(args[0], key) {
+			matched = append(matched, key)
+		}
+	}
+	c.w.bulks(matched)
+}
+
+// cmdScan implements SCAN cursor [MATCH pattern] [COUNT count] [TYPE type].
+// The keys are visited in lexicographic order and the cursor stands for the last visited key, so the keys present
+// during the whole iteration are returned exactly once, even if other keys are added or deleted meanwhile.
+func cmdScan(d *db, c *client, args []string) {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		c.w.error("ERR invalid cursor")
+		return
+	}
+	pattern, count, typ := "*", int64(defaultScanCount), ""
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			c.w.error(errSyntax)
+			return
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			count, err = strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				c.w.error(errNotInteger)
+				return
+			}
+			if count < 1 {
+				c.w.error(errSyntax)
+				return
+			}
+		case "TYPE":
+			typ = strings.ToLower(args[i+1])
+		default:
+			c.w.error(errSyntax)
+			return
+		}
+	}
+
+	keys := d.keys()
+	start := 0
+	if cursor != 0 {
+		last, ok := d.cursors[cursor]
+		if !ok {
+			c.w.error("ERR invalid cursor")
+			return
+		}
+		start, _ = slices.BinarySearch(keys, last)
+		if start < len(keys) && keys[start] == last {
+			start++
+		}
+	}
+	end := len(keys)
+	if count < int64(end-start) {
+		end = start + int(count)
+	}
+	var matched []string
+	for _, key := range keys[start:end] {
+		value, _ := d.lookup(key)
+		if matchGlob(pattern, key) && (typ == "" || typeName(value) == typ) {
+			matched = append(matched, key)
+		}
+	}
+	next := uint64(0)
+	if end < len(keys) {
+		d.lastCursor++
+		next = d.lastCursor
+		d.cursors[next] = keys[end-1]
+	}
+	c.w.array(2)
+	c.w.bulk(strconv.FormatUint(next, 10))
+	c.w.bulks(matched)
+}
+
+// cmdSet implements SET key value [NX|XX] [GET] [EX seconds|PX milliseconds|EXAT unix-time-seconds|PXAT unix-time-milliseconds|KEEPTTL].
+func cmdSet(d *db, c *client, args []string) {
+	key, value := args[0], args[1]
+	var nx, xx, get, keepTTL bool
+	var expiresAt time.Time
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i]); option {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GET":
+			get = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if !expiresAt.IsZero() || i+1 >= len(args) {
+				c.w.error(errSyntax)
+				return
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				c.w.error(errNotInteger)
+				return
+			}
+			if n <= 0 {
+				c.w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			i++
+			switch option {
+			case "EX":
+				expiresAt = time.Now().Add(time.Duration(n) * time.Second)
+			case "PX":
+				expiresAt = time.Now().Add(time.Duration(n) * time.Millisecond)
+			case "EXAT":
+				expiresAt = time.Unix(n, 0)
+			case "PXAT":
+				expiresAt = time.UnixMilli(n)
+			}
+		default:
+			c.w.error(errSyntax)
+			return
+		}
+	}
+	if (nx && xx) || (keepTTL && !expiresAt.IsZero()) {
+		c.w.error(errSyntax)
+		return
+	}
+
+	old, exists, wrongType := d.lookupString(key)
+	if get && wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	// NX and XX consider keys of any type, GET has failed on the other types already
+	exists = exists || wrongType
+	if (nx && exists) || (xx && !exists) {
+		if get {
+			writeOptionalBulk(c, old, exists)
+			return
+		}
+		c.w.null()
+		return
+	}
+
+	oldExpiresAt, hasTTL := d.expires[key]
+	d.set(key, value)
+	switch {
+	case !expiresAt.IsZero():
+		d.expires[key] = expiresAt
+	case keepTTL && hasTTL:
+		d.expires[key] = oldExpiresAt
+	}
+	if get {
+		writeOptionalBulk(c, old, exists)
+		return
+	}
+	c.w.ok()
+}
+
+// cmdSetNX implements SETNX key value.
+func cmdSetNX(d *db, c *client, args []string) {
+	if _, ok := d.lookup(args[0]); ok {
+		c.w.int(0)
+		return
+	}
+	d.set(args[0], args[1])
+	c.w.int(1)
+}
+
+// cmdGet implements GET key.
+func cmdGet(d *db, c *client, args []string) {
+	value, ok, wrongType := d.lookupString(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	writeOptionalBulk(c, value, ok)
+}
+
+// writeOptionalBulk writes the bulk string if ok, the null reply otherwise.
+func writeOptionalBulk(c *client, s string, ok bool) {
+	if !ok {
+		c.w.null()
+		return
+	}
+	c.w.bulk(s)
+}
@@ -0,0 +1,204 @@
+package resptest
+
+import (
+	"slices"
+	"time"
+)
+
+// Error replies shared by the commands.
+const (
+	errSyntax      = "ERR syntax error"
+	errWrongType   = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	errNotInteger  = "ERR value is not an integer or out of range"
+	errNotFloat    = "ERR value is not a valid float"
+	errMinMaxFloat = "ERR min or max is not a float"
+)
+
+// db is the keyspace of the server holding strings, lists and sorted sets.
+// The keys expire lazily, an expired key is deleted when it is looked up.
+// The db is not safe for concurrent use, the server serializes the commands.
+type db struct {
+	values  map[string]any
+	expires map[string]time.Time
+	// cursors are the last keys visited by the SCAN iterations, by their cursors
+	cursors    map[uint64]string
+	lastCursor uint64
+}
+
+// newDB returns a new empty keyspace.
+func newDB() *db {
+	return &db{
+		values:  make(map[string]any),
+		expires: make(map[string]time.Time),
+		cursors: make(map[uint64]string),
+	}
+}
+
+// lookup returns the value of the key, deleting the key first if it has expired.
+func (d *db) lookup(key string) (any, bool) {
+	if expiresAt, ok := d.expires[key]; ok && !time.Now().Before(expiresAt) {
+		d.delete(key)
+	}
+	value, ok := d.values[key]
+	return value, ok
+}
+
+// set sets the value of the key and removes its time to live.
+func (d *db) set(key string, value any) {
+	d.values[key] = value
+	delete(d.expires, key)
+}
+
+// delete deletes the key and reports whether it existed.
+func (d *db) delete(key string) bool {
+	_, ok := d.values[key]
+	delete(d.values, key)
+	delete(d.expires, key)
+	return ok
+}
+
+// flush deletes all keys.
+func (d *db) flush() {
+	clear(d.values)
+	clear(d.expires)
+	clear(d.cursors)
+}
+
+// keys returns the keys that have not expired in lexicographic order.
+func (d *db) keys() []string {
+	keys := make([]string, 0, len(d.values))
+	for key := range d.values {
+		if _, ok := d.lookup(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// typeName returns the name of the type of the value as reported by TYPE.
+func typeName(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case *list:
+		return "list"
+	case *zset:
+		return "zset"
+	default:
+		return "none"
+	}
+}
+
+// lookupString returns the string of the key, ok is false if the key is missing.
+// Returns wrongType if the key holds another type.
+func (d *db) lookupString(key string) (s string, ok, wrongType bool) {
+	value, ok := d.lookup(key)
+	if !ok {
+		return "", false, false
+	}
+	s, ok = value.(string)
+	return s, ok, !ok
+}
+
+// lookupList returns the list of the key, nil if the key is missing.
+// Returns wrongType if the key holds another type.
+func (d *db) lookupList(key string) (l *list, wrongType bool) {
+	value, ok := d.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	l, ok = value.(*list)
+	return l, !ok
+}
+
+// lookupZSet returns the sorted set of the key, nil if the key is missing.
+// Returns wrongType if the key holds another type.
+func (d *db) lookupZSet(key string) (z *zset, wrongType bool) {
+	value, ok := d.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	z, ok = value.(*zset)
+	return z, !ok
+}
+
+// matchGlob reports whether the string matches the glob-style pattern of KEYS and SCAN,
+// supporting *, ?, character classes like [a-z] or [^a] and escaping with a backslash.
+func matchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// collapse consecutive stars, then try every split of the rest
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			matched, rest := matchClass(pattern[1:], s[0])
+			if !matched {
+				return false
+			}
+			pattern, s = rest, s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass matches the byte against the character class following '[' in the pattern
+// and returns the rest of the pattern after the closing ']'.
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			matched = matched || pattern[1] == c
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = matched || (c >= lo && c <= hi)
+			pattern = pattern[3:]
+		default:
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+	// an unterminated class runs to the end of the pattern, the same as in redis
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+	return matched != negate, pattern
+}
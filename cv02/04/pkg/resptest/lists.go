@@ -0,0 +1,144 @@
+package resptest
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// list is a list value, the head of the list is the first item.
+type list struct {
+	items []string
+}
+
+// cmdRPush implements RPUSH key element [element ...].
+func cmdRPush(d *db, c *client, args []string) {
+	push(d, c, args[0], args[1:], false)
+}
+
+// cmdLPush implements LPUSH key element [element ...], the elements are inserted one after another at the head.
+func cmdLPush(d *db, c *client, args []string) {
+	push(d, c, args[0], args[1:], true)
+}
+
+// push inserts the elements at the head or at the tail of the list and writes its new length.
+func push(d *db, c *client, key string, elements []string, head bool) {
+	l, wrongType := d.lookupList(key)
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if l == nil {
+		l = &list{}
+		d.set(key, l)
+	}
+	if head {
+		reversed := slices.Clone(elements)
+		slices.Reverse(reversed)
+		l.items = append(reversed, l.items...)
+	} else {
+		l.items = append(l.items, elements...)
+	}
+	c.w.int(int64(len(l.items)))
+}
+
+// cmdLRange implements LRANGE key start stop.
+func cmdLRange(d *db, c *client, args []string) {
+	start, err1 := strconv.ParseInt(args[1], 10, 64)
+	stop, err2 := strconv.ParseInt(args[2], 10, 64)
+	if err1 != nil || err2 != nil {
+		c.w.error(errNotInteger)
+		return
+	}
+	l, wrongType := d.lookupList(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if l == nil {
+		c.w.array(0)
+		return
+	}
+	start, stop, ok := indexRange(start, stop, int64(len(l.items)))
+	if !ok {
+		c.w.array(0)
+		return
+	}
+	c.w.bulks(l.items[start : stop+1])
+}
+
+// cmdLLen implements LLEN key.
+func cmdLLen(d *db, c *client, args []string) {
+	l, wrongType := d.lookupList(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if l == nil {
+		c.w.int(0)
+		return
+	}
+	c.w.int(int64(len(l.items)))
+}
+
+// cmdLMove implements LMOVE source destination LEFT|RIGHT LEFT|RIGHT.
+func cmdLMove(d *db, c *client, args []string) {
+	from, to := strings.ToUpper(args[2]), strings.ToUpper(args[3])
+	if (from != "LEFT" && from != "RIGHT") || (to != "LEFT" && to != "RIGHT") {
+		c.w.error(errSyntax)
+		return
+	}
+	source, wrongType := d.lookupList(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if source == nil {
+		c.w.null()
+		return
+	}
+	destination, wrongType := d.lookupList(args[1])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+
+	var element string
+	if from == "LEFT" {
+		element, source.items = source.items[0], source.items[1:]
+	} else {
+		last := len(source.items) - 1
+		element, source.items = source.items[last], source.items[:last]
+	}
+	// the source may be the destination, so the destination is created only after the pop
+	if destination == nil {
+		destination = &list{}
+		d.set(args[1], destination)
+	}
+	if to == "LEFT" {
+		destination.items = append([]string{element}, destination.items...)
+	} else {
+		destination.items = append(destination.items, element)
+	}
+	// a list emptied by the move is deleted, the same as in redis
+	if len(source.items) == 0 {
+		d.delete(args[0])
+	}
+	c.w.bulk(element)
+}
+
+// indexRange resolves the inclusive range of indexes of LRANGE and ZRANGE, where negative indexes count
+// from the end, into a valid range of the sequence of the given length. Reports false if the range is empty.
+func indexRange(start, stop, length int64) (int64, int64, bool) {
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if stop < 0 {
+		stop = length + stop
+	}
+	stop = min(stop, length-1)
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
@@ -0,0 +1,167 @@
+package resptest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxBulkLen is the maximum length of a bulk string of a request, the same as proto-max-bulk-len of redis.
+const maxBulkLen = 512 << 20
+
+// maxArgs is the maximum number of arguments of a request.
+const maxArgs = 1 << 20
+
+// errProtocol is returned when a request violates the protocol, the connection is closed afterwards.
+var errProtocol = errors.New("protocol error")
+
+// readCommand reads a command sent either as a RESP array of bulk strings or as an inline command.
+// Returns nil arguments for an empty inline command.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxArgs {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+	}
+	args := make([]string, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("%w: expected '$', got %q", errProtocol, line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[size] != '\r' || buf[size+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errProtocol)
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// readLine reads a line terminated by CRLF or LF and returns it without the terminator.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// writer writes the replies in the protocol version negotiated by the connection.
+// Errors are kept by the buffered writer and reported by Flush.
+type writer struct {
+	w *bufio.Writer
+	// proto is the protocol version, 2 or 3.
+	proto int
+}
+
+// simple writes a simple string reply.
+func (w *writer) simple(s string) {
+	w.w.WriteString("+" + s + "\r\n")
+}
+
+// ok writes the OK simple string reply.
+func (w *writer) ok() {
+	w.simple("OK")
+}
+
+// error writes an error reply, the message starts with the error code, e.g. "ERR syntax error".
+func (w *writer) error(msg string) {
+	w.w.WriteString("-" + msg + "\r\n")
+}
+
+// int writes an integer reply.
+func (w *writer) int(n int64) {
+	w.w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+// bulk writes a bulk string reply.
+func (w *writer) bulk(s string) {
+	w.w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+// null writes the null reply, a null bulk string in RESP2.
+func (w *writer) null() {
+	if w.proto == 3 {
+		w.w.WriteString("_\r\n")
+		return
+	}
+	w.w.WriteString("$-1\r\n")
+}
+
+// nullArray writes the null reply in place of an array, a null array in RESP2.
+func (w *writer) nullArray() {
+	if w.proto == 3 {
+		w.w.WriteString("_\r\n")
+		return
+	}
+	w.w.WriteString("*-1\r\n")
+}
+
+// array writes the header of an array reply of n elements, which must be written next.
+func (w *writer) array(n int) {
+	w.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+// bulks writes an array reply of bulk strings.
+func (w *writer) bulks(values []string) {
+	w.array(len(values))
+	for _, value := range values {
+		w.bulk(value)
+	}
+}
+
+// mapHeader writes the header of a map reply of n pairs, which must be written next.
+// RESP2 has no maps, so the pairs are written as a flat array.
+func (w *writer) mapHeader(n int) {
+	if w.proto == 3 {
+		w.w.WriteString("%" + strconv.Itoa(n) + "\r\n")
+		return
+	}
+	w.array(2 * n)
+}
+
+// double writes a double reply, a bulk string in RESP2.
+func (w *writer) double(f float64) {
+	if w.proto == 3 {
+		w.w.WriteString("," + formatDouble(f) + "\r\n")
+		return
+	}
+	w.bulk(formatDouble(f))
+}
+
+// formatDouble formats the double the way redis does, e.g. "1.5", "100" or "inf".
+func formatDouble(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case f == math.Trunc(f) && math.Abs(f) < 1e17:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,245 @@
+package resptest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Server is an in-process redis server for hermetic tests, speaking RESP2 and RESP3 over TCP.
+// It implements the commands on strings, lists and sorted sets used by the exercises of cv02 and by the sorted set
+// store of the leaderboard package in a single database, see the command table for the full list.
+// The commands are executed one at a time, so every command is atomic, and MULTI/EXEC transactions run
+// their queued commands together. WATCH, pub/sub and scripts are not supported, so the conditional updates
+// of the leaderboard, which run as lua scripts, cannot be used.
+//
+// The go-redis clients connect to it as to a redis server:
+//
+//	server, err := resptest.NewServer()
+//	...
+//	defer server.Close()
+//	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+type Server struct {
+	listener net.Listener
+
+	// mu guards the database and the connections.
+	mu     sync.Mutex
+	db     *db
+	conns  map[net.Conn]struct{}
+	nextID int64
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewServer starts a new Server listening on a random port of the loopback interface.
+func NewServer() (*Server, error) {
+	return Listen("127.0.0.1:0")
+}
+
+// Listen starts a new Server listening on the TCP address, e.g. ":6379".
+func Listen(addr string) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %q: %w", addr, err)
+	}
+	s := &Server{
+		listener: listener,
+		db:       newDB(),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the server listens on in the host:port form.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server, closes all connections and waits until they are handled.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// FlushAll deletes all keys, e.g. between tests sharing the server.
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.db.flush()
+}
+
+// serve accepts the connections until the listener is closed.
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.nextID++
+		id := s.nextID
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.handle(conn, id)
+	}
+}
+
+// client is the state of a single connection.
+type client struct {
+	id   int64
+	name string
+	w    *writer
+	// quit is set by QUIT to close the connection after the reply.
+	quit bool
+	// multi is set by MULTI, the commands are queued until EXEC.
+	multi  bool
+	queued [][]string
+	// aborted is set when a command is rejected while queuing, EXEC then discards the transaction.
+	aborted bool
+}
+
+// reset ends the transaction of the client.
+func (c *client) reset() {
+	c.multi = false
+	c.queued = nil
+	c.aborted = false
+}
+
+// handle reads the commands of the connection and writes their replies until the connection is closed.
+// The replies are flushed once all pipelined commands read so far are executed.
+func (s *Server) handle(conn net.Conn, id int64) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	c := &client{id: id, w: &writer{w: bufio.NewWriter(conn), proto: 2}}
+	for {
+		args, err := readCommand(r)
+		if errors.Is(err, errProtocol) {
+			c.w.error("ERR Protocol error: " + strings.TrimPrefix(err.Error(), errProtocol.Error()+": "))
+			c.w.w.Flush()
+			return
+		}
+		if err != nil {
+			return
+		}
+		if len(args) > 0 {
+			s.execute(c, args)
+		}
+		if r.Buffered() == 0 || c.quit {
+			if err := c.w.w.Flush(); err != nil || c.quit {
+				return
+			}
+		}
+	}
+}
+
+// execute looks up the command and runs it with the database locked, or queues it within a transaction.
+func (s *Server) execute(c *client, args []string) {
+	name := strings.ToLower(args[0])
+	switch name {
+	case "multi", "exec", "discard":
+		s.transaction(c, name, args[1:])
+		return
+	}
+	cmd, ok := lookup(c, name, args)
+	if !ok {
+		// a rejected command aborts the transaction the same way as in redis
+		if c.multi {
+			c.aborted = true
+		}
+		return
+	}
+	if c.multi {
+		c.queued = append(c.queued, args)
+		c.w.simple("QUEUED")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cmd.run(s.db, c, args[1:])
+}
+
+// transaction implements MULTI, EXEC and DISCARD.
+// EXEC runs the queued commands with the database locked, so no other command runs in between.
+func (s *Server) transaction(c *client, name string, args []string) {
+	if len(args) != 0 {
+		c.w.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+		if c.multi {
+			c.aborted = true
+		}
+		return
+	}
+	switch {
+	case name == "multi" && c.multi:
+		c.w.error("ERR MULTI calls can not be nested")
+	case name == "multi":
+		c.multi = true
+		c.w.ok()
+	case !c.multi:
+		c.w.error(fmt.Sprintf("ERR %s without MULTI", strings.ToUpper(name)))
+	case name == "discard":
+		c.reset()
+		c.w.ok()
+	case c.aborted:
+		c.reset()
+		c.w.error("EXECABORT Transaction discarded because of previous errors.")
+	default:
+		queued := c.queued
+		c.reset()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		c.w.array(len(queued))
+		for _, args := range queued {
+			commands[strings.ToLower(args[0])].run(s.db, c, args[1:])
+		}
+	}
+}
+
+// lookup returns the command of the arguments.
+// Returns false after writing the error reply if the command is unknown or has a wrong number of arguments.
+func lookup(c *client, name string, args []string) (command, bool) {
+	cmd, ok := commands[name]
+	if !ok {
+		var quoted strings.Builder
+		for _, arg := range args[1:] {
+			quoted.WriteString("'" + arg + "' ")
+		}
+		c.w.error(fmt.Sprintf("ERR unknown command '%s', with args beginning with: %s", args[0], quoted.String()))
+		return command{}, false
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		c.w.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+		return command{}, false
+	}
+	return cmd, true
+}
@@ -0,0 +1,419 @@
+package resptest_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/resptest"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newServer starts a server closed at the end of the test.
+func newServer(t *testing.T) *resptest.Server {
+	t.Helper()
+	server, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+// newClient returns a go-redis client of the server speaking the protocol version.
+func newClient(t *testing.T, server *resptest.Server, protocol int) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), Protocol: protocol, ClientName: "resptest"})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// forEachProtocol runs the test with a client speaking RESP2 and with a client speaking RESP3.
+func forEachProtocol(t *testing.T, test func(t *testing.T, client *redis.Client)) {
+	for _, protocol := range []int{2, 3} {
+		t.Run(fmt.Sprintf("RESP%d", protocol), func(t *testing.T) {
+			test(t, newClient(t, newServer(t), protocol))
+		})
+	}
+}
+
+// assertEqual fails the test if the values are not deeply equal.
+func assertEqual(t *testing.T, name string, got, want any) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %#v, want %#v", name, got, want)
+	}
+}
+
+// must fails the test if the command failed.
+func must(t *testing.T, cmd redis.Cmder) {
+	t.Helper()
+	if err := cmd.Err(); err != nil {
+		t.Fatalf("%v failed: %v", cmd.Args(), err)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, client *redis.Client) {
+		ctx := context.Background()
+		must(t, client.Set(ctx, "greeting", "hello", 0))
+		got, err := client.Get(ctx, "greeting").Result()
+		assertEqual(t, "GET greeting", got, "hello")
+		assertEqual(t, "GET greeting error", err, nil)
+
+		_, err = client.Get(ctx, "missing").Result()
+		assertEqual(t, "GET missing error", err, redis.Nil)
+
+		set, err := client.SetNX(ctx, "greeting", "bye", 0).Result()
+		assertEqual(t, "SETNX greeting", set, false)
+		assertEqual(t, "SETNX greeting error", err, nil)
+
+		must(t, client.Set(ctx, "session", "token", time.Minute))
+		ttl, err := client.TTL(ctx, "session").Result()
+		assertEqual(t, "TTL session", ttl, time.Minute)
+		assertEqual(t, "TTL session error", err, nil)
+
+		must(t, client.Rename(ctx, "session", "renamed"))
+		ttl, _ = client.TTL(ctx, "renamed").Result()
+		assertEqual(t, "TTL renamed", ttl, time.Minute)
+		err = client.Rename(ctx, "session", "renamed").Err()
+		assertEqual(t, "RENAME missing error", fmt.Sprint(err), "ERR no such key")
+	})
+}
+
+func TestLists(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, client *redis.Client) {
+		ctx := context.Background()
+		must(t, client.RPush(ctx, "queue", "b", "c"))
+		must(t, client.LPush(ctx, "queue", "a"))
+		items, _ := client.LRange(ctx, "queue", 0, -1).Result()
+		assertEqual(t, "LRANGE queue", items, []string{"a", "b", "c"})
+
+		moved, _ := client.LMove(ctx, "queue", "done", "LEFT", "RIGHT").Result()
+		assertEqual(t, "LMOVE queue done", moved, "a")
+		length, _ := client.LLen(ctx, "queue").Result()
+		assertEqual(t, "LLEN queue", length, int64(2))
+
+		_, err := client.LMove(ctx, "missing", "done", "LEFT", "RIGHT").Result()
+		assertEqual(t, "LMOVE missing error", err, redis.Nil)
+		err = client.LPush(ctx, "done", "x").Err()
+		assertEqual(t, "LPUSH done error", err, nil)
+		must(t, client.Set(ctx, "greeting", "hello", 0))
+		err = client.LLen(ctx, "greeting").Err()
+		assertEqual(t, "LLEN greeting error", fmt.Sprint(err), "WRONGTYPE Operation against a key holding the wrong kind of value")
+	})
+}
+
+func TestSortedSets(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, client *redis.Client) {
+		ctx := context.Background()
+		must(t, client.ZAdd(ctx, "scores", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}, redis.Z{Score: 2, Member: "c"}))
+		score, _ := client.ZIncrBy(ctx, "scores", 2.5, "a").Result()
+		assertEqual(t, "ZINCRBY scores a", score, 3.5)
+
+		members, _ := client.ZRevRangeWithScores(ctx, "scores", 0, -1).Result()
+		assertEqual(t, "ZREVRANGE scores", members, []redis.Z{{Score: 3.5, Member: "a"}, {Score: 2, Member: "c"}, {Score: 2, Member: "b"}})
+		rank, _ := client.ZRevRank(ctx, "scores", "b").Result()
+		assertEqual(t, "ZREVRANK scores b", rank, int64(2))
+		count, _ := client.ZCount(ctx, "scores", "(2", "+inf").Result()
+		assertEqual(t, "ZCOUNT scores (2 +inf", count, int64(1))
+
+		_, err := client.ZScore(ctx, "scores", "missing").Result()
+		assertEqual(t, "ZSCORE missing error", err, redis.Nil)
+		_, err = client.ZRankWithScore(ctx, "scores", "missing").Result()
+		assertEqual(t, "ZRANK missing WITHSCORE error", err, redis.Nil)
+
+		must(t, client.ZAdd(ctx, "bonus", redis.Z{Score: 10, Member: "b"}, redis.Z{Score: 1, Member: "d"}))
+		size, _ := client.ZUnionStore(ctx, "total", &redis.ZStore{Keys: []string{"scores", "bonus"}, Weights: []float64{2, 1}}).Result()
+		assertEqual(t, "ZUNIONSTORE total size", size, int64(4))
+		members, _ = client.ZRangeWithScores(ctx, "total", 0, -1).Result()
+		assertEqual(t, "ZRANGE total", members, []redis.Z{{Score: 1, Member: "d"}, {Score: 4, Member: "c"}, {Score: 7, Member: "a"}, {Score: 14, Member: "b"}})
+
+		members, _ = client.ZInterWithScores(ctx, &redis.ZStore{Keys: []string{"scores", "bonus"}, Aggregate: "MAX"}).Result()
+		assertEqual(t, "ZINTER scores bonus", members, []redis.Z{{Score: 10, Member: "b"}})
+		size, _ = client.ZInterStore(ctx, "total", &redis.ZStore{Keys: []string{"scores", "missing"}}).Result()
+		assertEqual(t, "ZINTERSTORE empty size", size, int64(0))
+		exists, _ := client.Exists(ctx, "total").Result()
+		assertEqual(t, "EXISTS total after empty ZINTERSTORE", exists, int64(0))
+	})
+}
+
+func TestPipeline(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, client *redis.Client) {
+		ctx := context.Background()
+		var incr *redis.FloatCmd
+		var get *redis.StringCmd
+		var wrongType *redis.IntCmd
+		var top *redis.ZSliceCmd
+		_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, "greeting", "hello", 0)
+			for i := 0; i < 100; i++ {
+				incr = pipe.ZIncrBy(ctx, "scores", 1, "a")
+			}
+			get = pipe.Get(ctx, "greeting")
+			wrongType = pipe.ZCard(ctx, "greeting")
+			top = pipe.ZRevRangeWithScores(ctx, "scores", 0, 0)
+			return nil
+		})
+		// the error of the failed command is reported, while the other commands still succeed
+		assertEqual(t, "pipeline error", fmt.Sprint(err), "WRONGTYPE Operation against a key holding the wrong kind of value")
+		assertEqual(t, "last ZINCRBY", incr.Val(), float64(100))
+		assertEqual(t, "GET greeting", get.Val(), "hello")
+		assertEqual(t, "ZCARD greeting error", wrongType.Err() != nil, true)
+		assertEqual(t, "ZREVRANGE scores", top.Val(), []redis.Z{{Score: 100, Member: "a"}})
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, client *redis.Client) {
+		ctx := context.Background()
+		var incr *redis.FloatCmd
+		var ttl *redis.DurationCmd
+		_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZAdd(ctx, "scores", redis.Z{Score: 1, Member: "a"})
+			incr = pipe.ZIncrBy(ctx, "scores", 2, "a")
+			pipe.Expire(ctx, "scores", time.Minute)
+			ttl = pipe.TTL(ctx, "scores")
+			return nil
+		})
+		assertEqual(t, "EXEC error", err, nil)
+		assertEqual(t, "ZINCRBY scores", incr.Val(), float64(3))
+		assertEqual(t, "TTL scores", ttl.Val(), time.Minute)
+
+		// a command rejected while queuing discards the whole transaction
+		_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZIncrBy(ctx, "scores", 2, "a")
+			pipe.Do(ctx, "ZADD", "scores")
+			return nil
+		})
+		assertEqual(t, "aborted EXEC error", fmt.Sprint(err), "EXECABORT Transaction discarded because of previous errors.")
+		score, _ := client.ZScore(ctx, "scores", "a").Result()
+		assertEqual(t, "ZSCORE scores a", score, float64(3))
+
+		err = client.Do(ctx, "EXEC").Err()
+		assertEqual(t, "EXEC without MULTI error", fmt.Sprint(err), "ERR EXEC without MULTI")
+	})
+
+	conn := dial(t, newServer(t))
+	assertEqual(t, "MULTI", conn.do(t, "MULTI\r\n"), "+OK\r\n")
+	assertEqual(t, "nested MULTI", conn.do(t, "MULTI\r\n"), "-ERR MULTI calls can not be nested\r\n")
+	assertEqual(t, "queued SET", conn.do(t, "SET greeting hello\r\n"), "+QUEUED\r\n")
+	assertEqual(t, "DISCARD", conn.do(t, "DISCARD\r\n"), "+OK\r\n")
+	assertEqual(t, "GET greeting", conn.do(t, "GET greeting\r\n"), "$-1\r\n")
+}
+
+func TestConnection(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, client *redis.Client) {
+		ctx := context.Background()
+		hello, err := client.Do(ctx, "HELLO").Result()
+		if err != nil {
+			t.Fatalf("HELLO failed: %v", err)
+		}
+		// the client negotiates the protocol version with HELLO when it connects
+		protocol := client.Options().Protocol
+		switch reply := hello.(type) {
+		case map[interface{}]interface{}:
+			assertEqual(t, "HELLO proto", reply["proto"], int64(protocol))
+			assertEqual(t, "HELLO version", reply["version"], resptest.Version)
+		case []interface{}:
+			assertEqual(t, "HELLO reply", reply[:6], []interface{}{"server", "redis", "version", resptest.Version, "proto", int64(protocol)})
+		default:
+			t.Fatalf("HELLO = %#v, want a map", hello)
+		}
+
+		name, _ := client.ClientGetName(ctx).Result()
+		assertEqual(t, "CLIENT GETNAME", name, "resptest")
+		id, err := client.ClientID(ctx).Result()
+		assertEqual(t, "CLIENT ID > 0", id > 0, true)
+		assertEqual(t, "CLIENT ID error", err, nil)
+
+		err = client.Do(ctx, "CLIENT", "KILL").Err()
+		assertEqual(t, "CLIENT KILL error", fmt.Sprint(err), "ERR unknown subcommand or wrong number of arguments for 'KILL'")
+		err = client.Do(ctx, "NOPE", "a").Err()
+		assertEqual(t, "NOPE error", fmt.Sprint(err), "ERR unknown command 'NOPE', with args beginning with: 'a' ")
+		err = client.Do(ctx, "GET").Err()
+		assertEqual(t, "GET error", fmt.Sprint(err), "ERR wrong number of arguments for 'get' command")
+	})
+}
+
+// rawConn is a connection to the server sending the requests as they are and reading the replies unparsed.
+type rawConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dial connects to the server without a client.
+func dial(t *testing.T, server *resptest.Server) *rawConn {
+	t.Helper()
+	conn, err := net.Dial("tcp", server.Addr())
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return &rawConn{conn: conn, r: bufio.NewReader(conn)}
+}
+
+// do sends the request and returns the raw reply.
+func (c *rawConn) do(t *testing.T, request string) string {
+	t.Helper()
+	if _, err := io.WriteString(c.conn, request); err != nil {
+		t.Fatalf("write %q failed: %v", request, err)
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		t.Fatalf("read reply of %q failed: %v", request, err)
+	}
+	return reply
+}
+
+// readReply reads a whole reply, including the nested replies of the aggregate types.
+func (c *rawConn) readReply() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 3 {
+		return "", fmt.Errorf("invalid reply line %q", line)
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	switch line[0] {
+	case '$':
+		if n < 0 {
+			return line, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return "", err
+		}
+		return line + string(data), nil
+	case '*', '%':
+		if line[0] == '%' {
+			n *= 2
+		}
+		reply := line
+		for i := 0; i < n; i++ {
+			element, err := c.readReply()
+			if err != nil {
+				return "", err
+			}
+			reply += element
+		}
+		return reply, nil
+	default:
+		return line, nil
+	}
+}
+
+func TestNullReplies(t *testing.T) {
+	server := newServer(t)
+	conn := dial(t, server)
+
+	// RESP2 has a null bulk string and a null array
+	assertEqual(t, "RESP2 GET missing", conn.do(t, "*2\r\n$3\r\nGET\r\n$7\r\nmissing\r\n"), "$-1\r\n")
+	assertEqual(t, "RESP2 ZRANK missing WITHSCORE", conn.do(t, "ZRANK scores missing WITHSCORE\r\n"), "*-1\r\n")
+	assertEqual(t, "RESP2 ZSCORE missing", conn.do(t, "ZSCORE scores missing\r\n"), "$-1\r\n")
+
+	// RESP3 has a single null for both
+	hello := conn.do(t, "HELLO 3\r\n")
+	if !strings.HasPrefix(hello, "%7\r\n") || !strings.Contains(hello, "$5\r\nproto\r\n:3\r\n") {
+		t.Errorf("HELLO 3 = %q, want a map with proto 3", hello)
+	}
+	assertEqual(t, "RESP3 GET missing", conn.do(t, "GET missing\r\n"), "_\r\n")
+	assertEqual(t, "RESP3 ZRANK missing WITHSCORE", conn.do(t, "ZRANK scores missing WITHSCORE\r\n"), "_\r\n")
+
+	// the scores are doubles in RESP3
+	conn.do(t, "ZADD scores 1.5 a\r\n")
+	assertEqual(t, "RESP3 ZSCORE a", conn.do(t, "ZSCORE scores a\r\n"), ",1.5\r\n")
+	assertEqual(t, "RESP3 ZRANGE WITHSCORES", conn.do(t, "ZRANGE scores 0 -1 WITHSCORES\r\n"), "*1\r\n*2\r\n$1\r\na\r\n,1.5\r\n")
+	conn.do(t, "HELLO 2\r\n")
+	assertEqual(t, "RESP2 ZRANGE WITHSCORES", conn.do(t, "ZRANGE scores 0 -1 WITHSCORES\r\n"), "*2\r\n$1\r\na\r\n$3\r\n1.5\r\n")
+}
+
+func TestProtocolErrors(t *testing.T) {
+	server := newServer(t)
+	for _, test := range []struct {
+		name, request, reply string
+	}{
+		{"invalid multibulk length", "*x\r\n", "-ERR Protocol error: invalid multibulk length\r\n"},
+		{"missing bulk", "*1\r\n:1\r\n", "-ERR Protocol error: expected '$', got \":1\"\r\n"},
+		{"invalid bulk length", "*1\r\n$-5\r\n", "-ERR Protocol error: invalid bulk length\r\n"},
+		{"unterminated bulk", "*1\r\n$4\r\nPINGxx", "-ERR Protocol error: bulk string not terminated by CRLF\r\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			conn := dial(t, server)
+			assertEqual(t, "reply", conn.do(t, test.request), test.reply)
+			// the server closes the connection after a protocol error
+			if _, err := conn.readReply(); !errors.Is(err, io.EOF) {
+				t.Errorf("read after protocol error = %v, want EOF", err)
+			}
+		})
+	}
+
+	// the other connections are not affected
+	conn := dial(t, server)
+	assertEqual(t, "inline PING", conn.do(t, "PING\r\n"), "+PONG\r\n")
+}
+
+func TestClose(t *testing.T) {
+	server, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	conn := dial(t, server)
+	assertEqual(t, "PING", conn.do(t, "PING\r\n"), "+PONG\r\n")
+
+	// Close returns once the idle connections are closed
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if _, err := conn.readReply(); err == nil {
+		t.Errorf("read after Close() succeeded, want an error")
+	}
+	if err := server.Close(); err != nil {
+		t.Errorf("second Close() = %v, want nil", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, client *redis.Client) {
+		ctx := context.Background()
+		for _, key := range []string{"a", "b", "c", "d", "e", "f", "other"} {
+			must(t, client.Set(ctx, key, "1", 0))
+		}
+
+		// the keys are deleted while they are scanned, the keys present during the whole scan are still returned
+		var scanned []string
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(ctx, cursor, "?", 2).Result()
+			if err != nil {
+				t.Fatalf("SCAN %d failed: %v", cursor, err)
+			}
+			scanned = append(scanned, keys...)
+			if len(keys) > 0 {
+				must(t, client.Del(ctx, keys...))
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+		assertEqual(t, "SCAN keys", scanned, []string{"a", "b", "c", "d", "e", "f"})
+		remaining, err := client.Keys(ctx, "*").Result()
+		assertEqual(t, "KEYS *", remaining, []string{"other"})
+		assertEqual(t, "KEYS * error", err, nil)
+
+		err = client.Scan(ctx, 12345, "", 0).Err()
+		assertEqual(t, "SCAN invalid cursor error", fmt.Sprint(err), "ERR invalid cursor")
+	})
+}
@@ -0,0 +1,748 @@
+package resptest
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// zmember is a member of a sorted set with its score.
+type zmember struct {
+	member string
+	score  float64
+}
+
+// less orders the members by score and the members with equal score lexicographically, the same as redis.
+func (m zmember) less(other zmember) bool {
+	if m.score != other.score {
+		return m.score < other.score
+	}
+	return m.member < other.member
+}
+
+// zset is a sorted set value, a map for score lookups and a slice kept in order.
+// The updates are linear in the size of the set, which is plenty for tests.
+type zset struct {
+	scores map[string]float64
+	sorted []zmember
+}
+
+// newZSet returns a new empty sorted set.
+func newZSet() *zset {
+	return &zset{scores: make(map[string]float64)}
+}
+
+// search returns the index of the first member not less than the given one.
+func (z *zset) search(m zmember) int {
+	return sort.Search(len(z.sorted), func(i int) bool { return !z.sorted[i].less(m) })
+}
+
+// set sets the score of the member, adding the member if it is not in the set yet.
+func (z *zset) set(member string, score float64) {
+	z.remove(member)
+	m := zmember{member: member, score: score}
+	i := z.search(m)
+	z.sorted = append(z.sorted, zmember{})
+	copy(z.sorted[i+1:], z.sorted[i:])
+	z.sorted[i] = m
+	z.scores[member] = score
+}
+
+// remove removes the member and reports whether it was in the set.
+func (z *zset) remove(member string) bool {
+	score, ok := z.scores[member]
+	if !ok {
+		return false
+	}
+	i := z.search(zmember{member: member, score: score})
+	z.sorted = append(z.sorted[:i], z.sorted[i+1:]...)
+	delete(z.scores, member)
+	return true
+}
+
+// rank returns the 0-based rank of the member in the ascending order.
+func (z *zset) rank(member string) (int, bool) {
+	score, ok := z.scores[member]
+	if !ok {
+		return 0, false
+	}
+	return z.search(zmember{member: member, score: score}), true
+}
+
+// scoreRange returns the indexes [lo, hi) of the members with a score within the bounds.
+func (z *zset) scoreRange(lower, upper scoreBound) (int, int) {
+	lo := sort.Search(len(z.sorted), func(i int) bool { return lower.below(z.sorted[i].score) })
+	hi := sort.Search(len(z.sorted), func(i int) bool { return !upper.above(z.sorted[i].score) })
+	return lo, max(hi, lo)
+}
+
+// scoreBound is a bound of a score range, e.g. "5", "(5" or "-inf".
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+// parseScoreBound parses a score bound of ZCOUNT and ZRANGEBYSCORE.
+func parseScoreBound(s string) (scoreBound, bool) {
+	var bound scoreBound
+	if strings.HasPrefix(s, "(") {
+		bound.exclusive = true
+		s = s[1:]
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(value) {
+		return scoreBound{}, false
+	}
+	bound.value = value
+	return bound, true
+}
+
+// below reports whether the bound as a minimum allows the score.
+func (b scoreBound) below(score float64) bool {
+	if b.exclusive {
+		return score > b.value
+	}
+	return score >= b.value
+}
+
+// above reports whether the bound as a maximum allows the score.
+func (b scoreBound) above(score float64) bool {
+	if b.exclusive {
+		return score < b.value
+	}
+	return score <= b.value
+}
+
+// parseScore parses a score of ZADD and ZINCRBY.
+func parseScore(s string) (float64, bool) {
+	score, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, false
+	}
+	return score, true
+}
+
+// cmdZAdd implements ZADD key [NX|XX] [GT|LT] [CH] [INCR] score member [score member ...].
+func cmdZAdd(d *db, c *client, args []string) {
+	key := args[0]
+	var nx, xx, gt, lt, ch, incr bool
+	i := 1
+flags:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			gt = true
+		case "LT":
+			lt = true
+		case "CH":
+			ch = true
+		case "INCR":
+			incr = true
+		default:
+			break flags
+		}
+	}
+	pairs := args[i:]
+	switch {
+	case len(pairs) == 0 || len(pairs)%2 != 0:
+		c.w.error(errSyntax)
+		return
+	case nx && xx:
+		c.w.error("ERR XX and NX options at the same time are not compatible")
+		return
+	case (gt && lt) || (nx && (gt || lt)):
+		c.w.error("ERR GT, LT, and/or NX options at the same time are not compatible")
+		return
+	case incr && len(pairs) > 2:
+		c.w.error("ERR INCR option supports a single increment-element pair")
+		return
+	}
+	scores := make([]float64, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		score, ok := parseScore(pairs[j])
+		if !ok {
+			c.w.error(errNotFloat)
+			return
+		}
+		scores = append(scores, score)
+	}
+
+	z, wrongType := d.lookupZSet(key)
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if z == nil {
+		z = newZSet()
+	}
+	var added, changed int64
+	var incremented float64
+	updated := false
+	for j, score := range scores {
+		member := pairs[2*j+1]
+		old, exists := z.scores[member]
+		if (nx && exists) || (xx && !exists) {
+			continue
+		}
+		if incr {
+			score += old
+			if math.IsNaN(score) {
+				c.w.error("ERR resulting score is not a number (NaN)")
+				return
+			}
+		}
+		if exists && ((gt && score <= old) || (lt && score >= old)) {
+			continue
+		}
+		incremented, updated = score, true
+		if !exists {
+			added++
+		} else if score != old {
+			changed++
+		}
+		z.set(member, score)
+	}
+	// a sorted set is created only when a member is added to it
+	if len(z.sorted) > 0 {
+		d.values[key] = z
+	}
+
+	if incr {
+		if !updated {
+			c.w.null()
+			return
+		}
+		c.w.double(incremented)
+		return
+	}
+	if ch {
+		c.w.int(added + changed)
+		return
+	}
+	c.w.int(added)
+}
+
+// cmdZIncrBy implements ZINCRBY key increment member.
+func cmdZIncrBy(d *db, c *client, args []string) {
+	delta, ok := parseScore(args[1])
+	if !ok {
+		c.w.error(errNotFloat)
+		return
+	}
+	z, wrongType := d.lookupZSet(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if z == nil {
+		z = newZSet()
+		d.set(args[0], z)
+	}
+	score := z.scores[args[2]] + delta
+	if math.IsNaN(score) {
+		c.w.error("ERR resulting score is not a number (NaN)")
+		return
+	}
+	z.set(args[2], score)
+	c.w.double(score)
+}
+
+// cmdZScore implements ZSCORE key member.
+func cmdZScore(d *db, c *client, args []string) {
+	z, wrongType := d.lookupZSet(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if z == nil {
+		c.w.null()
+		return
+	}
+	score, ok := z.scores[args[1]]
+	if !ok {
+		c.w.null()
+		return
+	}
+	c.w.double(score)
+}
+
+// cmdZCard implements ZCARD key.
+func cmdZCard(d *db, c *client, args []string) {
+	z, wrongType := d.lookupZSet(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if z == nil {
+		c.w.int(0)
+		return
+	}
+	c.w.int(int64(len(z.sorted)))
+}
+
+// cmdZRem implements ZREM key member [member ...].
+func cmdZRem(d *db, c *client, args []string) {
+	z, wrongType := d.lookupZSet(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if z == nil {
+		c.w.int(0)
+		return
+	}
+	var removed int64
+	for _, member := range args[1:] {
+		if z.remove(member) {
+			removed++
+		}
+	}
+	// a sorted set emptied by the removal is deleted, the same as in redis
+	if len(z.sorted) == 0 {
+		d.delete(args[0])
+	}
+	c.w.int(removed)
+}
+
+// cmdZCount implements ZCOUNT key min max.
+func cmdZCount(d *db, c *client, args []string) {
+	lower, ok1 := parseScoreBound(args[1])
+	upper, ok2 := parseScoreBound(args[2])
+	if !ok1 || !ok2 {
+		c.w.error(errMinMaxFloat)
+		return
+	}
+	z, wrongType := d.lookupZSet(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if z == nil {
+		c.w.int(0)
+		return
+	}
+	lo, hi := z.scoreRange(lower, upper)
+	c.w.int(int64(hi - lo))
+}
+
+// cmdZRank implements ZRANK key member [WITHSCORE].
+func cmdZRank(d *db, c *client, args []string) {
+	rank(d, c, args, false)
+}
+
+// cmdZRevRank implements ZREVRANK key member [WITHSCORE].
+func cmdZRevRank(d *db, c *client, args []string) {
+	rank(d, c, args, true)
+}
+
+// rank writes the rank of the member in the ascending or descending order, optionally with its score.
+func rank(d *db, c *client, args []string, rev bool) {
+	withScore := false
+	switch {
+	case len(args) == 3 && strings.EqualFold(args[2], "WITHSCORE"):
+		withScore = true
+	case len(args) > 2:
+		c.w.error(errSyntax)
+		return
+	}
+	z, wrongType := d.lookupZSet(args[0])
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	var r int
+	ok := false
+	if z != nil {
+		r, ok = z.rank(args[1])
+	}
+	if !ok {
+		if withScore {
+			c.w.nullArray()
+			return
+		}
+		c.w.null()
+		return
+	}
+	if rev {
+		r = len(z.sorted) - 1 - r
+	}
+	if !withScore {
+		c.w.int(int64(r))
+		return
+	}
+	c.w.array(2)
+	c.w.int(int64(r))
+	c.w.double(z.scores[args[1]])
+}
+
+// rangeQuery is a parsed query of the ZRANGE family.
+type rangeQuery struct {
+	key        string
+	start      string
+	stop       string
+	byScore    bool
+	rev        bool
+	limit      bool
+	offset     int64
+	count      int64
+	withScores bool
+}
+
+// cmdZRange implements ZRANGE key start stop [BYSCORE] [REV] [LIMIT offset count] [WITHSCORES].
+// With BYSCORE and REV, start is the maximum and stop the minimum score.
+func cmdZRange(d *db, c *client, args []string) {
+	q := rangeQuery{key: args[0], start: args[1], stop: args[2]}
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "BYSCORE":
+			q.byScore = true
+		case "BYLEX":
+			c.w.error("ERR BYLEX is not supported")
+			return
+		case "REV":
+			q.rev = true
+		case "WITHSCORES":
+			q.withScores = true
+		case "LIMIT":
+			if !parseLimit(c, args, &i, &q) {
+				return
+			}
+		default:
+			c.w.error(errSyntax)
+			return
+		}
+	}
+	if q.limit && !q.byScore {
+		c.w.error("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+		return
+	}
+	zrange(d, c, q)
+}
+
+// cmdZRevRange implements ZREVRANGE key start stop [WITHSCORES].
+func cmdZRevRange(d *db, c *client, args []string) {
+	q := rangeQuery{key: args[0], start: args[1], stop: args[2], rev: true}
+	switch {
+	case len(args) == 4 && strings.EqualFold(args[3], "WITHSCORES"):
+		q.withScores = true
+	case len(args) > 3:
+		c.w.error(errSyntax)
+		return
+	}
+	zrange(d, c, q)
+}
+
+// cmdZRangeByScore implements ZRANGEBYSCORE key min max [WITHSCORES] [LIMIT offset count].
+func cmdZRangeByScore(d *db, c *client, args []string) {
+	q := rangeQuery{key: args[0], start: args[1], stop: args[2], byScore: true}
+	if parseByScoreOptions(c, args, &q) {
+		zrange(d, c, q)
+	}
+}
+
+// cmdZRevRangeByScore implements ZREVRANGEBYSCORE key max min [WITHSCORES] [LIMIT offset count].
+func cmdZRevRangeByScore(d *db, c *client, args []string) {
+	q := rangeQuery{key: args[0], start: args[1], stop: args[2], byScore: true, rev: true}
+	if parseByScoreOptions(c, args, &q) {
+		zrange(d, c, q)
+	}
+}
+
+// parseByScoreOptions parses the WITHSCORES and LIMIT options of ZRANGEBYSCORE and ZREVRANGEBYSCORE.
+func parseByScoreOptions(c *client, args []string, q *rangeQuery) bool {
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "WITHSCORES":
+			q.withScores = true
+		case "LIMIT":
+			if !parseLimit(c, args, &i, q) {
+				return false
+			}
+		default:
+			c.w.error(errSyntax)
+			return false
+		}
+	}
+	return true
+}
+
+// parseLimit parses the offset and count following LIMIT at args[*i] and advances *i past them.
+func parseLimit(c *client, args []string, i *int, q *rangeQuery) bool {
+	if *i+2 >= len(args) {
+		c.w.error(errSyntax)
+		return false
+	}
+	offset, err1 := strconv.ParseInt(args[*i+1], 10, 64)
+	count, err2 := strconv.ParseInt(args[*i+2], 10, 64)
+	if err1 != nil || err2 != nil {
+		c.w.error(errNotInteger)
+		return false
+	}
+	q.limit, q.offset, q.count = true, offset, count
+	*i += 2
+	return true
+}
+
+// zrange writes the members selected by the query.
+func zrange(d *db, c *client, q rangeQuery) {
+	var start, stop int64
+	var lower, upper scoreBound
+	if q.byScore {
+		var ok1, ok2 bool
+		lower, ok1 = parseScoreBound(q.start)
+		upper, ok2 = parseScoreBound(q.stop)
+		if !ok1 || !ok2 {
+			c.w.error(errMinMaxFloat)
+			return
+		}
+		if q.rev {
+			lower, upper = upper, lower
+		}
+	} else {
+		var err1, err2 error
+		start, err1 = strconv.ParseInt(q.start, 10, 64)
+		stop, err2 = strconv.ParseInt(q.stop, 10, 64)
+		if err1 != nil || err2 != nil {
+			c.w.error(errNotInteger)
+			return
+		}
+	}
+	z, wrongType := d.lookupZSet(q.key)
+	if wrongType {
+		c.w.error(errWrongType)
+		return
+	}
+	if z == nil {
+		c.w.array(0)
+		return
+	}
+
+	var members []zmember
+	if q.byScore {
+		lo, hi := z.scoreRange(lower, upper)
+		members = make([]zmember, 0, hi-lo)
+		for i := lo; i < hi; i++ {
+			members = append(members, z.sorted[i])
+		}
+		if q.rev {
+			slices.Reverse(members)
+		}
+		if q.limit {
+			members = applyLimit(members, q.offset, q.count)
+		}
+	} else {
+		length := int64(len(z.sorted))
+		if start, stop, ok := indexRange(start, stop, length); ok {
+			for i := start; i <= stop; i++ {
+				index := i
+				if q.rev {
+					index = length - 1 - i
+				}
+				members = append(members, z.sorted[index])
+			}
+		}
+	}
+	writeMembers(c, members, q.withScores)
+}
+
+// applyLimit returns the members selected by LIMIT offset count, a negative count selects all the rest.
+func applyLimit(members []zmember, offset, count int64) []zmember {
+	if offset < 0 || offset >= int64(len(members)) {
+		return nil
+	}
+	members = members[offset:]
+	if count >= 0 && count < int64(len(members)) {
+		members = members[:count]
+	}
+	return members
+}
+
+// writeMembers writes the members as an array, with their scores as a flat array in RESP2
+// or as an array of pairs in RESP3, the same as redis.
+func writeMembers(c *client, members []zmember, withScores bool) {
+	if !withScores {
+		c.w.array(len(members))
+		for _, m := range members {
+			c.w.bulk(m.member)
+		}
+		return
+	}
+	if c.w.proto == 3 {
+		c.w.array(len(members))
+		for _, m := range members {
+			c.w.array(2)
+			c.w.bulk(m.member)
+			c.w.double(m.score)
+		}
+		return
+	}
+	c.w.array(2 * len(members))
+	for _, m := range members {
+		c.w.bulk(m.member)
+		c.w.double(m.score)
+	}
+}
+
+// cmdZUnionStore implements ZUNIONSTORE destination numkeys key [key ...] [WEIGHTS weight ...] [AGGREGATE SUM|MIN|MAX].
+func cmdZUnionStore(d *db, c *client, args []string) {
+	combineStore(d, c, args, "zunionstore", false)
+}
+
+// cmdZInterStore implements ZINTERSTORE destination numkeys key [key ...] [WEIGHTS weight ...] [AGGREGATE SUM|MIN|MAX].
+func cmdZInterStore(d *db, c *client, args []string) {
+	combineStore(d, c, args, "zinterstore", true)
+}
+
+// cmdZUnion implements ZUNION numkeys key [key ...] [WEIGHTS weight ...] [AGGREGATE SUM|MIN|MAX] [WITHSCORES].
+func cmdZUnion(d *db, c *client, args []string) {
+	combineReply(d, c, args, "zunion", false)
+}
+
+// cmdZInter implements ZINTER numkeys key [key ...] [WEIGHTS weight ...] [AGGREGATE SUM|MIN|MAX] [WITHSCORES].
+func cmdZInter(d *db, c *client, args []string) {
+	combineReply(d, c, args, "zinter", true)
+}
+
+// combineStore stores the union or the intersection under the destination, replacing it, and writes its size.
+// The destination is deleted if the result is empty, the same as in redis.
+func combineStore(d *db, c *client, args []string, name string, intersect bool) {
+	z, _, ok := combine(d, c, args[1:], name, intersect, false)
+	if !ok {
+		return
+	}
+	if len(z.sorted) == 0 {
+		d.delete(args[0])
+	} else {
+		d.set(args[0], z)
+	}
+	c.w.int(int64(len(z.sorted)))
+}
+
+// combineReply writes the union or the intersection in the ascending order, the same as ZRANGE.
+func combineReply(d *db, c *client, args []string, name string, intersect bool) {
+	z, withScores, ok := combine(d, c, args, name, intersect, true)
+	if !ok {
+		return
+	}
+	writeMembers(c, z.sorted, withScores)
+}
+
+// combine parses numkeys key [key ...] [WEIGHTS weight ...] [AGGREGATE SUM|MIN|MAX], and [WITHSCORES] if allowed,
+// and computes the union or the intersection of the sorted sets. A missing key is an empty sorted set.
+// Writes the error and reports false if the arguments or the keys are invalid.
+func combine(d *db, c *client, args []string, name string, intersect, allowWithScores bool) (*zset, bool, bool) {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		c.w.error(errNotInteger)
+		return nil, false, false
+	}
+	if numKeys < 1 {
+		c.w.error("ERR at least 1 input key is needed for '" + name + "' command")
+		return nil, false, false
+	}
+	if numKeys > len(args)-1 {
+		c.w.error(errSyntax)
+		return nil, false, false
+	}
+	keys := args[1 : 1+numKeys]
+
+	weights := make([]float64, numKeys)
+	for i := range weights {
+		weights[i] = 1
+	}
+	aggregate, withScores := "SUM", false
+	for i := 1 + numKeys; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i]); {
+		case option == "WEIGHTS" && i+numKeys < len(args):
+			for j := range weights {
+				weight, ok := parseScore(args[i+1+j])
+				if !ok {
+					c.w.error("ERR weight value is not a float")
+					return nil, false, false
+				}
+				weights[j] = weight
+			}
+			i += numKeys
+		case option == "AGGREGATE" && i+1 < len(args):
+			aggregate = strings.ToUpper(args[i+1])
+			if aggregate != "SUM" && aggregate != "MIN" && aggregate != "MAX" {
+				c.w.error(errSyntax)
+				return nil, false, false
+			}
+			i++
+		case option == "WITHSCORES" && allowWithScores:
+			withScores = true
+		default:
+			c.w.error(errSyntax)
+			return nil, false, false
+		}
+	}
+
+	sets := make([]*zset, numKeys)
+	for i, key := range keys {
+		z, wrongType := d.lookupZSet(key)
+		if wrongType {
+			c.w.error(errWrongType)
+			return nil, false, false
+		}
+		sets[i] = z
+	}
+
+	scores := make(map[string]float64)
+	counts := make(map[string]int)
+	for i, z := range sets {
+		if z == nil {
+			continue
+		}
+		for _, m := range z.sorted {
+			score := m.score * weights[i]
+			// inf * 0 and inf - inf are 0 in redis instead of NaN
+			if math.IsNaN(score) {
+				score = 0
+			}
+			current, seen := scores[m.member]
+			counts[m.member]++
+			if !seen {
+				scores[m.member] = score
+				continue
+			}
+			switch aggregate {
+			case "SUM":
+				score += current
+				if math.IsNaN(score) {
+					score = 0
+				}
+			case "MIN":
+				score = min(score, current)
+			case "MAX":
+				score = max(score, current)
+			}
+			scores[m.member] = score
+		}
+	}
+
+	result := newZSet()
+	for member, score := range scores {
+		if intersect && counts[member] != numKeys {
+			continue
+		}
+		result.scores[member] = score
+		result.sorted = append(result.sorted, zmember{member: member, score: score})
+	}
+	slices.SortFunc(result.sorted, func(a, b zmember) int {
+		if a.less(b) {
+			return -1
+		}
+		if b.less(a) {
+			return 1
+		}
+		return 0
+	})
+	return result, withScores, true
+}
@@ -0,0 +1,426 @@
+package season
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultPageSize is the default number of entries read from an archived season by a single page of CarryOver.
+	DefaultPageSize = 1000
+	// DefaultLockTTL is the default time a CarryOver holds the lock of the season.
+	DefaultLockTTL = time.Minute
+)
+
+var (
+	// ErrNoSeason is returned when no season has been started yet.
+	ErrNoSeason = errors.New("no season started")
+	// ErrSeasonNotFound is returned when the season is not in the registry.
+	ErrSeasonNotFound = errors.New("season not found")
+	// ErrSeasonExists is returned when a season with the same ID has already been started.
+	ErrSeasonExists = errors.New("season already exists")
+	// ErrSeasonActive is returned when an operation needs a season that has already ended.
+	ErrSeasonActive = errors.New("season has not ended yet")
+	// ErrAlreadyCarriedOver is returned when the points of a season have already been carried over.
+	ErrAlreadyCarriedOver = errors.New("points already carried over")
+	// ErrConcurrentStart is returned when the current season changed while a new season was being started.
+	ErrConcurrentStart = errors.New("current season changed concurrently")
+	// ErrCarryOverInProgress is returned when the points of a season are being carried over by another CarryOver.
+	ErrCarryOverInProgress = errors.New("points are being carried over")
+)
+
+// RegistryKey returns the key of the hash holding the seasons of the leaderboard with the given key.
+func RegistryKey(key string) string {
+	return key + ":seasons"
+}
+
+// CurrentKey returns the key holding the ID of the current season of the leaderboard with the given key.
+func CurrentKey(key string) string {
+	return key + ":season"
+}
+
+// ArchiveKey returns the key the leaderboard with the given key is renamed to when the season ends.
+func ArchiveKey(key, id string) string {
+	return key + ":season:" + id
+}
+
+// ProgressKey returns the key of the stream recording the members awarded by the carry-over of the season
+// with the given ID of the leaderboard with the given key, until the carry-over completes.
+func ProgressKey(key, id string) string {
+	return ArchiveKey(key, id) + ":carryover"
+}
+
+// lockKey returns the key of the lock of the carry-over of the season with the given ID.
+func lockKey(key, id string) string {
+	return ProgressKey(key, id) + ":lock"
+}
+
+// Season is the metadata of a season kept in the registry.
+// The scores of the current season are in the leaderboard itself, the scores of an ended season in its Key.
+type Season struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Key is the archive key holding the final scores once the season ends.
+	Key     string    `json:"key"`
+	Started time.Time `json:"started"`
+	// Ended is zero while the season is current.
+	Ended time.Time `json:"ended"`
+	// Members is the number of members on the leaderboard when the season ended.
+	Members int64 `json:"members"`
+	// CarriedOver reports whether the points for the final ranks have been awarded to the next season.
+	CarriedOver bool `json:"carried_over"`
+}
+
+// Active reports whether the season is the current season.
+func (s Season) Active() bool {
+	return s.Ended.IsZero()
+}
+
+// Manager manages the seasons of a leaderboard. When a new season starts, the leaderboard is renamed
+// to the archive key of the ending season and the new season starts with an empty leaderboard.
+// With a redis cluster, the key of the leaderboard must contain a hash tag, e.g. "{scoreboard}",
+// so the leaderboard, its archives and the registry are in the same slot.
+type Manager struct {
+	// Now returns the time recorded as the start and end of the seasons, time.Now if nil.
+	Now func() time.Time
+	// PageSize is the number of entries read by a single page of CarryOver, DefaultPageSize if zero.
+	PageSize int64
+	// LockTTL is the time a CarryOver holds the lock of the season, DefaultLockTTL if zero.
+	// It must be longer than awarding the points of a season takes.
+	LockTTL time.Duration
+
+	client   redis.UniversalClient
+	lb       *leaderboard.Leaderboard
+	registry string
+	current  string
+}
+
+// New returns a new Manager of the seasons of the leaderboard.
+func New(client redis.UniversalClient, lb *leaderboard.Leaderboard) *Manager {
+	return &Manager{
+		client:   client,
+		lb:       lb,
+		registry: RegistryKey(lb.Key()),
+		current:  CurrentKey(lb.Key()),
+	}
+}
+
+// startScript atomically ends the current season and starts a new one.
+// KEYS[1] is the leaderboard, KEYS[2] the registry, KEYS[3] the current season ID and KEYS[4] the archive key
+// of the current season. ARGV holds the expected current season ID, empty if none, the new season ID,
+// the new season and the ending season with its end time.
+// It returns 1 if the season started, 0 if the current season is not the expected one
+// and -1 if the new season already exists.
+var startScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[3]) or ''
+if current ~= ARGV[1] then
+	return 0
+end
+if redis.call('HEXISTS', KEYS[2], ARGV[2]) == 1 then
+	return -1
+end
+if current ~= '' then
+	local ended = cjson.decode(ARGV[4])
+	ended['members'] = 0
+	if redis.call('EXISTS', KEYS[1]) == 1 then
+		redis.call('RENAME', KEYS[1], KEYS[4])
+		ended['members'] = redis.call('ZCARD', KEYS[4])
+	end
+	redis.call('HSET', KEYS[2], current, cjson.encode(ended))
+end
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+redis.call('SET', KEYS[3], ARGV[2])
+return 1
+`)
+
+// finishScript marks the points of an ended season as carried over and deletes the progress of the carry-over.
+// KEYS[1] is the registry and KEYS[2] the progress stream, ARGV[1] is the season ID.
+// It returns 1 if the season was marked, 0 if it was already marked and -1 if it does not exist.
+var finishScript = redis.NewScript(`
+local raw = redis.call('HGET', KEYS[1], ARGV[1])
+if not raw then
+	return -1
+end
+local season = cjson.decode(raw)
+if season['carried_over'] then
+	return 0
+end
+season['carried_over'] = true
+redis.call('HSET', KEYS[1], ARGV[1], cjson.encode(season))
+redis.call('DEL', KEYS[2])
+return 1
+`)
+
+// unlockScript deletes the lock KEYS[1] if it is still held with the token ARGV[1].
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Start ends the current season and starts the season with the given ID and name, returning the ended season.
+// The leaderboard is renamed to the archive key of the ended season in the same atomic step,
+// so every score lands either in the ended season or in the new one.
+// If no season has been started yet, the scores already on the leaderboard become part of the new season
+// and the returned season is zero.
+func (m *Manager) Start(ctx context.Context, id, name string) (Season, error) {
+	if id == "" {
+		return Season{}, errors.New("season ID must not be empty")
+	}
+	ended, err := m.Current(ctx)
+	if err != nil && !errors.Is(err, ErrNoSeason) {
+		return Season{}, err
+	}
+
+	now := m.now()
+	started, err := json.Marshal(Season{
+		ID:      id,
+		Name:    name,
+		Key:     ArchiveKey(m.lb.Key(), id),
+		Started: now,
+	})
+	if err != nil {
+		return Season{}, fmt.Errorf("failed to encode season %q: %w", id, err)
+	}
+	var endedRaw []byte
+	if ended.ID != "" {
+		ended.Ended = now
+		if endedRaw, err = json.Marshal(ended); err != nil {
+			return Season{}, fmt.Errorf("failed to encode season %q: %w", ended.ID, err)
+		}
+	}
+
+	keys := []string{m.lb.Key(), m.registry, m.current, ArchiveKey(m.lb.Key(), ended.ID)}
+	status, err := startScript.Run(ctx, m.client, keys, ended.ID, id, started, endedRaw).Int()
+	if err != nil {
+		return Season{}, fmt.Errorf("failed to start season %q: %w", id, err)
+	}
+	switch status {
+	case 0:
+		return Season{}, fmt.Errorf("failed to start season %q: %w", id, ErrConcurrentStart)
+	case -1:
+		return Season{}, fmt.Errorf("failed to start season %q: %w", id, ErrSeasonExists)
+	}
+	if ended.ID == "" {
+		return Season{}, nil
+	}
+	// the number of members is only known to the script
+	return m.Get(ctx, ended.ID)
+}
+
+// Current returns the current season. Returns ErrNoSeason if no season has been started yet.
+func (m *Manager) Current(ctx context.Context) (Season, error) {
+	id, err := m.client.Get(ctx, m.current).Result()
+	if errors.Is(err, redis.Nil) {
+		return Season{}, ErrNoSeason
+	}
+	if err != nil {
+		return Season{}, fmt.Errorf("failed to get current season of %q: %w", m.lb.Key(), err)
+	}
+	return m.Get(ctx, id)
+}
+
+// Get returns the season with the given ID. Returns ErrSeasonNotFound if the season is not in the registry.
+func (m *Manager) Get(ctx context.Context, id string) (Season, error) {
+	raw, err := m.client.HGet(ctx, m.registry, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return Season{}, fmt.Errorf("failed to get season %q: %w", id, ErrSeasonNotFound)
+	}
+	if err != nil {
+		return Season{}, fmt.Errorf("failed to get season %q: %w", id, err)
+	}
+	var season Season
+	if err := json.Unmarshal([]byte(raw), &season); err != nil {
+		return Season{}, fmt.Errorf("failed to decode season %q: %w", id, err)
+	}
+	return season, nil
+}
+
+// List returns all seasons in the registry ordered by their start, the current season last.
+func (m *Manager) List(ctx context.Context) ([]Season, error) {
+	all, err := m.client.HGetAll(ctx, m.registry).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list seasons of %q: %w", m.lb.Key(), err)
+	}
+	seasons := make([]Season, 0, len(all))
+	for id, raw := range all {
+		var season Season
+		if err := json.Unmarshal([]byte(raw), &season); err != nil {
+			return nil, fmt.Errorf("failed to decode season %q: %w", id, err)
+		}
+		seasons = append(seasons, season)
+	}
+	slices.SortFunc(seasons, func(a, b Season) int {
+		return a.Started.Compare(b.Started)
+	})
+	return seasons, nil
+}
+
+// Board returns the leaderboard of the season, the leaderboard itself for the current season.
+// The archived leaderboards share the ranking settings of the leaderboard.
+func (m *Manager) Board(season Season) *leaderboard.Leaderboard {
+	if season.Active() {
+		return m.lb
+	}
+	return m.lb.WithKey(season.Key)
+}
+
+// Top returns the n best members of the season with the given ID.
+// Returns ErrSeasonNotFound if the season is not in the registry.
+func (m *Manager) Top(ctx context.Context, id string, n int64) ([]leaderboard.Entry, error) {
+	season, err := m.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return m.Board(season).Top(ctx, n)
+}
+
+// CarryOver awards points[i] to the members of the ended season with the given ID whose final rank was i+1,
+// adding them to their scores in the current season like Update with UpdateIncrement, and returns the number
+// of awarded members. The final ranks are in the ranking mode of the leaderboard, so tied members get the same points.
+// Every award is recorded in the progress stream of the season by the same script as the increment,
+// so a CarryOver that failed midway can be run again and awards only the remaining members.
+// A lock of the season keeps concurrent CarryOvers from awarding the same points twice.
+// Returns ErrAlreadyCarriedOver if the season has already been carried over
+// and ErrCarryOverInProgress if another CarryOver holds the lock.
+func (m *Manager) CarryOver(ctx context.Context, id string, points []float64) (int64, error) {
+	token, err := newToken()
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	locked, err := m.client.SetNX(ctx, lockKey(m.lb.Key(), id), token, m.lockTTL()).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock season %q: %w", id, err)
+	}
+	if !locked {
+		return 0, fmt.Errorf("failed to carry over season %q: %w", id, ErrCarryOverInProgress)
+	}
+	defer func() {
+		// the lock is released only if it was not taken over after expiring
+		_ = unlockScript.Run(context.WithoutCancel(ctx), m.client, []string{lockKey(m.lb.Key(), id)}, token).Err()
+	}()
+
+	// the season is read under the lock, so a carry-over completed by the previous holder is seen
+	season, err := m.Get(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if season.Active() {
+		return 0, fmt.Errorf("failed to carry over season %q: %w", id, ErrSeasonActive)
+	}
+	if season.CarriedOver {
+		return 0, fmt.Errorf("failed to carry over season %q: %w", id, ErrAlreadyCarriedOver)
+	}
+
+	awards, err := m.awards(ctx, m.Board(season), points)
+	if err != nil {
+		return 0, fmt.Errorf("failed to carry over season %q: %w", id, err)
+	}
+	progress := leaderboard.ChangeStream{Key: ProgressKey(m.lb.Key(), id)}
+	awarded, err := m.awarded(ctx, progress.Key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to carry over season %q: %w", id, err)
+	}
+	var n int64
+	for _, award := range awards {
+		if !awarded[award.Member] {
+			if _, err := m.lb.UpdateRecorded(ctx, award.Member, award.Score, leaderboard.UpdateIncrement, progress); err != nil {
+				return n, fmt.Errorf("failed to carry over season %q: %w", id, err)
+			}
+		}
+		n++
+	}
+
+	status, err := finishScript.Run(ctx, m.client, []string{m.registry, progress.Key}, id).Int()
+	if err != nil {
+		return n, fmt.Errorf("failed to carry over season %q: %w", id, err)
+	}
+	switch status {
+	case 0:
+		return n, fmt.Errorf("failed to carry over season %q: %w", id, ErrAlreadyCarriedOver)
+	case -1:
+		return n, fmt.Errorf("failed to carry over season %q: %w", id, ErrSeasonNotFound)
+	}
+	return n, nil
+}
+
+// awarded returns the members already awarded by a previous attempt of the carry-over recorded in the progress stream.
+func (m *Manager) awarded(ctx context.Context, progress string) (map[string]bool, error) {
+	messages, err := m.client.XRange(ctx, progress, "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress %q: %w", progress, err)
+	}
+	awarded := make(map[string]bool, len(messages))
+	for _, message := range messages {
+		if member, ok := message.Values["member"].(string); ok {
+			awarded[member] = true
+		}
+	}
+	return awarded, nil
+}
+
+// awards returns the entries of the archived leaderboard ranked within the points with the points as their scores.
+func (m *Manager) awards(ctx context.Context, archive *leaderboard.Leaderboard, points []float64) ([]leaderboard.Entry, error) {
+	var awards []leaderboard.Entry
+	for offset := int64(0); ; offset += m.pageSize() {
+		entries, err := archive.Page(ctx, offset, m.pageSize())
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Rank > int64(len(points)) {
+				return awards, nil
+			}
+			if points[entry.Rank-1] != 0 {
+				awards = append(awards, leaderboard.Entry{Member: entry.Member, Score: points[entry.Rank-1]})
+			}
+		}
+		if int64(len(entries)) < m.pageSize() {
+			return awards, nil
+		}
+	}
+}
+
+// now returns the current time.
+func (m *Manager) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+// lockTTL returns the time a CarryOver holds the lock of the season.
+func (m *Manager) lockTTL() time.Duration {
+	if m.LockTTL > 0 {
+		return m.LockTTL
+	}
+	return DefaultLockTTL
+}
+
+// pageSize returns the number of entries read by a single page of CarryOver.
+func (m *Manager) pageSize() int64 {
+	if m.PageSize > 0 {
+		return m.PageSize
+	}
+	return DefaultPageSize
+}
+
+// newToken returns a new random lock token.
+func newToken() (string, error) {
+	var token [16]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token[:]), nil
+}
@@ -0,0 +1,370 @@
+package season_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/season"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisAddrEnv is the environment variable with the address of a real redis to run the tests against,
+// the same as in the tests of the leaderboard package. The database of the address is flushed by the tests.
+const redisAddrEnv = "LEADERBOARD_TEST_REDIS_ADDR"
+
+// key is the key of the leaderboard of the tests.
+const key = "{scoreboard}"
+
+// start is the start of the first season of the tests.
+var start = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newManager returns a manager of the seasons of a leaderboard in the flushed real redis with the scores,
+// the test is skipped if its address is not set. The manager uses lua scripts, which resptest does not run.
+func newManager(t *testing.T, scores map[string]float64) (*season.Manager, *leaderboard.Leaderboard, *redis.Client) {
+	t.Helper()
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skip(redisAddrEnv + " is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := client.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("FLUSHDB failed: %v", err)
+	}
+
+	lb := leaderboard.New(leaderboard.NewRedisStore(client), key)
+	submit(t, lb, scores)
+	manager := season.New(client, lb)
+	now := start
+	// every season starts a day after the previous one
+	manager.Now = func() time.Time {
+		now = now.Add(24 * time.Hour)
+		return now
+	}
+	return manager, lb, client
+}
+
+// submit submits the scores to the leaderboard.
+func submit(t *testing.T, lb *leaderboard.Leaderboard, scores map[string]float64) {
+	t.Helper()
+	for member, score := range scores {
+		if err := lb.Submit(context.Background(), member, score); err != nil {
+			t.Fatalf("Submit(%q, %v) failed: %v", member, score, err)
+		}
+	}
+}
+
+// assertScores checks the scores of the members on the leaderboard, a missing member has no score.
+func assertScores(t *testing.T, lb *leaderboard.Leaderboard, want map[string]float64) {
+	t.Helper()
+	size, err := lb.Size(context.Background())
+	if err != nil {
+		t.Fatalf("Size() failed: %v", err)
+	}
+	if size != int64(len(want)) {
+		t.Errorf("Size() = %d, want %d", size, len(want))
+	}
+	for member, score := range want {
+		entry, err := lb.Rank(context.Background(), member)
+		if err != nil || entry.Score != score {
+			t.Errorf("Rank(%q) = %v, %v, want score %v", member, entry, err, score)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	ctx := context.Background()
+	manager, lb, client := newManager(t, map[string]float64{"Alfred": 100})
+
+	if _, err := manager.Current(ctx); !errors.Is(err, season.ErrNoSeason) {
+		t.Fatalf("Current() = %v, want ErrNoSeason", err)
+	}
+	if _, err := manager.Start(ctx, "", "Nameless"); err == nil {
+		t.Errorf("Start() with an empty ID succeeded, want error")
+	}
+	// the scores submitted before the first season are part of it
+	ended, err := manager.Start(ctx, "spring", "Spring")
+	if err != nil {
+		t.Fatalf("Start(spring) failed: %v", err)
+	}
+	if ended != (season.Season{}) {
+		t.Errorf("Start(spring) = %+v, want zero season", ended)
+	}
+	submit(t, lb, map[string]float64{"Bob": 200})
+
+	ended, err = manager.Start(ctx, "summer", "Summer")
+	if err != nil {
+		t.Fatalf("Start(summer) failed: %v", err)
+	}
+	want := season.Season{
+		ID:      "spring",
+		Name:    "Spring",
+		Key:     season.ArchiveKey(key, "spring"),
+		Started: start.Add(24 * time.Hour),
+		Ended:   start.Add(48 * time.Hour),
+		Members: 2,
+	}
+	if !ended.Started.Equal(want.Started) || !ended.Ended.Equal(want.Ended) {
+		t.Errorf("Start(summer) = %+v, want %+v", ended, want)
+	}
+	ended.Started, ended.Ended = want.Started, want.Ended
+	if ended != want {
+		t.Errorf("Start(summer) = %+v, want %+v", ended, want)
+	}
+	if ended.Active() {
+		t.Errorf("ended season is active")
+	}
+
+	// the leaderboard was renamed to the archive
+	assertScores(t, lb, map[string]float64{})
+	assertScores(t, lb.WithKey(want.Key), map[string]float64{"Alfred": 100, "Bob": 200})
+	current, err := manager.Current(ctx)
+	if err != nil || current.ID != "summer" || !current.Active() {
+		t.Errorf("Current() = %+v, %v, want active summer", current, err)
+	}
+	if id, err := client.Get(ctx, season.CurrentKey(key)).Result(); err != nil || id != "summer" {
+		t.Errorf("GET %s = %q, %v, want summer", season.CurrentKey(key), id, err)
+	}
+	if n, err := client.HLen(ctx, season.RegistryKey(key)).Result(); err != nil || n != 2 {
+		t.Errorf("HLEN %s = %d, %v, want 2", season.RegistryKey(key), n, err)
+	}
+
+	// an empty leaderboard ends with no members
+	ended, err = manager.Start(ctx, "autumn", "")
+	if err != nil || ended.ID != "summer" || ended.Members != 0 {
+		t.Errorf("Start(autumn) = %+v, %v, want summer with no members", ended, err)
+	}
+
+	if _, err := manager.Start(ctx, "spring", "Spring again"); !errors.Is(err, season.ErrSeasonExists) {
+		t.Errorf("Start(spring) again = %v, want ErrSeasonExists", err)
+	}
+	if _, err := manager.Get(ctx, "winter"); !errors.Is(err, season.ErrSeasonNotFound) {
+		t.Errorf("Get(winter) = %v, want ErrSeasonNotFound", err)
+	}
+}
+
+// startHook runs fn once before the first script, so a change between reading and starting a season is simulated.
+type startHook struct {
+	fn func()
+}
+
+func (h *startHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *startHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if h.fn != nil && (cmd.Name() == "evalsha" || cmd.Name() == "eval") {
+			fn := h.fn
+			h.fn = nil
+			fn()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *startHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestStartConcurrent(t *testing.T) {
+	ctx := context.Background()
+	manager, lb, client := newManager(t, map[string]float64{"Alfred": 100})
+	if _, err := manager.Start(ctx, "spring", ""); err != nil {
+		t.Fatalf("Start(spring) failed: %v", err)
+	}
+
+	// another manager starts a season after this one read the current season
+	other := season.New(client, lb)
+	client.AddHook(&startHook{fn: func() {
+		if _, err := other.Start(ctx, "summer", ""); err != nil {
+			t.Errorf("Start(summer) of the other manager failed: %v", err)
+		}
+	}})
+	if _, err := manager.Start(ctx, "autumn", ""); !errors.Is(err, season.ErrConcurrentStart) {
+		t.Fatalf("Start(autumn) = %v, want ErrConcurrentStart", err)
+	}
+	// the season of the other manager is kept
+	current, err := manager.Current(ctx)
+	if err != nil || current.ID != "summer" {
+		t.Errorf("Current() = %+v, %v, want summer", current, err)
+	}
+	if _, err := manager.Get(ctx, "autumn"); !errors.Is(err, season.ErrSeasonNotFound) {
+		t.Errorf("Get(autumn) = %v, want ErrSeasonNotFound", err)
+	}
+	assertScores(t, lb.WithKey(season.ArchiveKey(key, "spring")), map[string]float64{"Alfred": 100})
+}
+
+func TestListAndTop(t *testing.T) {
+	ctx := context.Background()
+	manager, lb, _ := newManager(t, nil)
+	// the IDs are not in the order of the seasons
+	for i, id := range []string{"winter", "autumn", "summer"} {
+		submit(t, lb, map[string]float64{"Alfred": float64(100 * (i + 1)), "Bob": 150})
+		if _, err := manager.Start(ctx, id, ""); err != nil {
+			t.Fatalf("Start(%s) failed: %v", id, err)
+		}
+	}
+
+	seasons, err := manager.List(ctx)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	want := []string{"winter", "autumn", "summer"}
+	if len(seasons) != len(want) {
+		t.Fatalf("List() = %+v, want %v", seasons, want)
+	}
+	for i, s := range seasons {
+		if s.ID != want[i] || s.Active() != (i == len(want)-1) {
+			t.Errorf("List()[%d] = %+v, want %s", i, s, want[i])
+		}
+	}
+
+	// the archived season keeps its final scores
+	top, err := manager.Top(ctx, "autumn", 10)
+	if err != nil {
+		t.Fatalf("Top(autumn) failed: %v", err)
+	}
+	wantTop := []leaderboard.Entry{{Rank: 1, Member: "Alfred", Score: 300}, {Rank: 2, Member: "Bob", Score: 150}}
+	if len(top) != len(wantTop) || top[0] != wantTop[0] || top[1] != wantTop[1] {
+		t.Errorf("Top(autumn) = %v, want %v", top, wantTop)
+	}
+	// the current season is the leaderboard itself
+	submit(t, lb, map[string]float64{"Cecil": 50})
+	if top, err := manager.Top(ctx, "summer", 10); err != nil || len(top) != 1 || top[0].Member != "Cecil" {
+		t.Errorf("Top(summer) = %v, %v, want Cecil", top, err)
+	}
+	if _, err := manager.Top(ctx, "spring", 10); !errors.Is(err, season.ErrSeasonNotFound) {
+		t.Errorf("Top(spring) = %v, want ErrSeasonNotFound", err)
+	}
+}
+
+func TestCarryOver(t *testing.T) {
+	// Bob and Cecil are tied on the second place, Dave is fourth
+	final := map[string]float64{"Alfred": 300, "Bob": 200, "Cecil": 200, "Dave": 100, "Eve": 50}
+	points := []float64{100, 50, 25}
+	tests := []struct {
+		mode leaderboard.RankingMode
+		want map[string]float64
+	}{
+		// the ordinal ranks of the tied members follow the order of the sorted set
+		{leaderboard.RankOrdinal, map[string]float64{"Alfred": 100, "Cecil": 50, "Bob": 25}},
+		{leaderboard.RankStandard, map[string]float64{"Alfred": 100, "Bob": 50, "Cecil": 50}},
+		{leaderboard.RankDense, map[string]float64{"Alfred": 100, "Bob": 50, "Cecil": 50, "Dave": 25}},
+	}
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			ctx := context.Background()
+			manager, lb, client := newManager(t, final)
+			lb.RankingMode = test.mode
+			// the page size is smaller than the awarded ranks, so the archive is read in several pages
+			manager.PageSize = 2
+			if _, err := manager.Start(ctx, "spring", ""); err != nil {
+				t.Fatalf("Start(spring) failed: %v", err)
+			}
+			if _, err := manager.CarryOver(ctx, "spring", points); !errors.Is(err, season.ErrSeasonActive) {
+				t.Errorf("CarryOver(spring) of the active season = %v, want ErrSeasonActive", err)
+			}
+			if _, err := manager.Start(ctx, "summer", ""); err != nil {
+				t.Fatalf("Start(summer) failed: %v", err)
+			}
+			// the points are added to the scores of the new season
+			submit(t, lb, map[string]float64{"Alfred": 10})
+
+			n, err := manager.CarryOver(ctx, "spring", points)
+			if err != nil {
+				t.Fatalf("CarryOver(spring) failed: %v", err)
+			}
+			if n != int64(len(test.want)) {
+				t.Errorf("CarryOver(spring) = %d, want %d", n, len(test.want))
+			}
+			want := map[string]float64{}
+			for member, score := range test.want {
+				want[member] = score
+			}
+			want["Alfred"] += 10
+			assertScores(t, lb, want)
+
+			if _, err := manager.CarryOver(ctx, "spring", points); !errors.Is(err, season.ErrAlreadyCarriedOver) {
+				t.Errorf("CarryOver(spring) again = %v, want ErrAlreadyCarriedOver", err)
+			}
+			if s, err := manager.Get(ctx, "spring"); err != nil || !s.CarriedOver {
+				t.Errorf("Get(spring) = %+v, %v, want carried over", s, err)
+			}
+			// the progress is deleted once the carry-over completes
+			if n, err := client.Exists(ctx, season.ProgressKey(key, "spring")).Result(); err != nil || n != 0 {
+				t.Errorf("EXISTS %s = %d, %v, want 0", season.ProgressKey(key, "spring"), n, err)
+			}
+			assertScores(t, lb.WithKey(season.ArchiveKey(key, "spring")), final)
+		})
+	}
+}
+
+func TestCarryOverTieBreakByTime(t *testing.T) {
+	ctx := context.Background()
+	manager, lb, _ := newManager(t, nil)
+	lb.TieBreakByTime = true
+	submit(t, lb, map[string]float64{"Alfred": 300, "Bob": 200})
+	if _, err := manager.Start(ctx, "spring", ""); err != nil {
+		t.Fatalf("Start(spring) failed: %v", err)
+	}
+	if _, err := manager.Start(ctx, "summer", ""); err != nil {
+		t.Fatalf("Start(summer) failed: %v", err)
+	}
+	submit(t, lb, map[string]float64{"Bob": 5})
+
+	if n, err := manager.CarryOver(ctx, "spring", []float64{20, 10}); err != nil || n != 2 {
+		t.Fatalf("CarryOver(spring) = %d, %v, want 2", n, err)
+	}
+	assertScores(t, lb, map[string]float64{"Alfred": 20, "Bob": 15})
+}
+
+func TestCarryOverResume(t *testing.T) {
+	ctx := context.Background()
+	manager, lb, client := newManager(t, map[string]float64{"Alfred": 300, "Bob": 200, "Cecil": 100})
+	if _, err := manager.Start(ctx, "spring", ""); err != nil {
+		t.Fatalf("Start(spring) failed: %v", err)
+	}
+	if _, err := manager.Start(ctx, "summer", ""); err != nil {
+		t.Fatalf("Start(summer) failed: %v", err)
+	}
+
+	// another CarryOver holds the lock
+	lock := season.ProgressKey(key, "spring") + ":lock"
+	if err := client.Set(ctx, lock, "other", time.Minute).Err(); err != nil {
+		t.Fatalf("SET %s failed: %v", lock, err)
+	}
+	if _, err := manager.CarryOver(ctx, "spring", []float64{100, 50, 25}); !errors.Is(err, season.ErrCarryOverInProgress) {
+		t.Errorf("CarryOver(spring) = %v, want ErrCarryOverInProgress", err)
+	}
+	assertScores(t, lb, map[string]float64{})
+
+	// the other CarryOver awarded Alfred and failed before awarding the others
+	if err := lb.Submit(ctx, "Alfred", 100); err != nil {
+		t.Fatalf("Submit(Alfred, 100) failed: %v", err)
+	}
+	err := client.XAdd(ctx, &redis.XAddArgs{
+		Stream: season.ProgressKey(key, "spring"),
+		Values: []any{"member", "Alfred", "mode", "incr", "value", 100, "delta", 100, "score", 100},
+	}).Err()
+	if err != nil {
+		t.Fatalf("XADD failed: %v", err)
+	}
+	if err := client.Del(ctx, lock).Err(); err != nil {
+		t.Fatalf("DEL %s failed: %v", lock, err)
+	}
+
+	// the resumed CarryOver awards only the remaining members
+	if n, err := manager.CarryOver(ctx, "spring", []float64{100, 50, 25}); err != nil || n != 3 {
+		t.Fatalf("CarryOver(spring) = %d, %v, want 3", n, err)
+	}
+	assertScores(t, lb, map[string]float64{"Alfred": 100, "Bob": 50, "Cecil": 25})
+	if n, err := client.Exists(ctx, lock).Result(); err != nil || n != 0 {
+		t.Errorf("EXISTS %s = %d, %v, want 0", lock, n, err)
+	}
+}
@@ -0,0 +1,395 @@
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+)
+
+const (
+	// DefaultPageSize is the default number of entries read from the leaderboard by a single page of Export.
+	DefaultPageSize = 1000
+	// DefaultBatchSize is the default number of entries added to the leaderboard by a single batch of Import.
+	DefaultBatchSize = 1000
+	// DefaultTTL is the default time to live of the temporary leaderboard of an import replacing the leaderboard.
+	DefaultTTL = time.Hour
+	// importSuffix is appended to the key of the leaderboard replaced by an import, followed by a random token,
+	// to get the key of the import.
+	importSuffix = ":import:"
+)
+
+// csvHeader is the header of the CSV snapshots.
+// The snapshots written before the times of achievement were exported lack the last column.
+var csvHeader = []string{"rank", "member", "score", "achieved"}
+
+// jsonEntry is an entry of a JSON Lines snapshot, Achieved is omitted without TieBreakByTime.
+type jsonEntry struct {
+	Rank     int64      `json:"rank"`
+	Member   string     `json:"member"`
+	Score    float64    `json:"score"`
+	Achieved *time.Time `json:"achieved,omitempty"`
+}
+
+// Format is the format of a snapshot.
+type Format int
+
+const (
+	// JSONLines stores every entry as a JSON object on its own line, see leaderboard.Entry,
+	// with the time of achievement of TieBreakByTime in RFC 3339 under "achieved".
+	JSONLines Format = iota
+	// CSV stores every entry as a CSV record with the columns rank, member, score and achieved after a header,
+	// achieved is empty without TieBreakByTime.
+	CSV
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case JSONLines:
+		return "jsonl"
+	case CSV:
+		return "csv"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ParseFormat returns the format with the given name, see Format.String.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range []Format{JSONLines, CSV} {
+		if format.String() == name {
+			return format, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid format: %q", name)
+}
+
+// Mode determines how an import changes the leaderboard.
+type Mode int
+
+const (
+	// Replace replaces the leaderboard with the snapshot.
+	Replace Mode = iota
+	// MergeMax merges the snapshot into the leaderboard, keeping the higher score of the members in both.
+	MergeMax
+	// MergeSum merges the snapshot into the leaderboard, summing the scores of the members in both.
+	MergeSum
+)
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	switch m {
+	case Replace:
+		return "replace"
+	case MergeMax:
+		return "max"
+	case MergeSum:
+		return "sum"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// ParseMode returns the mode with the given name, see Mode.String.
+func ParseMode(name string) (Mode, error) {
+	for _, mode := range []Mode{Replace, MergeMax, MergeSum} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid import mode: %q", name)
+}
+
+// Exporter writes snapshots of leaderboards.
+type Exporter struct {
+	// PageSize is the number of entries read by a single page, DefaultPageSize if zero.
+	PageSize int64
+}
+
+// Export writes the entries of the leaderboard in the format, best first, and returns their number.
+// The leaderboard is read in ranged pages, so the snapshot is not consistent if the leaderboard changes meanwhile.
+// The ranks are in the ranking mode of the leaderboard. With TieBreakByTime, the scores are exported
+// with their times of achievement, so an import restores the order of the members with equal score.
+func (e *Exporter) Export(ctx context.Context, lb *leaderboard.Leaderboard, w io.Writer, format Format) (int64, error) {
+	write, flush, err := newWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	var exported int64
+	for {
+		entries, err := lb.TimedPage(ctx, exported, e.pageSize())
+		if err != nil {
+			return exported, fmt.Errorf("failed to export %q: %w", lb.Key(), err)
+		}
+		for _, entry := range entries {
+			if err := write(entry); err != nil {
+				return exported, fmt.Errorf("failed to write entry of %q: %w", entry.Member, err)
+			}
+			exported++
+		}
+		if int64(len(entries)) < e.pageSize() {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return exported, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return exported, nil
+}
+
+// pageSize returns the number of entries read by a single page.
+func (e *Exporter) pageSize() int64 {
+	if e.PageSize > 0 {
+		return e.PageSize
+	}
+	return DefaultPageSize
+}
+
+// Importer loads snapshots into leaderboards.
+type Importer struct {
+	// BatchSize is the number of entries added by a single batch, DefaultBatchSize if zero.
+	BatchSize int
+	// TTL is the time to live of the temporary leaderboard of Replace, DefaultTTL if zero.
+	// It is set again after every batch, so a crashed import is deleted once no batch was loaded for TTL.
+	TTL time.Duration
+}
+
+// Import loads the snapshot in the format into the leaderboard in batches and returns the number of loaded entries.
+// The ranks in the snapshot are ignored. With TieBreakByTime, the scores are achieved at the times
+// of the snapshot, or now if the snapshot has none. Replace loads the snapshot into a temporary leaderboard
+// unique to the import first and then renames it over the leaderboard, so the leaderboard is replaced atomically
+// and kept if the import fails. The temporary leaderboard expires if the import crashes, see TTL. The merges are applied batch by batch, a failed merge leaves the leaderboard
+// partially merged.
+func (i *Importer) Import(ctx context.Context, lb *leaderboard.Leaderboard, r io.Reader, format Format, mode Mode) (int64, error) {
+	var aggregate leaderboard.Aggregate
+	var ttl time.Duration
+	target := lb
+	switch mode {
+	case Replace:
+		// the import key is derived from the key, so it keeps its hash tag,
+		// and the token keeps the concurrent imports of the same leaderboard apart
+		token, err := newToken()
+		if err != nil {
+			return 0, fmt.Errorf("failed to generate import token: %w", err)
+		}
+		target = lb.WithKey(lb.Key() + importSuffix + token)
+		ttl = i.ttl()
+	case MergeMax:
+		aggregate = leaderboard.AggregateMax
+	case MergeSum:
+		aggregate = leaderboard.AggregateSum
+	default:
+		return 0, fmt.Errorf("invalid import mode: %v", mode)
+	}
+
+	imported, err := i.load(ctx, target, r, format, aggregate, ttl)
+	if err != nil {
+		if mode == Replace {
+			_ = target.Clear(context.WithoutCancel(ctx))
+		}
+		return imported, err
+	}
+	if mode != Replace {
+		return imported, nil
+	}
+	// an empty snapshot creates no sorted set to rename
+	if imported == 0 {
+		return 0, lb.Clear(ctx)
+	}
+	// the renamed leaderboard would keep the time to live
+	if err := target.Persist(ctx); err != nil {
+		return 0, err
+	}
+	if _, err := target.Rename(ctx, lb.Key()); err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
+
+// load reads the snapshot and adds its entries to the leaderboard in batches,
+// setting the time to live of the leaderboard after every batch if ttl is positive.
+func (i *Importer) load(ctx context.Context, lb *leaderboard.Leaderboard, r io.Reader, format Format, aggregate leaderboard.Aggregate, ttl time.Duration) (int64, error) {
+	read, err := newReader(r, format)
+	if err != nil {
+		return 0, err
+	}
+
+	var imported int64
+	batch := make([]leaderboard.TimedEntry, 0, i.batchSize())
+	add := func() error {
+		if err := lb.AddManyTimed(ctx, batch, aggregate); err != nil {
+			return err
+		}
+		if ttl > 0 {
+			if err := lb.Expire(ctx, ttl); err != nil {
+				return err
+			}
+		}
+		imported += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		entry, err := read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("failed to read entry %d: %w", imported+int64(len(batch))+1, err)
+		}
+		batch = append(batch, entry)
+		if len(batch) == i.batchSize() {
+			if err := add(); err != nil {
+				return imported, err
+			}
+		}
+	}
+	if err := add(); err != nil {
+		return imported, err
+	}
+	return imported, nil
+}
+
+// batchSize returns the number of entries added by a single batch.
+func (i *Importer) batchSize() int {
+	if i.BatchSize > 0 {
+		return i.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// ttl returns the time to live of the temporary leaderboard of Replace.
+func (i *Importer) ttl() time.Duration {
+	if i.TTL > 0 {
+		return i.TTL
+	}
+	return DefaultTTL
+}
+
+// newWriter returns the functions writing an entry in the format and flushing the written entries.
+func newWriter(w io.Writer, format Format) (func(leaderboard.TimedEntry) error, func() error, error) {
+	switch format {
+	case JSONLines:
+		buffered := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buffered)
+		return func(entry leaderboard.TimedEntry) error {
+			line := jsonEntry{Rank: entry.Rank, Member: entry.Member, Score: entry.Score}
+			if !entry.Achieved.IsZero() {
+				line.Achieved = &entry.Achieved
+			}
+			return encoder.Encode(line)
+		}, buffered.Flush, nil
+	case CSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvHeader); err != nil {
+			return nil, nil, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		return func(entry leaderboard.TimedEntry) error {
+				var achieved string
+				if !entry.Achieved.IsZero() {
+					achieved = entry.Achieved.Format(time.RFC3339)
+				}
+				return writer.Write([]string{
+					strconv.FormatInt(entry.Rank, 10),
+					entry.Member,
+					strconv.FormatFloat(entry.Score, 'f', -1, 64),
+					achieved,
+				})
+			}, func() error {
+				writer.Flush()
+				return writer.Error()
+			}, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid format: %v", format)
+	}
+}
+
+// newReader returns the function reading the next entry in the format, io.EOF after the last entry.
+func newReader(r io.Reader, format Format) (func() (leaderboard.TimedEntry, error), error) {
+	switch format {
+	case JSONLines:
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		return func() (leaderboard.TimedEntry, error) {
+			var line jsonEntry
+			if err := decoder.Decode(&line); err != nil {
+				return leaderboard.TimedEntry{}, err
+			}
+			entry := leaderboard.TimedEntry{Entry: leaderboard.Entry{Member: line.Member, Score: line.Score}}
+			if line.Achieved != nil {
+				entry.Achieved = *line.Achieved
+			}
+			return entry, validateEntry(entry.Entry)
+		}, nil
+	case CSV:
+		reader := csv.NewReader(r)
+		// the number of columns is set by the header, the old snapshots have no achieved column
+		reader.FieldsPerRecord = 0
+		header, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return func() (leaderboard.TimedEntry, error) { return leaderboard.TimedEntry{}, io.EOF }, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		if len(header) < len(csvHeader)-1 || len(header) > len(csvHeader) {
+			return nil, fmt.Errorf("invalid CSV header: %q, want %q", header, csvHeader)
+		}
+		for i, column := range header {
+			if column != csvHeader[i] {
+				return nil, fmt.Errorf("invalid CSV header: %q, want %q", header, csvHeader)
+			}
+		}
+		return func() (leaderboard.TimedEntry, error) {
+			record, err := reader.Read()
+			if err != nil {
+				return leaderboard.TimedEntry{}, err
+			}
+			score, err := strconv.ParseFloat(record[2], 64)
+			if err != nil {
+				return leaderboard.TimedEntry{}, fmt.Errorf("invalid score: %q", record[2])
+			}
+			entry := leaderboard.TimedEntry{Entry: leaderboard.Entry{Member: record[1], Score: score}}
+			if len(record) > 3 && record[3] != "" {
+				if entry.Achieved, err = time.Parse(time.RFC3339, record[3]); err != nil {
+					return leaderboard.TimedEntry{}, fmt.Errorf("invalid time of achievement: %q", record[3])
+				}
+			}
+			return entry, validateEntry(entry.Entry)
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid format: %v", format)
+	}
+}
+
+// validateEntry returns an error if the entry cannot be added to a leaderboard.
+func validateEntry(entry leaderboard.Entry) error {
+	if entry.Member == "" {
+		return errors.New("member must not be empty")
+	}
+	if math.IsNaN(entry.Score) {
+		return errors.New("score must be a number")
+	}
+	return nil
+}
+
+// newToken returns a new random token of an import.
+func newToken() (string, error) {
+	var token [8]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token[:]), nil
+}
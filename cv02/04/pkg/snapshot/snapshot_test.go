@@ -0,0 +1,316 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/leaderboard"
+	"dpb-cv02-04/pkg/snapshot"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisAddrEnv is the environment variable with the address of a real redis to run the tests against,
+// the same as in the tests of the leaderboard package. The database of the address is flushed by the tests.
+const redisAddrEnv = "LEADERBOARD_TEST_REDIS_ADDR"
+
+// newBoard returns a leaderboard in a memory store with the scores.
+func newBoard(t *testing.T, scores map[string]float64) *leaderboard.Leaderboard {
+	t.Helper()
+	lb := leaderboard.New(leaderboard.NewMemoryStore(), "{scoreboard}")
+	for member, score := range scores {
+		if err := lb.Submit(context.Background(), member, score); err != nil {
+			t.Fatalf("Submit(%q, %v) failed: %v", member, score, err)
+		}
+	}
+	return lb
+}
+
+// assertEntries checks all the entries of the leaderboard with their times of achievement.
+func assertEntries(t *testing.T, lb *leaderboard.Leaderboard, want []leaderboard.TimedEntry) {
+	t.Helper()
+	entries, err := lb.TimedPage(context.Background(), 0, int64(len(want))+1)
+	if err != nil {
+		t.Fatalf("TimedPage() failed: %v", err)
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("TimedPage() = %v, want %v", entries, want)
+	}
+	for i, entry := range entries {
+		if entry.Entry != want[i].Entry || !entry.Achieved.Equal(want[i].Achieved) {
+			t.Errorf("TimedPage()[%d] = %v, want %v", i, entry, want[i])
+		}
+	}
+}
+
+// entry returns a timed entry.
+func entry(rank int64, member string, score float64, achieved time.Time) leaderboard.TimedEntry {
+	return leaderboard.TimedEntry{Entry: leaderboard.Entry{Rank: rank, Member: member, Score: score}, Achieved: achieved}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, format := range []snapshot.Format{snapshot.JSONLines, snapshot.CSV} {
+		t.Run(format.String(), func(t *testing.T) {
+			ctx := context.Background()
+			source := newBoard(t, nil)
+			source.TieBreakByTime = true
+			// Alfred achieves the score of Bob later, so Bob stays ahead despite the order of the names
+			for _, submit := range []struct {
+				member string
+				score  float64
+				at     time.Time
+			}{
+				{"Bob", 100, t0},
+				{"Alfred", 100, t0.Add(time.Hour)},
+				{"Cecil", 50, t0.Add(2 * time.Hour)},
+			} {
+				source.Now = func() time.Time { return submit.at }
+				if err := source.Submit(ctx, submit.member, submit.score); err != nil {
+					t.Fatalf("Submit(%q) failed: %v", submit.member, err)
+				}
+			}
+			want := []leaderboard.TimedEntry{
+				entry(1, "Bob", 100, t0),
+				entry(2, "Alfred", 100, t0.Add(time.Hour)),
+				entry(3, "Cecil", 50, t0.Add(2*time.Hour)),
+			}
+
+			var buf bytes.Buffer
+			exporter := &snapshot.Exporter{PageSize: 2}
+			if n, err := exporter.Export(ctx, source, &buf, format); err != nil || n != 3 {
+				t.Fatalf("Export() = %d, %v, want 3", n, err)
+			}
+			target := newBoard(t, map[string]float64{"Dave": 10})
+			target.TieBreakByTime = true
+			importer := &snapshot.Importer{BatchSize: 2}
+			if n, err := importer.Import(ctx, target, &buf, format, snapshot.Replace); err != nil || n != 3 {
+				t.Fatalf("Import() = %d, %v, want 3", n, err)
+			}
+			assertEntries(t, target, want)
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	lb := newBoard(t, map[string]float64{"Alfred": 100, "Bob": 50.5})
+	tests := []struct {
+		format snapshot.Format
+		want   string
+	}{
+		{snapshot.JSONLines, `{"rank":1,"member":"Alfred","score":100}` + "\n" + `{"rank":2,"member":"Bob","score":50.5}` + "\n"},
+		{snapshot.CSV, "rank,member,score,achieved\n1,Alfred,100,\n2,Bob,50.5,\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if _, err := (&snapshot.Exporter{}).Export(context.Background(), lb, &buf, test.format); err != nil {
+			t.Fatalf("Export(%v) failed: %v", test.format, err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("Export(%v) = %q, want %q", test.format, buf.String(), test.want)
+		}
+	}
+}
+
+func TestImportCSVHeaders(t *testing.T) {
+	achieved := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		csv     string
+		want    []leaderboard.TimedEntry
+		wantErr bool
+	}{
+		{
+			// the snapshots written before the times of achievement were exported
+			name: "three columns",
+			csv:  "rank,member,score\n1,Alfred,100\n2,Bob,50\n",
+			want: []leaderboard.TimedEntry{entry(1, "Alfred", 100, time.Time{}), entry(2, "Bob", 50, time.Time{})},
+		},
+		{
+			name: "four columns",
+			csv:  "rank,member,score,achieved\n1,Alfred,100,2024-05-01T12:00:00Z\n2,Bob,50,\n",
+			want: []leaderboard.TimedEntry{entry(1, "Alfred", 100, achieved), entry(2, "Bob", 50, time.Time{})},
+		},
+		{name: "two columns", csv: "rank,member\n1,Alfred\n", wantErr: true},
+		{name: "five columns", csv: "rank,member,score,achieved,level\n1,Alfred,100,,3\n", wantErr: true},
+		{name: "renamed column", csv: "rank,name,score\n1,Alfred,100\n", wantErr: true},
+		{name: "missing column", csv: "rank,member,score\n1,Alfred\n", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lb := newBoard(t, nil)
+			_, err := (&snapshot.Importer{}).Import(context.Background(), lb, strings.NewReader(test.csv), snapshot.CSV, snapshot.Replace)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Import() = %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr {
+				// the times of achievement are only kept with TieBreakByTime
+				for i := range test.want {
+					test.want[i].Achieved = time.Time{}
+				}
+				assertEntries(t, lb, test.want)
+			}
+		})
+	}
+}
+
+func TestImportModes(t *testing.T) {
+	const jsonl = `{"rank":1,"member":"Alfred","score":80}` + "\n" + `{"rank":2,"member":"Cecil","score":10}` + "\n"
+	tests := []struct {
+		mode snapshot.Mode
+		want []leaderboard.TimedEntry
+	}{
+		{snapshot.Replace, []leaderboard.TimedEntry{
+			entry(1, "Alfred", 80, time.Time{}),
+			entry(2, "Cecil", 10, time.Time{}),
+		}},
+		{snapshot.MergeMax, []leaderboard.TimedEntry{
+			entry(1, "Alfred", 100, time.Time{}),
+			entry(2, "Bob", 50, time.Time{}),
+			entry(3, "Cecil", 10, time.Time{}),
+		}},
+		{snapshot.MergeSum, []leaderboard.TimedEntry{
+			entry(1, "Alfred", 180, time.Time{}),
+			entry(2, "Bob", 50, time.Time{}),
+			entry(3, "Cecil", 10, time.Time{}),
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			lb := newBoard(t, map[string]float64{"Alfred": 100, "Bob": 50})
+			n, err := (&snapshot.Importer{}).Import(context.Background(), lb, strings.NewReader(jsonl), snapshot.JSONLines, test.mode)
+			if err != nil || n != 2 {
+				t.Fatalf("Import() = %d, %v, want 2", n, err)
+			}
+			assertEntries(t, lb, test.want)
+		})
+	}
+}
+
+func TestImportMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   snapshot.Format
+		snapshot string
+	}{
+		{"invalid JSON", snapshot.JSONLines, `{"member":"Dave","score":1}` + "\n" + `{"member":"Eve","score":` + "\n"},
+		{"unknown field", snapshot.JSONLines, `{"member":"Dave","score":1}` + "\n" + `{"member":"Eve","score":2,"level":3}` + "\n"},
+		{"empty member", snapshot.JSONLines, `{"member":"Dave","score":1}` + "\n" + `{"member":"","score":2}` + "\n"},
+		{"invalid score", snapshot.CSV, "rank,member,score\n1,Dave,1\n2,Eve,many\n"},
+		{"NaN score", snapshot.CSV, "rank,member,score\n1,Dave,1\n2,Eve,NaN\n"},
+		{"invalid time", snapshot.CSV, "rank,member,score,achieved\n1,Dave,1,\n2,Eve,2,yesterday\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the first entry is loaded by the first batch before the malformed entry is read
+			importer := &snapshot.Importer{BatchSize: 1}
+			for _, mode := range []snapshot.Mode{snapshot.Replace, snapshot.MergeMax} {
+				lb := newBoard(t, map[string]float64{"Alfred": 100, "Bob": 50})
+				if _, err := importer.Import(context.Background(), lb, strings.NewReader(test.snapshot), test.format, mode); err == nil {
+					t.Fatalf("Import(%v) succeeded, want error", mode)
+				}
+				if mode == snapshot.Replace {
+					// the replaced leaderboard is kept
+					assertEntries(t, lb, []leaderboard.TimedEntry{
+						entry(1, "Alfred", 100, time.Time{}),
+						entry(2, "Bob", 50, time.Time{}),
+					})
+				}
+			}
+		})
+	}
+}
+
+func TestImportEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   snapshot.Format
+		snapshot string
+	}{
+		{"jsonl", snapshot.JSONLines, ""},
+		{"csv", snapshot.CSV, ""},
+		{"csv header", snapshot.CSV, "rank,member,score,achieved\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lb := newBoard(t, map[string]float64{"Alfred": 100})
+			n, err := (&snapshot.Importer{}).Import(context.Background(), lb, strings.NewReader(test.snapshot), test.format, snapshot.MergeMax)
+			if err != nil || n != 0 {
+				t.Fatalf("Import(max) = %d, %v, want 0", n, err)
+			}
+			assertEntries(t, lb, []leaderboard.TimedEntry{entry(1, "Alfred", 100, time.Time{})})
+
+			// an empty snapshot replaces the leaderboard with an empty one
+			n, err = (&snapshot.Importer{}).Import(context.Background(), lb, strings.NewReader(test.snapshot), test.format, snapshot.Replace)
+			if err != nil || n != 0 {
+				t.Fatalf("Import(replace) = %d, %v, want 0", n, err)
+			}
+			assertEntries(t, lb, nil)
+		})
+	}
+}
+
+// readerFunc calls fn once when it is read and then reports the end of its data.
+type readerFunc func()
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	f()
+	return 0, io.EOF
+}
+
+// TestImportTTL checks the time to live of the temporary leaderboard of an import against a real redis.
+func TestImportTTL(t *testing.T) {
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skip(redisAddrEnv + " is not set")
+	}
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("FLUSHDB failed: %v", err)
+	}
+	lb := leaderboard.New(leaderboard.NewRedisStore(client), "{scoreboard}")
+	if err := lb.Submit(ctx, "Alfred", 100); err != nil {
+		t.Fatalf("Submit(Alfred) failed: %v", err)
+	}
+
+	// the snapshot is inspected after the first batch was loaded
+	inspected := false
+	inspect := readerFunc(func() {
+		inspected = true
+		keys, err := client.Keys(ctx, "{scoreboard}:import:*").Result()
+		if err != nil || len(keys) != 1 {
+			t.Fatalf("KEYS = %v, %v, want the import key", keys, err)
+		}
+		ttl, err := client.TTL(ctx, keys[0]).Result()
+		if err != nil || ttl <= 0 || ttl > time.Minute {
+			t.Errorf("TTL %s = %v, %v, want at most a minute", keys[0], ttl, err)
+		}
+	})
+	r := io.MultiReader(
+		strings.NewReader(`{"member":"Bob","score":10}`+"\n"),
+		inspect,
+		strings.NewReader(`{"member":"Cecil","score":20}`+"\n"),
+	)
+	importer := &snapshot.Importer{BatchSize: 1, TTL: time.Minute}
+	if n, err := importer.Import(ctx, lb, r, snapshot.JSONLines, snapshot.Replace); err != nil || n != 2 {
+		t.Fatalf("Import() = %d, %v, want 2", n, err)
+	}
+	if !inspected {
+		t.Errorf("the import was not inspected")
+	}
+	// the replaced leaderboard does not expire
+	if ttl, err := client.TTL(ctx, lb.Key()).Result(); err != nil || ttl != -1 {
+		t.Errorf("TTL %s = %v, %v, want -1", lb.Key(), ttl, err)
+	}
+	if keys, err := client.Keys(ctx, "{scoreboard}:import:*").Result(); err != nil || len(keys) != 0 {
+		t.Errorf("KEYS = %v, %v, want no import keys", keys, err)
+	}
+	assertEntries(t, lb, []leaderboard.TimedEntry{entry(1, "Cecil", 20, time.Time{}), entry(2, "Bob", 10, time.Time{})})
+}
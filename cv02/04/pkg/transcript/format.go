@@ -0,0 +1,149 @@
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// statusCommands are the commands replying with a status, printed unquoted by redis-cli.
+// go-redis returns statuses as strings, so they are told apart from bulk strings by the command.
+var statusCommands = map[string]bool{
+	"AUTH": true, "BGREWRITEAOF": true, "BGSAVE": true, "DISCARD": true, "FLUSHALL": true, "FLUSHDB": true,
+	"HMSET": true, "LSET": true, "LTRIM": true, "MIGRATE": true, "MSET": true, "MULTI": true, "PFMERGE": true,
+	"PING": true, "RENAME": true, "RESTORE": true, "SAVE": true, "SELECT": true, "SET": true, "SETEX": true,
+	"PSETEX": true, "SWAPDB": true, "TYPE": true, "UNWATCH": true, "WATCH": true, "XGROUP": true,
+}
+
+// FormatReply formats the reply of the command or its error the way redis-cli prints it and returns its lines.
+func FormatReply(command Command, reply any, err error) []string {
+	var redisErr redis.Error
+	switch {
+	case errors.Is(err, redis.Nil):
+		return []string{"(nil)"}
+	case errors.As(err, &redisErr):
+		return []string{"(error) " + redisErr.Error()}
+	case err != nil:
+		return []string{"(error) " + err.Error()}
+	}
+
+	// a status is a string reply of a status command, except SET with GET replying with the old value
+	if s, ok := reply.(string); ok && statusCommands[command.Name()] && !hasArg(command, "GET") {
+		return []string{s}
+	}
+	return formatValue(reply)
+}
+
+// formatValue formats the value of a reply and returns its lines.
+func formatValue(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return []string{"(nil)"}
+	case string:
+		return []string{repr(v)}
+	case int64:
+		return []string{fmt.Sprintf("(integer) %d", v)}
+	case float64:
+		return []string{"(double) " + strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		return []string{fmt.Sprintf("(%t)", v)}
+	case error:
+		return []string{"(error) " + v.Error()}
+	case []any:
+		if len(v) == 0 {
+			return []string{"(empty array)"}
+		}
+		width := len(strconv.Itoa(len(v)))
+		var lines []string
+		for i, element := range v {
+			lines = appendNested(lines, fmt.Sprintf("%*d) ", width, i+1), formatValue(element))
+		}
+		return lines
+	case map[any]any:
+		if len(v) == 0 {
+			return []string{"(empty hash)"}
+		}
+		// the map has no order, so the entries are sorted by their keys
+		entries := make([][2][]string, 0, len(v))
+		for key, element := range v {
+			entries = append(entries, [2][]string{formatValue(key), formatValue(element)})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return strings.Join(entries[i][0], "\n") < strings.Join(entries[j][0], "\n")
+		})
+		width := len(strconv.Itoa(len(entries)))
+		var lines []string
+		for i, entry := range entries {
+			key := strings.Join(entry[0], " ")
+			lines = appendNested(lines, fmt.Sprintf("%*d# %s => ", width, i+1, key), entry[1])
+		}
+		return lines
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// appendNested appends the lines of a nested value, the first line after the prefix and the others aligned with it.
+func appendNested(lines []string, prefix string, nested []string) []string {
+	lines = append(lines, prefix+nested[0])
+	indent := strings.Repeat(" ", len(prefix))
+	for _, line := range nested[1:] {
+		lines = append(lines, indent+line)
+	}
+	return lines
+}
+
+// repr returns the string quoted and escaped the way redis-cli prints it.
+func repr(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		default:
+			if c < ' ' || c > '~' {
+				fmt.Fprintf(&b, `\x%02x`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isPrintable returns true if the string contains only printable ASCII characters.
+func isPrintable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// hasArg returns true if the command has the argument, ignoring case.
+func hasArg(command Command, arg string) bool {
+	for _, a := range command.Args[1:] {
+		if strings.EqualFold(a, arg) {
+			return true
+		}
+	}
+	return false
+}
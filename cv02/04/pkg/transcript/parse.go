@@ -0,0 +1,178 @@
+package transcript
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ExpectPrefix starts a line of the expected reply of the preceding command.
+	ExpectPrefix = "#>"
+	// UnorderedPrefix starts a line of the expected reply of the preceding command, compared regardless of the order.
+	UnorderedPrefix = "#~"
+)
+
+// Command is a single command of a transcript.
+type Command struct {
+	// Line is the line number of the command in the transcript.
+	Line int
+	// Args are the name and the arguments of the command.
+	Args []string
+	// Expected are the lines of the expected reply, nil if the reply is not checked.
+	Expected []string
+	// Unordered compares the lines of the reply regardless of their order, e.g. for KEYS.
+	Unordered bool
+}
+
+// Name returns the upper case name of the command.
+func (c Command) Name() string {
+	return strings.ToUpper(c.Args[0])
+}
+
+// String returns the command as it is written in a transcript.
+func (c Command) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = quoteArg(arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// Parse parses a transcript of redis-cli commands, one command per line.
+// Blank lines and comments starting with # are skipped. The arguments are split like redis-cli does,
+// double quoted arguments may contain escape sequences like \n, \" or \x41, single quoted arguments only \'.
+// The lines starting with ExpectPrefix or UnorderedPrefix following a command are the lines of its expected reply.
+func Parse(r io.Reader) ([]Command, error) {
+	var commands []Command
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		prefix, unordered := ExpectPrefix, false
+		if strings.HasPrefix(line, UnorderedPrefix) {
+			prefix, unordered = UnorderedPrefix, true
+		}
+		if strings.HasPrefix(line, prefix) {
+			if len(commands) == 0 {
+				return nil, fmt.Errorf("line %d: expected reply without a command", lineNumber)
+			}
+			command := &commands[len(commands)-1]
+			if command.Expected != nil && command.Unordered != unordered {
+				return nil, fmt.Errorf("line %d: mixed ordered and unordered expected reply", lineNumber)
+			}
+			command.Expected = append(command.Expected, strings.TrimPrefix(strings.TrimPrefix(line, prefix), " "))
+			command.Unordered = unordered
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := SplitArgs(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		commands = append(commands, Command{Line: lineNumber, Args: args})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return commands, nil
+}
+
+// SplitArgs splits the line into arguments the way redis-cli does.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		var arg strings.Builder
+		switch line[i] {
+		case '"':
+			i++
+			for {
+				if i >= len(line) {
+					return nil, errors.New("unterminated double quoted argument")
+				}
+				if line[i] == '"' {
+					i++
+					break
+				}
+				if line[i] != '\\' || i+1 >= len(line) {
+					arg.WriteByte(line[i])
+					i++
+					continue
+				}
+				if line[i+1] == 'x' && i+3 < len(line) {
+					if b, err := strconv.ParseUint(line[i+2:i+4], 16, 8); err == nil {
+						arg.WriteByte(byte(b))
+						i += 4
+						continue
+					}
+				}
+				arg.WriteByte(unescape(line[i+1]))
+				i += 2
+			}
+		case '\'':
+			i++
+			for {
+				if i >= len(line) {
+					return nil, errors.New("unterminated single quoted argument")
+				}
+				if line[i] == '\'' {
+					i++
+					break
+				}
+				if line[i] == '\\' && i+1 < len(line) && line[i+1] == '\'' {
+					i++
+				}
+				arg.WriteByte(line[i])
+				i++
+			}
+		default:
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				arg.WriteByte(line[i])
+				i++
+			}
+		}
+		// a closing quote must be followed by a space, e.g. "foo"bar is invalid
+		if i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			return nil, fmt.Errorf("closing quote must be followed by a space at column %d", i+1)
+		}
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// unescape returns the character escaped by a backslash in a double quoted argument.
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return c
+	}
+}
+
+// quoteArg returns the argument quoted if it cannot be written as a plain word.
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") && isPrintable(arg) {
+		return arg
+	}
+	return repr(arg)
+}
@@ -0,0 +1,114 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrFlushNotAllowed is returned when a transcript flushes the databases but Runner.AllowFlush is not set.
+var ErrFlushNotAllowed = errors.New("flushing the databases is not allowed")
+
+// Result is the result of running a transcript.
+type Result struct {
+	// Commands is the number of executed commands.
+	Commands int
+	// Checked is the number of commands with an expected reply.
+	Checked int
+	// Failed is the number of commands whose reply differs from the expected reply.
+	Failed int
+}
+
+// Runner executes transcripts with a redis client and checks their expected replies.
+type Runner struct {
+	// AllowFlush allows the transcripts to run FLUSHALL and FLUSHDB, which delete the keys of everyone using the server.
+	AllowFlush bool
+
+	client redis.UniversalClient
+	out    io.Writer
+}
+
+// NewRunner returns a new Runner executing the commands with the client and printing them with their replies to out.
+// The client should use RESP2, which is the protocol of redis-cli, so the replies are printed the same.
+func NewRunner(client redis.UniversalClient, out io.Writer) *Runner {
+	return &Runner{
+		client: client,
+		out:    out,
+	}
+}
+
+// Run executes the commands in order with Do, prints every command and its reply,
+// and reports the commands whose reply differs from the expected reply.
+// The errors replied by redis are replies, Run returns an error only if a command cannot be executed at all.
+// Returns ErrFlushNotAllowed before executing any command if a command flushes the databases without AllowFlush.
+func (r *Runner) Run(ctx context.Context, name string, commands []Command) (Result, error) {
+	if !r.AllowFlush {
+		for _, command := range commands {
+			if command.Name() == "FLUSHALL" || command.Name() == "FLUSHDB" {
+				return Result{}, fmt.Errorf("%s:%d: %w", name, command.Line, ErrFlushNotAllowed)
+			}
+		}
+	}
+
+	var result Result
+	for _, command := range commands {
+		args := make([]any, len(command.Args))
+		for i, arg := range command.Args {
+			args[i] = arg
+		}
+		reply, err := r.client.Do(ctx, args...).Result()
+		var redisErr redis.Error
+		if err != nil && !errors.Is(err, redis.Nil) && !errors.As(err, &redisErr) {
+			return result, fmt.Errorf("%s:%d: failed to execute %s: %w", name, command.Line, command.Name(), err)
+		}
+		result.Commands++
+
+		lines := FormatReply(command, reply, err)
+		fmt.Fprintf(r.out, "> %s\n", command)
+		for _, line := range lines {
+			fmt.Fprintln(r.out, line)
+		}
+
+		if command.Expected == nil {
+			continue
+		}
+		result.Checked++
+		if !matches(command, lines) {
+			result.Failed++
+			fmt.Fprintf(r.out, "%s:%d: unexpected reply of %s, expected:\n", name, command.Line, command.Name())
+			for _, line := range command.Expected {
+				fmt.Fprintln(r.out, line)
+			}
+		}
+	}
+	return result, nil
+}
+
+// matches returns true if the lines of the reply are the expected lines of the command.
+func matches(command Command, lines []string) bool {
+	if !command.Unordered {
+		return slices.Equal(lines, command.Expected)
+	}
+	// the indexes of the array elements change with the order, so only the elements are compared
+	got, expected := stripIndexes(lines), stripIndexes(command.Expected)
+	slices.Sort(got)
+	slices.Sort(expected)
+	return slices.Equal(got, expected)
+}
+
+// stripIndexes returns a copy of the lines without the leading array indexes like "1) ".
+func stripIndexes(lines []string) []string {
+	stripped := make([]string, len(lines))
+	for i, line := range lines {
+		if _, element, ok := strings.Cut(line, ") "); ok && strings.Trim(line[:len(line)-len(element)-2], " 0123456789") == "" {
+			line = element
+		}
+		stripped[i] = line
+	}
+	return stripped
+}
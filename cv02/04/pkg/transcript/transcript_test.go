@@ -0,0 +1,161 @@
+package transcript_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dpb-cv02-04/pkg/resptest"
+	"dpb-cv02-04/pkg/transcript"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newClient returns a RESP2 client of a new resptest server, the protocol of redis-cli.
+func newClient(t *testing.T) *redis.Client {
+	t.Helper()
+	server, err := resptest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), Protocol: 2})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// parse parses the transcript or fails the test.
+func parse(t *testing.T, s string) []transcript.Command {
+	t.Helper()
+	commands, err := transcript.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	return commands
+}
+
+// TestTranscripts runs the annotated transcripts of the exercises of cv02 against resptest.
+func TestTranscripts(t *testing.T) {
+	names, err := filepath.Glob("../../../0[1-3].annotated.txt")
+	if err != nil || len(names) != 3 {
+		t.Fatalf("Glob() = %v, %v, want the 3 annotated transcripts", names, err)
+	}
+	for _, name := range names {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatalf("ReadFile() failed: %v", err)
+			}
+			commands := parse(t, string(data))
+
+			var out bytes.Buffer
+			runner := transcript.NewRunner(newClient(t), &out)
+			runner.AllowFlush = true
+			result, err := runner.Run(context.Background(), name, commands)
+			if err != nil {
+				t.Fatalf("Run() failed: %v", err)
+			}
+			if result.Commands != len(commands) || result.Checked == 0 || result.Failed != 0 {
+				t.Errorf("Run() = %+v, want all %d commands run with no failures, output:\n%s", result, len(commands), out.String())
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	commands := parse(t, `
+SET fruit apple
+#> OK
+GET fruit
+#> "pear"
+RPUSH basket apple pear
+LRANGE basket 0 -1
+#~ 1) "pear"
+#~ 2) "apple"
+GET fruit extra
+#> (error) ERR wrong number of arguments for 'get' command
+`)
+	var out bytes.Buffer
+	result, err := transcript.NewRunner(newClient(t), &out).Run(context.Background(), "test", commands)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if want := (transcript.Result{Commands: 5, Checked: 4, Failed: 1}); result != want {
+		t.Errorf("Run() = %+v, want %+v, output:\n%s", result, want, out.String())
+	}
+	for _, line := range []string{"> GET fruit\n\"apple\"\n", "test:4: unexpected reply of GET, expected:\n\"pear\"\n"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output = %q, want %q", out.String(), line)
+		}
+	}
+}
+
+func TestRunFlushNotAllowed(t *testing.T) {
+	client := newClient(t)
+	commands := parse(t, "SET fruit apple\nFLUSHDB\n")
+	var out bytes.Buffer
+	if _, err := transcript.NewRunner(client, &out).Run(context.Background(), "test", commands); !errors.Is(err, transcript.ErrFlushNotAllowed) {
+		t.Fatalf("Run() = %v, want ErrFlushNotAllowed", err)
+	}
+	// no command is executed
+	if n, err := client.Exists(context.Background(), "fruit").Result(); err != nil || n != 0 || out.Len() != 0 {
+		t.Errorf("EXISTS fruit = %d, %v with output %q, want nothing executed", n, err, out.String())
+	}
+}
+
+func TestParse(t *testing.T) {
+	commands := parse(t, `# a comment
+
+SET "a key" 'it\'s' "\x41\n"
+#> OK
+  KEYS *
+#~ 1) "a key"
+#~2) "b"
+DEL b
+GET b
+#> (nil)
+#>
+`)
+	want := []transcript.Command{
+		{Line: 3, Args: []string{"SET", "a key", "it's", "A\n"}, Expected: []string{"OK"}},
+		{Line: 5, Args: []string{"KEYS", "*"}, Expected: []string{`1) "a key"`, `2) "b"`}, Unordered: true},
+		{Line: 8, Args: []string{"DEL", "b"}},
+		{Line: 9, Args: []string{"GET", "b"}, Expected: []string{"(nil)", ""}},
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("Parse() = %#v, want %#v", commands, want)
+	}
+	// the commands are written back in a parsable form
+	if got := commands[0].String(); got != `SET "a key" "it's" "A\n"` {
+		t.Errorf("String() = %s, want the quoted arguments", got)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		want       string
+	}{
+		{"expected reply without a command", "#> OK\nSET a b\n", "line 1: expected reply without a command"},
+		{"unordered reply without a command", "# comment\n#~ 1) \"a\"\n", "line 2: expected reply without a command"},
+		{"ordered after unordered", "KEYS *\n#~ 1) \"a\"\n#> 2) \"b\"\n", "line 3: mixed ordered and unordered expected reply"},
+		{"unordered after ordered", "KEYS *\n#> 1) \"a\"\n#~ 2) \"b\"\n", "line 3: mixed ordered and unordered expected reply"},
+		{"unterminated double quote", "SET a \"b\n", "line 1: unterminated double quoted argument"},
+		{"unterminated single quote", "SET a 'b\n", "line 1: unterminated single quoted argument"},
+		{"text after closing quote", "GET a\nSET a \"b\"c\n", "line 2: closing quote must be followed by a space at column 10"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commands, err := transcript.Parse(strings.NewReader(test.transcript))
+			if err == nil || err.Error() != test.want {
+				t.Errorf("Parse() = %v, %v, want error %q", commands, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package workqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dpb-cv02-04/pkg/keyspace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultVisibilityTimeout is the default time a worker has to acknowledge an item before it is requeued.
+	DefaultVisibilityTimeout = 30 * time.Second
+	// DefaultMaxAttempts is the default number of attempts to process an item before it is dead-lettered.
+	DefaultMaxAttempts = 5
+)
+
+var (
+	// ErrEmpty is returned by Claim when no item arrived within the timeout.
+	ErrEmpty = errors.New("queue is empty")
+	// ErrClaimLost is returned when the item is no longer claimed by the worker,
+	// because the reaper requeued it after the visibility timeout.
+	ErrClaimLost = errors.New("claim of the item was lost")
+)
+
+// Item is an item of the queue.
+type Item struct {
+	// ID identifies the item.
+	ID string `json:"id"`
+	// Payload is the content of the item.
+	Payload string `json:"payload"`
+	// Attempts is the number of failed attempts to process the item.
+	Attempts int `json:"attempts"`
+
+	raw    string
+	worker string
+}
+
+// Queue is a reliable queue stored in redis lists, modelled on the todolist of cv02/02.txt.
+// A worker claims an item by moving it from the pending list to its own processing list with BLMOVE,
+// so an item claimed by a crashed worker is not lost, and acknowledges it by removing it from there.
+// Items not acknowledged within the visibility timeout are requeued by Reap, the items failing MaxAttempts times
+// are moved to the dead-letter list. All keys share the hash tag of the name, so the queue works with a cluster.
+type Queue struct {
+	// VisibilityTimeout is the time a worker has to acknowledge a claimed item, DefaultVisibilityTimeout if zero.
+	VisibilityTimeout time.Duration
+	// MaxAttempts is the number of attempts before an item is dead-lettered, DefaultMaxAttempts if zero.
+	MaxAttempts int
+	// Now returns the current time used for the visibility timeout, time.Now if nil.
+	Now func() time.Time
+
+	client redis.UniversalClient
+	base   string
+}
+
+// New returns a new Queue stored under keys derived from the name.
+func New(client redis.UniversalClient, name string) *Queue {
+	return &Queue{
+		client: client,
+		base:   keyspace.HashTag(name),
+	}
+}
+
+// PendingKey returns the key of the list of items waiting to be claimed.
+func (q *Queue) PendingKey() string {
+	return q.base
+}
+
+// ProcessingKey returns the key of the list of items claimed by the worker.
+func (q *Queue) ProcessingKey(worker string) string {
+	return q.base + ":processing:" + worker
+}
+
+// DeadKey returns the key of the dead-letter list.
+func (q *Queue) DeadKey() string {
+	return q.base + ":dead"
+}
+
+// deadlinesKey returns the key of the sorted set of the claimed items scored by their visibility deadline.
+func (q *Queue) deadlinesKey() string {
+	return q.base + ":deadlines"
+}
+
+// workersKey returns the key of the set of the workers whose processing lists are reaped.
+func (q *Queue) workersKey() string {
+	return q.base + ":workers"
+}
+
+// Push appends the payloads to the queue as new items and returns them.
+func (q *Queue) Push(ctx context.Context, payloads ...string) ([]Item, error) {
+	if len(payloads) == 0 {
+		return nil, nil
+	}
+	items := make([]Item, len(payloads))
+	values := make([]any, len(payloads))
+	for i, payload := range payloads {
+		id, err := newID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate item ID: %w", err)
+		}
+		items[i] = Item{ID: id, Payload: payload}
+		raw, err := json.Marshal(items[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode item: %w", err)
+		}
+		items[i].raw = string(raw)
+		values[i] = items[i].raw
+	}
+	if err := q.client.RPush(ctx, q.PendingKey(), values...).Err(); err != nil {
+		return nil, fmt.Errorf("failed to push %d items: %w", len(items), err)
+	}
+	return items, nil
+}
+
+// Register adds the worker to the workers whose processing lists are reaped. Claim registers the worker too.
+func (q *Queue) Register(ctx context.Context, worker string) error {
+	if err := q.client.SAdd(ctx, q.workersKey(), worker).Err(); err != nil {
+		return fmt.Errorf("failed to register worker %q: %w", worker, err)
+	}
+	return nil
+}
+
+// Unregister removes the worker from the reaped workers if its processing list is empty.
+// It returns false if the worker still has claimed items, which are left to the reaper.
+func (q *Queue) Unregister(ctx context.Context, worker string) (bool, error) {
+	removed, err := unregisterScript.Run(ctx, q.client, []string{q.ProcessingKey(worker), q.workersKey()}, worker).Bool()
+	if err != nil {
+		return false, fmt.Errorf("failed to unregister worker %q: %w", worker, err)
+	}
+	return removed, nil
+}
+
+// Claim moves the oldest pending item to the processing list of the worker with BLMOVE and returns it,
+// waiting at most timeout for an item to arrive. Returns ErrEmpty if no item arrived.
+// The item must be acknowledged by Ack or released by Retry within the visibility timeout.
+func (q *Queue) Claim(ctx context.Context, worker string, timeout time.Duration) (Item, error) {
+	if err := q.Register(ctx, worker); err != nil {
+		return Item{}, err
+	}
+	raw, err := q.client.BLMove(ctx, q.PendingKey(), q.ProcessingKey(worker), "LEFT", "RIGHT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return Item{}, ErrEmpty
+	}
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to claim item: %w", err)
+	}
+
+	// the reaper sets the deadline itself if the worker fails before setting it
+	deadline := q.now().Add(q.visibilityTimeout()).UnixMilli()
+	if err := q.client.ZAddNX(ctx, q.deadlinesKey(), redis.Z{Score: float64(deadline), Member: raw}).Err(); err != nil {
+		return Item{}, fmt.Errorf("failed to set visibility deadline: %w", err)
+	}
+	item, err := parseItem(raw)
+	if err != nil {
+		return Item{}, err
+	}
+	item.worker = worker
+	return item, nil
+}
+
+// Ack acknowledges that the claimed item was processed and removes it from the queue.
+// Returns ErrClaimLost if the item was requeued after the visibility timeout, it is then processed again.
+func (q *Queue) Ack(ctx context.Context, item Item) error {
+	acked, err := ackScript.Run(ctx, q.client, []string{q.ProcessingKey(item.worker), q.deadlinesKey()}, item.raw).Bool()
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge item %s: %w", item.ID, err)
+	}
+	if !acked {
+		return fmt.Errorf("failed to acknowledge item %s: %w", item.ID, ErrClaimLost)
+	}
+	return nil
+}
+
+// Retry releases the claimed item after a failed attempt, requeuing it at the end of the queue
+// or moving it to the dead-letter list if it reached MaxAttempts. It returns true if the item was dead-lettered.
+// Returns ErrClaimLost if the item was already requeued after the visibility timeout.
+func (q *Queue) Retry(ctx context.Context, item Item) (bool, error) {
+	keys := []string{q.ProcessingKey(item.worker), q.deadlinesKey(), q.PendingKey(), q.DeadKey()}
+	status, err := retryScript.Run(ctx, q.client, keys, item.raw, q.maxAttempts()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to retry item %s: %w", item.ID, err)
+	}
+	if status < 0 {
+		return false, fmt.Errorf("failed to retry item %s: %w", item.ID, ErrClaimLost)
+	}
+	return status == 1, nil
+}
+
+// Reap requeues the items of all workers not acknowledged within the visibility timeout, counting a failed attempt,
+// and returns the numbers of the requeued and the dead-lettered items.
+// Items claimed without a deadline, because the worker failed right after claiming them, get one now.
+func (q *Queue) Reap(ctx context.Context) (requeued, dead int64, err error) {
+	workers, err := q.client.SMembers(ctx, q.workersKey()).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get workers: %w", err)
+	}
+	now := q.now()
+	for _, worker := range workers {
+		keys := []string{q.ProcessingKey(worker), q.deadlinesKey(), q.PendingKey(), q.DeadKey()}
+		args := []any{now.UnixMilli(), q.visibilityTimeout().Milliseconds(), q.maxAttempts()}
+		counts, err := reapScript.Run(ctx, q.client, keys, args...).Int64Slice()
+		if err != nil {
+			return requeued, dead, fmt.Errorf("failed to reap items of worker %q: %w", worker, err)
+		}
+		requeued += counts[0]
+		dead += counts[1]
+	}
+	return requeued, dead, nil
+}
+
+// Len returns the number of pending items.
+func (q *Queue) Len(ctx context.Context) (int64, error) {
+	n, err := q.client.LLen(ctx, q.PendingKey()).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get length of the queue: %w", err)
+	}
+	return n, nil
+}
+
+// DeadLetters returns the dead-lettered items, the oldest first.
+func (q *Queue) DeadLetters(ctx context.Context) ([]Item, error) {
+	raws, err := q.client.LRange(ctx, q.DeadKey(), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letters: %w", err)
+	}
+	items := make([]Item, 0, len(raws))
+	for _, raw := range raws {
+		item, err := parseItem(raw)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// visibilityTimeout returns the time a worker has to acknowledge a claimed item.
+func (q *Queue) visibilityTimeout() time.Duration {
+	if q.VisibilityTimeout > 0 {
+		return q.VisibilityTimeout
+	}
+	return DefaultVisibilityTimeout
+}
+
+// maxAttempts returns the number of attempts before an item is dead-lettered.
+func (q *Queue) maxAttempts() int {
+	if q.MaxAttempts > 0 {
+		return q.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// now returns the current time of the queue.
+func (q *Queue) now() time.Time {
+	if q.Now != nil {
+		return q.Now()
+	}
+	return time.Now()
+}
+
+// parseItem decodes the item stored in a list.
+func parseItem(raw string) (Item, error) {
+	var item Item
+	if err := json.Unmarshal([]byte(raw), &item); err != nil {
+		return Item{}, fmt.Errorf("failed to decode item: %w", err)
+	}
+	item.raw = raw
+	return item, nil
+}
+
+// newID returns a new random item ID.
+func newID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,193 @@
+package workqueue_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/workqueue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisAddrEnv is the environment variable with the address of a real redis to run the tests against,
+// the same as in the tests of the leaderboard package. The database of the address is flushed by the tests.
+const redisAddrEnv = "LEADERBOARD_TEST_REDIS_ADDR"
+
+// newRedisClient returns a client of the flushed real redis, the test is skipped if its address is not set.
+// The queue needs BLMOVE and lua scripts with cjson, which the in-process test server does not support.
+func newRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		t.Skip(redisAddrEnv + " is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	if err := client.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("FLUSHDB failed: %v", err)
+	}
+	return client
+}
+
+// claim claims an item as the worker and checks its payload and number of attempts.
+func claim(t *testing.T, q *workqueue.Queue, worker, payload string, attempts int) workqueue.Item {
+	t.Helper()
+	item, err := q.Claim(context.Background(), worker, time.Second)
+	if err != nil {
+		t.Fatalf("Claim(%q) failed: %v", worker, err)
+	}
+	if item.Payload != payload || item.Attempts != attempts {
+		t.Fatalf("Claim(%q) = %+v, want payload %q with %d attempts", worker, item, payload, attempts)
+	}
+	return item
+}
+
+// assertLen checks the number of pending items.
+func assertLen(t *testing.T, q *workqueue.Queue, want int64) {
+	t.Helper()
+	if n, err := q.Len(context.Background()); err != nil || n != want {
+		t.Errorf("Len() = %d, %v, want %d", n, err, want)
+	}
+}
+
+func TestAck(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	q := workqueue.New(client, "jobs")
+
+	items, err := q.Push(ctx, "a", "b")
+	if err != nil || len(items) != 2 {
+		t.Fatalf("Push(a, b) = %v, %v, want 2 items", items, err)
+	}
+	item := claim(t, q, "w1", "a", 0)
+	if item.ID != items[0].ID {
+		t.Errorf("Claim() = %s, want the oldest item %s", item.ID, items[0].ID)
+	}
+	assertLen(t, q, 1)
+	if n, err := client.LLen(ctx, q.ProcessingKey("w1")).Result(); err != nil || n != 1 {
+		t.Errorf("LLEN processing = %d, %v, want 1", n, err)
+	}
+	// the worker with a claimed item is not unregistered
+	if removed, err := q.Unregister(ctx, "w1"); err != nil || removed {
+		t.Errorf("Unregister(w1) = %v, %v, want false", removed, err)
+	}
+
+	if err := q.Ack(ctx, item); err != nil {
+		t.Fatalf("Ack() failed: %v", err)
+	}
+	if n, err := client.LLen(ctx, q.ProcessingKey("w1")).Result(); err != nil || n != 0 {
+		t.Errorf("LLEN processing after Ack() = %d, %v, want 0", n, err)
+	}
+	if err := q.Ack(ctx, item); !errors.Is(err, workqueue.ErrClaimLost) {
+		t.Errorf("Ack() again = %v, want ErrClaimLost", err)
+	}
+	if removed, err := q.Unregister(ctx, "w1"); err != nil || !removed {
+		t.Errorf("Unregister(w1) after Ack() = %v, %v, want true", removed, err)
+	}
+	assertLen(t, q, 1)
+}
+
+func TestClaimEmpty(t *testing.T) {
+	client := newRedisClient(t)
+	q := workqueue.New(client, "jobs")
+	if item, err := q.Claim(context.Background(), "w1", time.Second); !errors.Is(err, workqueue.ErrEmpty) {
+		t.Errorf("Claim() = %+v, %v, want ErrEmpty", item, err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	q := workqueue.New(client, "jobs")
+	q.MaxAttempts = 3
+
+	if _, err := q.Push(ctx, "a", "b"); err != nil {
+		t.Fatalf("Push(a, b) failed: %v", err)
+	}
+	// the failed item is requeued at the end of the queue with the attempt counted
+	item := claim(t, q, "w1", "a", 0)
+	if dead, err := q.Retry(ctx, item); err != nil || dead {
+		t.Fatalf("Retry() = %v, %v, want requeued", dead, err)
+	}
+	if err := q.Ack(ctx, item); !errors.Is(err, workqueue.ErrClaimLost) {
+		t.Errorf("Ack() after Retry() = %v, want ErrClaimLost", err)
+	}
+	if _, err := q.Retry(ctx, item); !errors.Is(err, workqueue.ErrClaimLost) {
+		t.Errorf("Retry() again = %v, want ErrClaimLost", err)
+	}
+	claim(t, q, "w1", "b", 0)
+	item = claim(t, q, "w1", "a", 1)
+	if dead, err := q.Retry(ctx, item); err != nil || dead {
+		t.Fatalf("Retry() = %v, %v, want requeued", dead, err)
+	}
+
+	// the item is dead-lettered after the third failed attempt
+	item = claim(t, q, "w2", "a", 2)
+	if dead, err := q.Retry(ctx, item); err != nil || !dead {
+		t.Fatalf("Retry() = %v, %v, want dead-lettered", dead, err)
+	}
+	assertLen(t, q, 0)
+	letters, err := q.DeadLetters(ctx)
+	if err != nil {
+		t.Fatalf("DeadLetters() failed: %v", err)
+	}
+	if len(letters) != 1 || letters[0].ID != item.ID || letters[0].Payload != "a" || letters[0].Attempts != 3 {
+		t.Errorf("DeadLetters() = %+v, want item %s with 3 attempts", letters, item.ID)
+	}
+}
+
+func TestReap(t *testing.T) {
+	client := newRedisClient(t)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	q := workqueue.New(client, "jobs")
+	q.VisibilityTimeout = 30 * time.Second
+	q.MaxAttempts = 2
+	q.Now = func() time.Time { return now }
+
+	if _, err := q.Push(ctx, "a", "b", "c"); err != nil {
+		t.Fatalf("Push(a, b, c) failed: %v", err)
+	}
+	a := claim(t, q, "w1", "a", 0)
+	now = now.Add(20 * time.Second)
+	claim(t, q, "w2", "b", 0)
+	// the worker fails right after claiming the item, before setting its deadline
+	if err := client.LMove(ctx, q.PendingKey(), q.ProcessingKey("w3"), "LEFT", "RIGHT").Err(); err != nil {
+		t.Fatalf("LMOVE failed: %v", err)
+	}
+	if err := q.Register(ctx, "w3"); err != nil {
+		t.Fatalf("Register(w3) failed: %v", err)
+	}
+
+	// the deadline of the first item passes exactly now, the item without a deadline gets one
+	now = now.Add(10 * time.Second)
+	if requeued, dead, err := q.Reap(ctx); err != nil || requeued != 1 || dead != 0 {
+		t.Fatalf("Reap() at +30s = %d, %d, %v, want 1, 0", requeued, dead, err)
+	}
+	if err := q.Ack(ctx, a); !errors.Is(err, workqueue.ErrClaimLost) {
+		t.Errorf("Ack() of the reaped item = %v, want ErrClaimLost", err)
+	}
+	a = claim(t, q, "w1", "a", 1)
+
+	now = now.Add(29 * time.Second)
+	if requeued, dead, err := q.Reap(ctx); err != nil || requeued != 1 || dead != 0 {
+		t.Fatalf("Reap() at +59s = %d, %d, %v, want 1, 0", requeued, dead, err)
+	}
+	// the deadlines of the item claimed at +30s and the item given a deadline at +30s pass
+	now = now.Add(time.Second)
+	if requeued, dead, err := q.Reap(ctx); err != nil || requeued != 1 || dead != 1 {
+		t.Fatalf("Reap() at +60s = %d, %d, %v, want 1, 1", requeued, dead, err)
+	}
+	if requeued, dead, err := q.Reap(ctx); err != nil || requeued != 0 || dead != 0 {
+		t.Errorf("Reap() again = %d, %d, %v, want 0, 0", requeued, dead, err)
+	}
+	assertLen(t, q, 2)
+	if letters, err := q.DeadLetters(ctx); err != nil || len(letters) != 1 || letters[0].ID != a.ID || letters[0].Attempts != 2 {
+		t.Errorf("DeadLetters() = %+v, %v, want item %s with 2 attempts", letters, err, a.ID)
+	}
+	claim(t, q, "w1", "b", 1)
+	claim(t, q, "w1", "c", 1)
+}
@@ -0,0 +1,70 @@
+package workqueue
+
+import "github.com/redis/go-redis/v9"
+
+// releaseFunc is the Lua function shared by the scripts releasing a claimed item after a failed attempt.
+// KEYS are the processing list, the deadlines, the pending list and the dead-letter list.
+// It counts the attempt and returns 1 if the item was dead-lettered after max attempts and 0 if it was requeued.
+const releaseFunc = `
+local function release(raw, maxAttempts)
+	redis.call('LREM', KEYS[1], 1, raw)
+	redis.call('ZREM', KEYS[2], raw)
+	local item = cjson.decode(raw)
+	item.attempts = (item.attempts or 0) + 1
+	if item.attempts >= maxAttempts then
+		redis.call('RPUSH', KEYS[4], cjson.encode(item))
+		return 1
+	end
+	redis.call('RPUSH', KEYS[3], cjson.encode(item))
+	return 0
+end
+`
+
+// ackScript removes the item from the processing list (KEYS[1]) and its deadline (KEYS[2]).
+// It returns 0 if the item is no longer in the processing list.
+var ackScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+// retryScript releases the item ARGV[1] with ARGV[2] max attempts, see releaseFunc.
+// It returns -1 if the item is no longer in the processing list.
+var retryScript = redis.NewScript(releaseFunc + `
+if redis.call('LPOS', KEYS[1], ARGV[1]) == false then
+	return -1
+end
+return release(ARGV[1], tonumber(ARGV[2]))
+`)
+
+// reapScript releases the items of the processing list whose deadline passed at ARGV[1] (ms) with ARGV[3]
+// max attempts, see releaseFunc. Items without a deadline get the deadline ARGV[1] + ARGV[2] (ms).
+// It returns the numbers of the requeued and the dead-lettered items.
+var reapScript = redis.NewScript(releaseFunc + `
+local now = tonumber(ARGV[1])
+local requeued, dead = 0, 0
+for _, raw in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	local deadline = redis.call('ZSCORE', KEYS[2], raw)
+	if not deadline then
+		redis.call('ZADD', KEYS[2], now + tonumber(ARGV[2]), raw)
+	elseif tonumber(deadline) <= now then
+		if release(raw, tonumber(ARGV[3])) == 1 then
+			dead = dead + 1
+		else
+			requeued = requeued + 1
+		end
+	end
+end
+return {requeued, dead}
+`)
+
+// unregisterScript removes the worker ARGV[1] from the set of workers (KEYS[2]) if its processing list (KEYS[1]) is empty.
+var unregisterScript = redis.NewScript(`
+if redis.call('LLEN', KEYS[1]) > 0 then
+	return 0
+end
+redis.call('SREM', KEYS[2], ARGV[1])
+return 1
+`)
@@ -0,0 +1,175 @@
+package workqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPollTimeout is the default time a worker waits for an item before checking for shutdown.
+	DefaultPollTimeout = time.Second
+	// DefaultErrorBackoff is the default delay of a worker after redis failed.
+	DefaultErrorBackoff = time.Second
+)
+
+// Handler processes an item. The item is acknowledged if it returns nil and retried otherwise.
+type Handler func(ctx context.Context, item Item) error
+
+// Pool runs concurrent workers processing the items of a queue and a reaper requeuing the stuck items.
+type Pool struct {
+	// Workers is the number of concurrent workers, 1 if zero.
+	Workers int
+	// Name prefixes the names of the workers, which must be unique among all pools of the queue.
+	// The hostname and the process ID if empty.
+	Name string
+	// PollTimeout is the time a worker waits for an item before checking for shutdown, DefaultPollTimeout if zero.
+	// The blocking commands of go-redis round shorter timeouts up to a second.
+	PollTimeout time.Duration
+	// ReapInterval is the interval of reaping the stuck items, half of the visibility timeout if zero.
+	ReapInterval time.Duration
+	// ErrorBackoff is the delay of a worker after redis failed, DefaultErrorBackoff if zero.
+	ErrorBackoff time.Duration
+
+	queue  *Queue
+	handle Handler
+}
+
+// NewPool returns a new Pool processing the items of the queue with the handler.
+func NewPool(queue *Queue, handle Handler) *Pool {
+	return &Pool{
+		queue:  queue,
+		handle: handle,
+	}
+}
+
+// Run runs the workers and the reaper until the context is done, then shuts down gracefully:
+// the workers stop claiming new items, finish and acknowledge the items they are processing and unregister.
+// The handlers get a context that is not canceled by the shutdown. It always returns the error of the context.
+func (p *Pool) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < max(p.Workers, 1); i++ {
+		worker := fmt.Sprintf("%s-%d", p.name(), i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx, worker)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.reap(ctx)
+	}()
+	wg.Wait()
+	return ctx.Err()
+}
+
+// work claims and processes items as the worker until the context is done.
+func (p *Pool) work(ctx context.Context, worker string) {
+	// the claimed items are finished even during the shutdown
+	workCtx := context.WithoutCancel(ctx)
+	for ctx.Err() == nil {
+		item, err := p.queue.Claim(workCtx, worker, p.pollTimeout())
+		if errors.Is(err, ErrEmpty) {
+			continue
+		}
+		if err != nil {
+			slog.Warn("Failed to claim item", "worker", worker, "error", err)
+			p.sleep(ctx, p.errorBackoff())
+			continue
+		}
+		p.process(workCtx, worker, item)
+	}
+
+	if _, err := p.queue.Unregister(workCtx, worker); err != nil {
+		slog.Warn("Failed to unregister worker", "worker", worker, "error", err)
+	}
+}
+
+// process handles the item and acknowledges or retries it.
+func (p *Pool) process(ctx context.Context, worker string, item Item) {
+	if err := p.handle(ctx, item); err != nil {
+		slog.Warn("Failed to process item", "worker", worker, "id", item.ID, "attempts", item.Attempts+1, "error", err)
+		dead, err := p.queue.Retry(ctx, item)
+		if err != nil {
+			slog.Warn("Failed to retry item", "worker", worker, "id", item.ID, "error", err)
+		} else if dead {
+			slog.Error("Item moved to the dead-letter list", "worker", worker, "id", item.ID)
+		}
+		return
+	}
+	if err := p.queue.Ack(ctx, item); err != nil {
+		slog.Warn("Failed to acknowledge item", "worker", worker, "id", item.ID, "error", err)
+	}
+}
+
+// reap reaps the stuck items periodically until the context is done.
+func (p *Pool) reap(ctx context.Context) {
+	ticker := time.NewTicker(p.reapInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		requeued, dead, err := p.queue.Reap(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to reap stuck items", "error", err)
+		}
+		if requeued > 0 || dead > 0 {
+			slog.Info("Reaped stuck items", "requeued", requeued, "dead", dead)
+		}
+	}
+}
+
+// sleep waits for the duration or until the context is done.
+func (p *Pool) sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// name returns the prefix of the names of the workers.
+func (p *Pool) name() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// pollTimeout returns the time a worker waits for an item.
+func (p *Pool) pollTimeout() time.Duration {
+	if p.PollTimeout > 0 {
+		return p.PollTimeout
+	}
+	return DefaultPollTimeout
+}
+
+// reapInterval returns the interval of reaping the stuck items.
+func (p *Pool) reapInterval() time.Duration {
+	if p.ReapInterval > 0 {
+		return p.ReapInterval
+	}
+	return p.queue.visibilityTimeout() / 2
+}
+
+// errorBackoff returns the delay of a worker after redis failed.
+func (p *Pool) errorBackoff() time.Duration {
+	if p.ErrorBackoff > 0 {
+		return p.ErrorBackoff
+	}
+	return DefaultErrorBackoff
+}
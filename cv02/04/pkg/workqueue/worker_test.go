@@ -0,0 +1,176 @@
+package workqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dpb-cv02-04/pkg/workqueue"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestPool(t *testing.T) {
+	client := newRedisClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := workqueue.New(client, "jobs")
+	q.MaxAttempts = 2
+
+	if _, err := q.Push(ctx, "a", "fail", "b"); err != nil {
+		t.Fatalf("Push() failed: %v", err)
+	}
+	handled := make(chan workqueue.Item, 10)
+	pool := workqueue.NewPool(q, func(ctx context.Context, item workqueue.Item) error {
+		handled <- item
+		if item.Payload == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	pool.Workers = 2
+	pool.Name = "test"
+	done := make(chan error, 1)
+	go func() { done <- pool.Run(ctx) }()
+
+	// the failing item is handled twice before it is dead-lettered
+	attempts := map[string]int{}
+	for i := 0; i < 4; i++ {
+		select {
+		case item := <-handled:
+			attempts[item.Payload]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("handled %v, want 4 items", attempts)
+		}
+	}
+	if attempts["a"] != 1 || attempts["b"] != 1 || attempts["fail"] != 2 {
+		t.Errorf("handled %v, want a and b once and fail twice", attempts)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+	assertLen(t, q, 0)
+	if letters, err := q.DeadLetters(context.Background()); err != nil || len(letters) != 1 || letters[0].Payload != "fail" {
+		t.Errorf("DeadLetters() = %+v, %v, want the failing item", letters, err)
+	}
+}
+
+func TestPoolShutdown(t *testing.T) {
+	client := newRedisClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := workqueue.New(client, "jobs")
+
+	if _, err := q.Push(ctx, "a", "b"); err != nil {
+		t.Fatalf("Push() failed: %v", err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handlerErr error
+	pool := workqueue.NewPool(q, func(ctx context.Context, item workqueue.Item) error {
+		close(started)
+		<-release
+		// the handler is not canceled by the shutdown
+		handlerErr = ctx.Err()
+		return nil
+	})
+	pool.Name = "test"
+	done := make(chan error, 1)
+	go func() { done <- pool.Run(ctx) }()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the item was not handled")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("Run() = %v before the item was processed", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the shutdown")
+	}
+	if handlerErr != nil {
+		t.Errorf("handler context = %v, want not canceled", handlerErr)
+	}
+
+	// the processed item was acknowledged, the other one was not claimed and the worker unregistered
+	bg := context.Background()
+	if n, err := client.LLen(bg, q.ProcessingKey("test-0")).Result(); err != nil || n != 0 {
+		t.Errorf("LLEN processing = %d, %v, want 0", n, err)
+	}
+	assertLen(t, q, 1)
+	if removed, err := q.Unregister(bg, "test-0"); err != nil || !removed {
+		t.Errorf("Unregister(test-0) = %v, %v, want the worker registered with no items", removed, err)
+	}
+	if n, err := client.Exists(bg, q.PendingKey()+":workers").Result(); err != nil || n != 0 {
+		t.Errorf("EXISTS workers = %d, %v, want 0", n, err)
+	}
+}
+
+// failingHook fails all commands and counts the claims, which start by registering the worker with SADD.
+type failingHook struct {
+	claims atomic.Int64
+}
+
+// Interface guard for redis.Hook.
+var _ redis.Hook = (*failingHook)(nil)
+
+func (h *failingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *failingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "sadd" {
+			h.claims.Add(1)
+		}
+		err := errors.New("redis is down")
+		cmd.SetErr(err)
+		return err
+	}
+}
+
+func (h *failingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestPoolErrorBackoff(t *testing.T) {
+	// the hook fails the commands, so the client never connects
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	t.Cleanup(func() { client.Close() })
+	hook := &failingHook{}
+	client.AddHook(hook)
+
+	pool := workqueue.NewPool(workqueue.New(client, "jobs"), func(ctx context.Context, item workqueue.Item) error {
+		t.Errorf("handled %+v, want no items", item)
+		return nil
+	})
+	pool.Name = "test"
+	pool.ErrorBackoff = 100 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := pool.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v, want context.DeadlineExceeded", err)
+	}
+	// the backoff is interrupted by the shutdown
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run() returned after %v, want soon after the shutdown", elapsed)
+	}
+	// the worker claims at 0, 100, 200 and 300ms
+	if claims := hook.claims.Load(); claims < 3 || claims > 5 {
+		t.Errorf("claims = %d, want about 4 with the backoff of 100ms", claims)
+	}
+}
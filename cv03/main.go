@@ -25,6 +25,78 @@ func main() {
 	}
 	fmt.Printf("The chessboard with the queen on (%d, %d) and the squares under attack:\n%v\n", queenX, queenY, queenBoard)
 
+	// solve the n-queens problem with a queen preplaced on (queenX, queenY)
+	preplaced := chessboard.Position{X: queenX, Y: queenY}
+	solution, err := chessboard.SolveNQueens(boardSize, preplaced)
+	if err != nil {
+		panic(err.Error())
+	}
+	numSolutions, err := chessboard.CountNQueens(boardSize, preplaced)
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("The first of %d solutions of %d queens with a queen on (%d, %d):\n%v\n", numSolutions, boardSize, queenX, queenY, solution.Board())
+
+	// any piece can be placed on the board through the Piece interface
+	for _, piece := range []chessboard.Piece{chessboard.KnightPiece{}, chessboard.PawnPiece{Color: chessboard.Black}} {
+		pieceBoard, err := chessboard.AttackBoard(piece, boardSize, boardSize, queenX, queenY)
+		if err != nil {
+			panic(err.Error())
+		}
+		fmt.Printf("The chessboard with the %s on (%d, %d) and the squares under attack:\n%v\n", piece.Name(), queenX, queenY, pieceBoard)
+	}
+
+	// place pieces of both colors, the sliding pieces are blocked by the other pieces
+	board, err := chessboard.NewBoard(boardSize, boardSize)
+	if err != nil {
+		panic(err.Error())
+	}
+	for _, err := range []error{
+		board.Place(queenX, queenY, chessboard.QueenPiece{}, chessboard.White),
+		board.Place(queenX, queenY+3, chessboard.KnightPiece{}, chessboard.Black),
+		board.Place(queenX+3, queenY+3, chessboard.RookPiece{}, chessboard.Black),
+	} {
+		if err != nil {
+			panic(err.Error())
+		}
+	}
+	attackedBoard, err := board.AttackedBoard(chessboard.White)
+	if err != nil {
+		panic(err.Error())
+	}
+	defended, err := board.IsDefended(queenX, queenY+3)
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("The squares under attack by white:\n%v\nThe black knight is defended: %v\n\n", attackedBoard, defended)
+
+	// load a position from FEN and serialize it back
+	fen := "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3"
+	game, err := chessboard.ParseFEN(fen)
+	if err != nil {
+		panic(err.Error())
+	}
+	gameFEN, err := game.FEN()
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("The position %q with %v to move:\n%v\n", gameFEN, game.SideToMove, game)
+
+	// capture en passant and count the positions reachable from the position
+	move, err := chessboard.ParseMove("e5f6")
+	if err != nil {
+		panic(err.Error())
+	}
+	if err := game.MakeMove(move); err != nil {
+		panic(err.Error())
+	}
+	perftDepth := 3
+	nodes, err := game.Perft(perftDepth)
+	if err != nil {
+		panic(err.Error())
+	}
+	fmt.Printf("After %v black has %d legal moves and %d positions after %d moves:\n%v\n", move, len(game.LegalMoves()), nodes, perftDepth, game)
+
 	upperBound := 200
 	numToCensor := 17
 	censoredNumbers, err := numbers.CensorNumber(upperBound, numToCensor)
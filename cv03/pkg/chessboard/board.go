@@ -0,0 +1,244 @@
+package chessboard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BoardPiece is a piece of a side placed on a Board.
+type BoardPiece struct {
+	Piece Piece
+	Color Color
+}
+
+// Symbol returns the symbol of the piece, in upper case for white and in lower case for black.
+func (p BoardPiece) Symbol() string {
+	if p.Color == Black {
+		return strings.ToLower(p.Piece.Symbol())
+	}
+	return strings.ToUpper(p.Piece.Symbol())
+}
+
+// Board represents a rectangular n x m board with pieces of both colors.
+// Unlike the single piece boards of Queen and AttackBoard, the sliding pieces attack only up to
+// and including the first occupied square of every ray. The zero value is not usable, use NewBoard.
+type Board struct {
+	n, m int
+	// squares holds the pieces row by row, an empty square has a nil piece.
+	squares []BoardPiece
+}
+
+// Board implements the fmt.Stringer interface.
+var _ fmt.Stringer = (*Board)(nil)
+
+// NewBoard returns an empty n x m board.
+// Returns an error if the board size is not positive.
+func NewBoard(n, m int) (*Board, error) {
+	// check if the board size is valid
+	if n < 1 || m < 1 {
+		return nil, fmt.Errorf("invalid board size: %dx%d", n, m)
+	}
+	return &Board{n: n, m: m, squares: make([]BoardPiece, n*m)}, nil
+}
+
+// Size returns the number of rows and columns of the board.
+func (b *Board) Size() (int, int) {
+	return b.n, b.m
+}
+
+// Place places the piece of the color on (x, y).
+// Returns an error if the coordinates are not on the board, the square is occupied,
+// the color is invalid or it does not match the color of a pawn.
+func (b *Board) Place(x, y int, piece Piece, color Color) error {
+	if piece == nil {
+		return fmt.Errorf("missing piece for (%d, %d)", x, y)
+	}
+	if !b.contains(x, y) {
+		return fmt.Errorf("invalid %s coordinates: (%d, %d)", piece.Name(), x, y)
+	}
+	if color != White && color != Black {
+		return fmt.Errorf("invalid %s color: %v", piece.Name(), color)
+	}
+	// the color of a pawn decides its direction, so it must be the color of the side
+	if pawn, ok := piece.(PawnPiece); ok && pawn.Color != color {
+		return fmt.Errorf("pawn color %v does not match the side %v", pawn.Color, color)
+	}
+	if occupant := b.squares[b.index(x, y)]; occupant.Piece != nil {
+		return fmt.Errorf("square (%d, %d) is occupied by %v %s", x, y, occupant.Color, occupant.Piece.Name())
+	}
+	b.squares[b.index(x, y)] = BoardPiece{Piece: piece, Color: color}
+	return nil
+}
+
+// At returns the piece on (x, y). Returns false if the square is empty or not on the board.
+func (b *Board) At(x, y int) (BoardPiece, bool) {
+	if !b.contains(x, y) {
+		return BoardPiece{}, false
+	}
+	piece := b.squares[b.index(x, y)]
+	return piece, piece.Piece != nil
+}
+
+// Remove removes the piece from (x, y) and returns it. Returns false if the square is empty or not on the board.
+func (b *Board) Remove(x, y int) (BoardPiece, bool) {
+	piece, ok := b.At(x, y)
+	if ok {
+		b.squares[b.index(x, y)] = BoardPiece{}
+	}
+	return piece, ok
+}
+
+// Pieces returns the positions of the pieces of the color ordered by rows and columns.
+func (b *Board) Pieces(color Color) []Position {
+	var positions []Position
+	for i, piece := range b.squares {
+		if piece.Piece != nil && piece.Color == color {
+			positions = append(positions, Position{X: i / b.m, Y: i % b.m})
+		}
+	}
+	return positions
+}
+
+// AttacksFrom returns the squares attacked by the piece on (x, y) ordered by rows and columns.
+// Every ray of the piece stops at the first occupied square, which is attacked if it holds an opponent piece
+// and defended if it holds a piece of the same color.
+// Returns an error if the square is empty or not on the board.
+func (b *Board) AttacksFrom(x, y int) ([]Position, error) {
+	piece, ok := b.At(x, y)
+	if !ok {
+		return nil, fmt.Errorf("no piece on (%d, %d)", x, y)
+	}
+	rays, err := piece.Piece.Rays(b.n, b.m, x, y)
+	if err != nil {
+		return nil, err
+	}
+	for i, ray := range rays {
+		for j, square := range ray {
+			// cut the ray off behind the first occupied square
+			if _, occupied := b.At(square.X, square.Y); occupied {
+				rays[i] = ray[:j+1]
+				break
+			}
+		}
+	}
+	return flattenRays(rays, nil)
+}
+
+// AttackMap returns the number of pieces of the color attacking every square of the board, indexed by rows and columns.
+// Returns an error if the attacks of any of the pieces fail.
+func (b *Board) AttackMap(color Color) ([][]int, error) {
+	attackMap := make([][]int, b.n)
+	for i := range attackMap {
+		attackMap[i] = make([]int, b.m)
+	}
+	for _, position := range b.Pieces(color) {
+		attacked, err := b.AttacksFrom(position.X, position.Y)
+		if err != nil {
+			return nil, err
+		}
+		for _, square := range attacked {
+			attackMap[square.X][square.Y]++
+		}
+	}
+	return attackMap, nil
+}
+
+// Attackers returns the positions of the pieces of the color attacking (x, y) ordered by rows and columns.
+// Returns an error if the coordinates are not on the board.
+func (b *Board) Attackers(x, y int, color Color) ([]Position, error) {
+	if !b.contains(x, y) {
+		return nil, fmt.Errorf("invalid square coordinates: (%d, %d)", x, y)
+	}
+	var attackers []Position
+	for _, position := range b.Pieces(color) {
+		attacked, err := b.AttacksFrom(position.X, position.Y)
+		if err != nil {
+			return nil, err
+		}
+		for _, square := range attacked {
+			if square.X == x && square.Y == y {
+				attackers = append(attackers, position)
+				break
+			}
+		}
+	}
+	return attackers, nil
+}
+
+// IsAttackedBy reports whether any piece of the color attacks (x, y), the square may be empty.
+// Returns an error if the coordinates are not on the board.
+func (b *Board) IsAttackedBy(x, y int, color Color) (bool, error) {
+	attackers, err := b.Attackers(x, y, color)
+	if err != nil {
+		return false, err
+	}
+	return len(attackers) > 0, nil
+}
+
+// IsAttacked reports whether the piece on (x, y) is attacked by any opponent piece.
+// Returns an error if the square is empty or not on the board.
+func (b *Board) IsAttacked(x, y int) (bool, error) {
+	piece, ok := b.At(x, y)
+	if !ok {
+		return false, fmt.Errorf("no piece on (%d, %d)", x, y)
+	}
+	return b.IsAttackedBy(x, y, piece.Color.Opponent())
+}
+
+// IsDefended reports whether the piece on (x, y) is defended by any other piece of the same color.
+// Returns an error if the square is empty or not on the board.
+func (b *Board) IsDefended(x, y int) (bool, error) {
+	piece, ok := b.At(x, y)
+	if !ok {
+		return false, fmt.Errorf("no piece on (%d, %d)", x, y)
+	}
+	return b.IsAttackedBy(x, y, piece.Color)
+}
+
+// ChessBoard returns a chessboard with the symbols of the pieces, see BoardPiece.Symbol, and '.' for the empty squares.
+func (b *Board) ChessBoard() ChessBoard {
+	chessBoard := make(ChessBoard, b.n)
+	for i := range chessBoard {
+		chessBoard[i] = make([]string, b.m)
+		for j := range chessBoard[i] {
+			chessBoard[i][j] = "."
+			if piece, ok := b.At(i, j); ok {
+				chessBoard[i][j] = piece.Symbol()
+			}
+		}
+	}
+	return chessBoard
+}
+
+// AttackedBoard returns a chessboard with the pieces and the empty squares under attack by the color symbolized by '*'.
+// Returns an error if the attacks of any of the pieces fail.
+func (b *Board) AttackedBoard(color Color) (ChessBoard, error) {
+	attackMap, err := b.AttackMap(color)
+	if err != nil {
+		return nil, err
+	}
+	chessBoard := b.ChessBoard()
+	for i, row := range attackMap {
+		for j, attackers := range row {
+			if _, occupied := b.At(i, j); !occupied && attackers > 0 {
+				chessBoard[i][j] = "*"
+			}
+		}
+	}
+	return chessBoard, nil
+}
+
+// String returns a string representation of the board, see ChessBoard.
+func (b *Board) String() string {
+	return b.ChessBoard().String()
+}
+
+// contains reports whether (x, y) is on the board.
+func (b *Board) contains(x, y int) bool {
+	return x >= 0 && x < b.n && y >= 0 && y < b.m
+}
+
+// index returns the index of (x, y) in the squares.
+func (b *Board) index(x, y int) int {
+	return x*b.m + y
+}
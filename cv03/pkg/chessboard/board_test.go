@@ -0,0 +1,188 @@
+package chessboard_test
+
+import (
+	"reflect"
+	"testing"
+
+	"dpb03/pkg/chessboard"
+)
+
+// newTestBoard returns a 5x5 board with a white rook defending a white knight, which blocks a black bishop:
+//
+//	....s
+//	.....
+//	V.J..
+//	.....
+//	p...k
+func newTestBoard(t *testing.T) *chessboard.Board {
+	t.Helper()
+	board, err := chessboard.NewBoard(5, 5)
+	if err != nil {
+		t.Fatalf("NewBoard(5, 5) failed: %v", err)
+	}
+	for _, p := range []struct {
+		x, y  int
+		piece chessboard.Piece
+		color chessboard.Color
+	}{
+		{2, 0, chessboard.RookPiece{}, chessboard.White},
+		{2, 2, chessboard.KnightPiece{}, chessboard.White},
+		{0, 4, chessboard.BishopPiece{}, chessboard.Black},
+		{4, 0, chessboard.PawnPiece{Color: chessboard.Black}, chessboard.Black},
+		{4, 4, chessboard.KingPiece{}, chessboard.Black},
+	} {
+		if err := board.Place(p.x, p.y, p.piece, p.color); err != nil {
+			t.Fatalf("Place(%d, %d, %s) failed: %v", p.x, p.y, p.piece.Name(), err)
+		}
+	}
+	return board
+}
+
+func TestBoardAttacksFrom(t *testing.T) {
+	board := newTestBoard(t)
+	tests := []struct {
+		x, y int
+		want []chessboard.Position
+	}{
+		// the rook attacks the black pawn and defends the knight, the squares behind the knight are not attacked
+		{2, 0, []chessboard.Position{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 0}, {X: 4, Y: 0}}},
+		// the knight jumps over the pieces
+		{2, 2, []chessboard.Position{{X: 0, Y: 1}, {X: 0, Y: 3}, {X: 1, Y: 0}, {X: 1, Y: 4}, {X: 3, Y: 0}, {X: 3, Y: 4}, {X: 4, Y: 1}, {X: 4, Y: 3}}},
+		// the bishop stops at the knight
+		{0, 4, []chessboard.Position{{X: 1, Y: 3}, {X: 2, Y: 2}}},
+		// the black pawn on the last row attacks nothing
+		{4, 0, nil},
+	}
+	for _, test := range tests {
+		if attacks, err := board.AttacksFrom(test.x, test.y); err != nil || !reflect.DeepEqual(attacks, test.want) {
+			t.Errorf("AttacksFrom(%d, %d) = %v, %v, want %v", test.x, test.y, attacks, err, test.want)
+		}
+	}
+	if attacks, err := board.AttacksFrom(1, 1); err == nil {
+		t.Errorf("AttacksFrom(1, 1) of an empty square = %v, want error", attacks)
+	}
+
+	// the rays continue once the blocker is removed
+	if _, ok := board.Remove(2, 2); !ok {
+		t.Fatalf("Remove(2, 2) found no piece")
+	}
+	want := []chessboard.Position{{X: 1, Y: 3}, {X: 2, Y: 2}, {X: 3, Y: 1}, {X: 4, Y: 0}}
+	if attacks, err := board.AttacksFrom(0, 4); err != nil || !reflect.DeepEqual(attacks, want) {
+		t.Errorf("AttacksFrom(0, 4) without the knight = %v, %v, want %v", attacks, err, want)
+	}
+}
+
+func TestBoardAttackers(t *testing.T) {
+	board := newTestBoard(t)
+	tests := []struct {
+		name  string
+		x, y  int
+		color chessboard.Color
+		want  []chessboard.Position
+	}{
+		{"both white pieces", 1, 0, chessboard.White, []chessboard.Position{{X: 2, Y: 0}, {X: 2, Y: 2}}},
+		{"defended knight", 2, 2, chessboard.White, []chessboard.Position{{X: 2, Y: 0}}},
+		{"attacked knight", 2, 2, chessboard.Black, []chessboard.Position{{X: 0, Y: 4}}},
+		{"behind the knight", 2, 4, chessboard.White, nil},
+		{"behind the knight for the bishop", 3, 1, chessboard.Black, nil},
+		{"attacked pawn", 4, 0, chessboard.White, []chessboard.Position{{X: 2, Y: 0}}},
+		{"square next to the king", 3, 3, chessboard.Black, []chessboard.Position{{X: 4, Y: 4}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attackers, err := board.Attackers(test.x, test.y, test.color)
+			if err != nil || !reflect.DeepEqual(attackers, test.want) {
+				t.Errorf("Attackers(%d, %d, %v) = %v, %v, want %v", test.x, test.y, test.color, attackers, err, test.want)
+			}
+			attacked, err := board.IsAttackedBy(test.x, test.y, test.color)
+			if err != nil || attacked != (len(test.want) > 0) {
+				t.Errorf("IsAttackedBy(%d, %d, %v) = %v, %v, want %v", test.x, test.y, test.color, attacked, err, len(test.want) > 0)
+			}
+		})
+	}
+	for _, square := range [][2]int{{-1, 0}, {0, 5}, {5, 0}} {
+		if attackers, err := board.Attackers(square[0], square[1], chessboard.White); err == nil {
+			t.Errorf("Attackers(%d, %d) = %v, want error", square[0], square[1], attackers)
+		}
+	}
+}
+
+func TestBoardIsAttackedIsDefended(t *testing.T) {
+	board := newTestBoard(t)
+	tests := []struct {
+		name                   string
+		x, y                   int
+		wantAttacked, defended bool
+	}{
+		{"rook", 2, 0, false, false},
+		{"knight", 2, 2, true, true},
+		{"bishop", 0, 4, false, false},
+		{"pawn", 4, 0, true, false},
+		{"king", 4, 4, false, false},
+	}
+	for _, test := range tests {
+		if attacked, err := board.IsAttacked(test.x, test.y); err != nil || attacked != test.wantAttacked {
+			t.Errorf("IsAttacked(%s) = %v, %v, want %v", test.name, attacked, err, test.wantAttacked)
+		}
+		if defended, err := board.IsDefended(test.x, test.y); err != nil || defended != test.defended {
+			t.Errorf("IsDefended(%s) = %v, %v, want %v", test.name, defended, err, test.defended)
+		}
+	}
+	if _, err := board.IsAttacked(1, 1); err == nil {
+		t.Errorf("IsAttacked(1, 1) of an empty square succeeded, want error")
+	}
+	if _, err := board.IsDefended(1, 1); err == nil {
+		t.Errorf("IsDefended(1, 1) of an empty square succeeded, want error")
+	}
+}
+
+func TestBoardAttackedBoard(t *testing.T) {
+	board := newTestBoard(t)
+	if got, want := board.String(), "....s\n.....\nV.J..\n.....\np...k\n"; got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+	attacked, err := board.AttackedBoard(chessboard.White)
+	if err != nil {
+		t.Fatalf("AttackedBoard(white) failed: %v", err)
+	}
+	if got, want := attacked.String(), "**.*s\n*...*\nV*J..\n*...*\np*.*k\n"; got != want {
+		t.Errorf("AttackedBoard(white) =\n%s\nwant\n%s", got, want)
+	}
+	attackMap, err := board.AttackMap(chessboard.White)
+	if err != nil || attackMap[1][0] != 2 || attackMap[2][4] != 0 {
+		t.Errorf("AttackMap(white) = %v, %v, want 2 attackers of (1, 0) and none of (2, 4)", attackMap, err)
+	}
+}
+
+func TestBoardPlace(t *testing.T) {
+	if _, err := chessboard.NewBoard(0, 5); err == nil {
+		t.Errorf("NewBoard(0, 5) succeeded, want error")
+	}
+	board := newTestBoard(t)
+	tests := []struct {
+		name  string
+		x, y  int
+		piece chessboard.Piece
+		color chessboard.Color
+	}{
+		{"occupied", 2, 2, chessboard.QueenPiece{}, chessboard.Black},
+		{"outside", 5, 0, chessboard.QueenPiece{}, chessboard.White},
+		{"invalid color", 1, 1, chessboard.QueenPiece{}, chessboard.Color(2)},
+		{"pawn of the other side", 1, 1, chessboard.PawnPiece{Color: chessboard.White}, chessboard.Black},
+		{"missing piece", 1, 1, nil, chessboard.White},
+	}
+	for _, test := range tests {
+		if err := board.Place(test.x, test.y, test.piece, test.color); err == nil {
+			t.Errorf("Place(%s) succeeded, want error", test.name)
+		}
+	}
+	if piece, ok := board.At(1, 1); ok {
+		t.Errorf("At(1, 1) = %v after the failed places, want empty", piece)
+	}
+	if piece, ok := board.At(2, 2); !ok || piece.Symbol() != "J" {
+		t.Errorf("At(2, 2) = %v, %v, want the white knight", piece, ok)
+	}
+	if got := board.Pieces(chessboard.Black); !reflect.DeepEqual(got, []chessboard.Position{{X: 0, Y: 4}, {X: 4, Y: 0}, {X: 4, Y: 4}}) {
+		t.Errorf("Pieces(black) = %v", got)
+	}
+}
@@ -0,0 +1,446 @@
+package chessboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartFEN is the FEN of the standard starting position.
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// fenSize is the number of rows and columns of the board described by FEN.
+const fenSize = 8
+
+// FENField is the name of a field of FEN.
+type FENField string
+
+// The fields of FEN in their order.
+const (
+	FENPlacement      FENField = "piece placement"
+	FENSideToMove     FENField = "side to move"
+	FENCastling       FENField = "castling"
+	FENEnPassant      FENField = "en passant"
+	FENHalfmoveClock  FENField = "halfmove clock"
+	FENFullmoveNumber FENField = "fullmove number"
+)
+
+// FENError is the error of an invalid FEN, pointing at the offending field.
+type FENError struct {
+	// Field is the offending field, empty if the FEN does not have the six fields.
+	Field FENField
+	// Value is the value of the offending field, the whole FEN if the Field is empty.
+	Value string
+	// Reason describes what is wrong with the value.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *FENError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid FEN %q: %s", e.Value, e.Reason)
+	}
+	return fmt.Sprintf("invalid FEN %s %q: %s", e.Field, e.Value, e.Reason)
+}
+
+// CastlingRights is a set of the castling rights of both sides.
+type CastlingRights uint8
+
+// The castling rights, the sides of the board are named after the king and the queen.
+const (
+	WhiteKingside CastlingRights = 1 << iota
+	WhiteQueenside
+	BlackKingside
+	BlackQueenside
+)
+
+// CastlingRights implements the fmt.Stringer interface.
+var _ fmt.Stringer = CastlingRights(0)
+
+// castles describes the castlings of the rights, the king and the rook move from and to the squares.
+var castles = []struct {
+	right            CastlingRights
+	color            Color
+	king, kingTo     Position
+	rookFrom, rookTo Position
+}{
+	{WhiteKingside, White, Position{7, 4}, Position{7, 6}, Position{7, 7}, Position{7, 5}},
+	{WhiteQueenside, White, Position{7, 4}, Position{7, 2}, Position{7, 0}, Position{7, 3}},
+	{BlackKingside, Black, Position{0, 4}, Position{0, 6}, Position{0, 7}, Position{0, 5}},
+	{BlackQueenside, Black, Position{0, 4}, Position{0, 2}, Position{0, 0}, Position{0, 3}},
+}
+
+// castlingLetters are the letters of the castling rights in the order of FEN.
+var castlingLetters = []struct {
+	letter byte
+	right  CastlingRights
+}{
+	{'K', WhiteKingside},
+	{'Q', WhiteQueenside},
+	{'k', BlackKingside},
+	{'q', BlackQueenside},
+}
+
+// String returns the castling rights as in FEN, e.g. "KQkq", or "-" if there are none.
+func (c CastlingRights) String() string {
+	var sb strings.Builder
+	for _, l := range castlingLetters {
+		if c&l.right != 0 {
+			sb.WriteByte(l.letter)
+		}
+	}
+	if sb.Len() == 0 {
+		return "-"
+	}
+	return sb.String()
+}
+
+// Game represents a position of a chess game on the standard 8 x 8 board, as described by FEN.
+// The row 0 of the board is the rank 8 and the column 0 is the file a, so white moves towards the row 0.
+type Game struct {
+	Board      *Board
+	SideToMove Color
+	Castling   CastlingRights
+	// EnPassant is the square behind a pawn that has just made a two-square move, nil if there is none.
+	EnPassant      *Position
+	HalfmoveClock  int
+	FullmoveNumber int
+
+	// history holds the states before the moves made, see MakeMove and UnmakeMove.
+	history []undo
+}
+
+// Game implements the fmt.Stringer interface.
+var _ fmt.Stringer = (*Game)(nil)
+
+// String returns the board of the game, see Board.ChessBoard.
+func (g *Game) String() string {
+	return g.Board.String()
+}
+
+// ParseFEN parses the position described by the FEN, e.g. StartFEN.
+// The FEN is validated strictly: it must have all six fields separated by single spaces,
+// every side must have one king, the castling rights must match the kings and the rooks on their starting squares,
+// the en passant square must be behind a pawn that has just moved two squares and the side that is not to move must not be in check.
+// Returns a *FENError pointing at the offending field if the FEN is invalid.
+func ParseFEN(fen string) (*Game, error) {
+	fields := strings.Split(fen, " ")
+	if len(fields) != 6 {
+		return nil, &FENError{Value: fen, Reason: fmt.Sprintf("expected 6 fields separated by single spaces, got %d", len(fields))}
+	}
+
+	board, err := parsePlacement(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	g := &Game{Board: board}
+
+	switch fields[1] {
+	case "w":
+		g.SideToMove = White
+	case "b":
+		g.SideToMove = Black
+	default:
+		return nil, &FENError{Field: FENSideToMove, Value: fields[1], Reason: `must be "w" or "b"`}
+	}
+	// the side to move could capture the king of the other side
+	king := g.king(g.SideToMove.Opponent())
+	if inCheck, err := board.IsAttacked(king.X, king.Y); err != nil || inCheck {
+		return nil, &FENError{Field: FENSideToMove, Value: fields[1], Reason: fmt.Sprintf("%v is in check but not to move", g.SideToMove.Opponent())}
+	}
+
+	if g.Castling, err = g.parseCastling(fields[2]); err != nil {
+		return nil, err
+	}
+	if g.EnPassant, err = g.parseEnPassant(fields[3]); err != nil {
+		return nil, err
+	}
+
+	if g.HalfmoveClock, err = parseCounter(fields[4]); err != nil {
+		return nil, &FENError{Field: FENHalfmoveClock, Value: fields[4], Reason: err.Error()}
+	}
+	if g.FullmoveNumber, err = parseCounter(fields[5]); err != nil {
+		return nil, &FENError{Field: FENFullmoveNumber, Value: fields[5], Reason: err.Error()}
+	}
+	if g.FullmoveNumber == 0 {
+		return nil, &FENError{Field: FENFullmoveNumber, Value: fields[5], Reason: "must be at least 1"}
+	}
+	return g, nil
+}
+
+// FEN returns the FEN describing the position of the game.
+// Returns an error if the board is not 8 x 8 or holds a piece that FEN cannot describe.
+func (g *Game) FEN() (string, error) {
+	if n, m := g.Board.Size(); n != fenSize || m != fenSize {
+		return "", fmt.Errorf("invalid board size for FEN: %dx%d", n, m)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < fenSize; i++ {
+		if i > 0 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for j := 0; j < fenSize; j++ {
+			piece, ok := g.Board.At(i, j)
+			if !ok {
+				empty++
+				continue
+			}
+			// write the number of the empty squares before the piece
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			letter, err := fenLetter(piece)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteByte(letter)
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+	}
+
+	side := "w"
+	if g.SideToMove == Black {
+		side = "b"
+	}
+	enPassant := "-"
+	if g.EnPassant != nil {
+		enPassant = squareName(*g.EnPassant)
+	}
+	return fmt.Sprintf("%s %s %v %s %d %d", sb.String(), side, g.Castling, enPassant, g.HalfmoveClock, g.FullmoveNumber), nil
+}
+
+// parsePlacement parses the piece placement field, the ranks from 8 to 1 separated by '/'.
+func parsePlacement(placement string) (*Board, error) {
+	fail := func(format string, args ...any) error {
+		return &FENError{Field: FENPlacement, Value: placement, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != fenSize {
+		return nil, fail("expected %d ranks, got %d", fenSize, len(ranks))
+	}
+	board, err := NewBoard(fenSize, fenSize)
+	if err != nil {
+		return nil, err
+	}
+	kings := map[Color]int{}
+	for i, rank := range ranks {
+		j := 0
+		previousDigit := false
+		for _, c := range []byte(rank) {
+			// a digit is the number of empty squares, two digits in a row are not allowed
+			if c >= '1' && c <= '8' {
+				if previousDigit {
+					return nil, fail("rank %d has consecutive digits", fenSize-i)
+				}
+				previousDigit = true
+				j += int(c - '0')
+				continue
+			}
+			previousDigit = false
+			piece, ok := fenPiece(c)
+			if !ok {
+				return nil, fail("rank %d has an invalid character %q", fenSize-i, c)
+			}
+			if j >= fenSize {
+				return nil, fail("rank %d has more than %d squares", fenSize-i, fenSize)
+			}
+			if _, ok := piece.Piece.(PawnPiece); ok && (i == 0 || i == fenSize-1) {
+				return nil, fail("rank %d has a pawn", fenSize-i)
+			}
+			if _, ok := piece.Piece.(KingPiece); ok {
+				kings[piece.Color]++
+			}
+			if err := board.Place(i, j, piece.Piece, piece.Color); err != nil {
+				return nil, fail("%v", err)
+			}
+			j++
+		}
+		if j != fenSize {
+			return nil, fail("rank %d has %d squares instead of %d", fenSize-i, j, fenSize)
+		}
+	}
+	for _, color := range []Color{White, Black} {
+		if kings[color] != 1 {
+			return nil, fail("%v has %d kings instead of 1", color, kings[color])
+		}
+	}
+	return board, nil
+}
+
+// parseCastling parses the castling field, "-" or the letters of the rights in the order "KQkq".
+func (g *Game) parseCastling(castling string) (CastlingRights, error) {
+	fail := func(format string, args ...any) error {
+		return &FENError{Field: FENCastling, Value: castling, Reason: fmt.Sprintf(format, args...)}
+	}
+	if castling == "-" {
+		return 0, nil
+	}
+	if castling == "" {
+		return 0, fail(`must be "-" or a subset of "KQkq"`)
+	}
+
+	var rights CastlingRights
+	next := 0
+	for _, c := range []byte(castling) {
+		// find the letter after the letters already parsed, so that they are in order and not repeated
+		found := false
+		for next < len(castlingLetters) {
+			l := castlingLetters[next]
+			next++
+			if l.letter == c {
+				rights |= l.right
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fail(`must be "-" or a subset of "KQkq" in this order`)
+		}
+	}
+
+	// a castling right is lost once the king or the rook moves from its starting square
+	for _, castle := range castles {
+		if rights&castle.right == 0 {
+			continue
+		}
+		king, kingOK := g.Board.At(castle.king.X, castle.king.Y)
+		rook, rookOK := g.Board.At(castle.rookFrom.X, castle.rookFrom.Y)
+		if !kingOK || king.Piece != (KingPiece{}) || king.Color != castle.color ||
+			!rookOK || rook.Piece != (RookPiece{}) || rook.Color != castle.color {
+			return 0, fail("%v needs the king on %s and the rook on %s", castle.right, squareName(castle.king), squareName(castle.rookFrom))
+		}
+	}
+	return rights, nil
+}
+
+// parseEnPassant parses the en passant field, "-" or the square behind the pawn that has just moved two squares.
+func (g *Game) parseEnPassant(enPassant string) (*Position, error) {
+	fail := func(format string, args ...any) error {
+		return &FENError{Field: FENEnPassant, Value: enPassant, Reason: fmt.Sprintf(format, args...)}
+	}
+	if enPassant == "-" {
+		return nil, nil
+	}
+	square, err := parseSquare(enPassant)
+	if err != nil {
+		return nil, fail("%v", err)
+	}
+
+	// the pawn of the other side has moved from behind the square to in front of it
+	mover := g.SideToMove.Opponent()
+	forward := -1
+	if mover == Black {
+		forward = 1
+	}
+	row := fenSize - 3
+	if mover == Black {
+		row = 2
+	}
+	if square.X != row {
+		return nil, fail("must be on rank %d when %v is to move", fenSize-row, g.SideToMove)
+	}
+	if _, occupied := g.Board.At(square.X, square.Y); occupied {
+		return nil, fail("the square is occupied")
+	}
+	if _, occupied := g.Board.At(square.X-forward, square.Y); occupied {
+		return nil, fail("the starting square of the pawn is occupied")
+	}
+	if pawn, ok := g.Board.At(square.X+forward, square.Y); !ok || pawn.Piece != (PawnPiece{Color: mover}) || pawn.Color != mover {
+		return nil, fail("no %v pawn in front of the square", mover)
+	}
+	return &square, nil
+}
+
+// parseCounter parses a non-negative decimal number without a sign or leading zeros.
+func parseCounter(s string) (int, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("must be a non-negative decimal number")
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("must be a non-negative decimal number")
+	}
+	return n, nil
+}
+
+// king returns the position of the king of the color.
+// The board of the game always has one king of each color.
+func (g *Game) king(color Color) Position {
+	for i, piece := range g.Board.squares {
+		if piece.Piece == (KingPiece{}) && piece.Color == color {
+			return Position{X: i / g.Board.m, Y: i % g.Board.m}
+		}
+	}
+	return Position{X: -1, Y: -1}
+}
+
+// fenPiece returns the piece of the FEN letter, upper case letters are white and lower case letters are black.
+func fenPiece(c byte) (BoardPiece, bool) {
+	color := White
+	if c >= 'a' && c <= 'z' {
+		color = Black
+		c -= 'a' - 'A'
+	}
+	var piece Piece
+	switch c {
+	case 'K':
+		piece = KingPiece{}
+	case 'Q':
+		piece = QueenPiece{}
+	case 'R':
+		piece = RookPiece{}
+	case 'B':
+		piece = BishopPiece{}
+	case 'N':
+		piece = KnightPiece{}
+	case 'P':
+		piece = PawnPiece{Color: color}
+	default:
+		return BoardPiece{}, false
+	}
+	return BoardPiece{Piece: piece, Color: color}, true
+}
+
+// fenLetter returns the FEN letter of the piece, see fenPiece.
+func fenLetter(piece BoardPiece) (byte, error) {
+	var letter byte
+	switch piece.Piece.(type) {
+	case KingPiece:
+		letter = 'K'
+	case QueenPiece:
+		letter = 'Q'
+	case RookPiece:
+		letter = 'R'
+	case BishopPiece:
+		letter = 'B'
+	case KnightPiece:
+		letter = 'N'
+	case PawnPiece:
+		letter = 'P'
+	default:
+		return 0, fmt.Errorf("piece %s has no FEN letter", piece.Piece.Name())
+	}
+	if piece.Color == Black {
+		letter += 'a' - 'A'
+	}
+	return letter, nil
+}
+
+// parseSquare parses the name of a square of the 8 x 8 board, e.g. "e3".
+func parseSquare(name string) (Position, error) {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return Position{}, fmt.Errorf("invalid square %q", name)
+	}
+	return Position{X: fenSize - int(name[1]-'0'), Y: int(name[0] - 'a')}, nil
+}
+
+// squareName returns the name of the square of the 8 x 8 board, see parseSquare.
+func squareName(square Position) string {
+	return string([]byte{byte('a' + square.Y), byte('0' + fenSize - square.X)})
+}
@@ -0,0 +1,131 @@
+package chessboard_test
+
+import (
+	"errors"
+	"testing"
+
+	"dpb03/pkg/chessboard"
+)
+
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		chessboard.StartFEN,
+		"r3k2r/8/8/8/8/8/8/R3K2R b Kq - 12 40",
+		// black has just moved the pawn from d7 to d5
+		"rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3",
+		// white has just moved the pawn from c2 to c4
+		"4k3/8/8/8/1pP5/8/8/4K3 b - c3 0 1",
+		"8/8/8/8/8/8/8/k6K w - - 99 1000",
+	}
+	for _, fen := range fens {
+		game, err := chessboard.ParseFEN(fen)
+		if err != nil {
+			t.Errorf("ParseFEN(%q) failed: %v", fen, err)
+			continue
+		}
+		if got, err := game.FEN(); err != nil || got != fen {
+			t.Errorf("FEN() of ParseFEN(%q) = %q, %v", fen, got, err)
+		}
+	}
+}
+
+func TestParseFEN(t *testing.T) {
+	game, err := chessboard.ParseFEN("rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w Kq d6 0 3")
+	if err != nil {
+		t.Fatalf("ParseFEN() failed: %v", err)
+	}
+	if game.SideToMove != chessboard.White {
+		t.Errorf("SideToMove = %v, want white", game.SideToMove)
+	}
+	if want := chessboard.WhiteKingside | chessboard.BlackQueenside; game.Castling != want {
+		t.Errorf("Castling = %v, want %v", game.Castling, want)
+	}
+	if want := (chessboard.Position{X: 2, Y: 3}); game.EnPassant == nil || *game.EnPassant != want {
+		t.Errorf("EnPassant = %v, want %v", game.EnPassant, want)
+	}
+	if game.HalfmoveClock != 0 || game.FullmoveNumber != 3 {
+		t.Errorf("HalfmoveClock, FullmoveNumber = %d, %d, want 0, 3", game.HalfmoveClock, game.FullmoveNumber)
+	}
+	// the rank 8 is the row 0 and the file a is the column 0
+	if piece, ok := game.Board.At(0, 4); !ok || piece != (chessboard.BoardPiece{Piece: chessboard.KingPiece{}, Color: chessboard.Black}) {
+		t.Errorf("At(0, 4) = %v, %v, want the black king", piece, ok)
+	}
+	if piece, ok := game.Board.At(3, 4); !ok || piece != (chessboard.BoardPiece{Piece: chessboard.PawnPiece{Color: chessboard.White}, Color: chessboard.White}) {
+		t.Errorf("At(3, 4) = %v, %v, want the white pawn", piece, ok)
+	}
+}
+
+func TestParseFENInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		field chessboard.FENField
+	}{
+		{"empty", "", ""},
+		{"five fields", "4k3/8/8/8/8/8/8/4K3 w - - 0", ""},
+		{"seven fields", "4k3/8/8/8/8/8/8/4K3 w - - 0 1 x", ""},
+		{"two spaces", "4k3/8/8/8/8/8/8/4K3  w - - 0 1", ""},
+
+		{"seven ranks", "4k3/8/8/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"nine ranks", "4k3/8/8/8/8/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"consecutive digits", "4k3/8/8/44/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"nine empty squares", "4k3/8/8/9/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"invalid letter", "4k3/8/8/7x/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"long rank", "4k3/8/8/8p/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"short rank", "4k3/8/8/7/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"pawn on rank 8", "4k2P/8/8/8/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+		{"pawn on rank 1", "4k3/8/8/8/8/8/8/p3K3 w - - 0 1", chessboard.FENPlacement},
+		{"no white king", "4k3/8/8/8/8/8/8/8 w - - 0 1", chessboard.FENPlacement},
+		{"two black kings", "4k2k/8/8/8/8/8/8/4K3 w - - 0 1", chessboard.FENPlacement},
+
+		{"invalid side", "4k3/8/8/8/8/8/8/4K3 x - - 0 1", chessboard.FENSideToMove},
+		{"upper case side", "4k3/8/8/8/8/8/8/4K3 W - - 0 1", chessboard.FENSideToMove},
+		{"check of the side not to move", "4k3/8/8/8/8/8/8/4RK2 w - - 0 1", chessboard.FENSideToMove},
+
+		{"empty castling", "r3k2r/8/8/8/8/8/8/R3K2R w  - 0 1", chessboard.FENCastling},
+		{"invalid castling", "r3k2r/8/8/8/8/8/8/R3K2R w X - 0 1", chessboard.FENCastling},
+		{"castling out of order", "r3k2r/8/8/8/8/8/8/R3K2R w qk - 0 1", chessboard.FENCastling},
+		{"repeated castling", "r3k2r/8/8/8/8/8/8/R3K2R w KK - 0 1", chessboard.FENCastling},
+		{"castling with a dash", "r3k2r/8/8/8/8/8/8/R3K2R w K- - 0 1", chessboard.FENCastling},
+		{"castling without the rook", "r3k2r/8/8/8/8/8/8/R3K3 w K - 0 1", chessboard.FENCastling},
+		{"castling with a moved king", "r3k2r/8/8/8/8/8/8/R4K1R w Q - 0 1", chessboard.FENCastling},
+
+		{"invalid square", "4k3/8/8/8/8/8/8/4K3 w - e9 0 1", chessboard.FENEnPassant},
+		{"en passant on the wrong rank", "4k3/8/8/8/4P3/8/8/4K3 b - e4 0 1", chessboard.FENEnPassant},
+		{"en passant of the side to move", "4k3/8/8/8/4P3/8/8/4K3 w - e3 0 1", chessboard.FENEnPassant},
+		{"en passant without a pawn", "4k3/8/8/8/8/8/8/4K3 b - e3 0 1", chessboard.FENEnPassant},
+		{"en passant with a pawn on the start", chessboard.StartFEN[:len(chessboard.StartFEN)-len("KQkq - 0 1")] + "KQkq d6 0 1", chessboard.FENEnPassant},
+
+		{"negative halfmove clock", "4k3/8/8/8/8/8/8/4K3 w - - -1 1", chessboard.FENHalfmoveClock},
+		{"signed halfmove clock", "4k3/8/8/8/8/8/8/4K3 w - - +1 1", chessboard.FENHalfmoveClock},
+		{"leading zero", "4k3/8/8/8/8/8/8/4K3 w - - 01 1", chessboard.FENHalfmoveClock},
+		{"empty halfmove clock", "4k3/8/8/8/8/8/8/4K3 w - -  1", chessboard.FENHalfmoveClock},
+
+		{"zero fullmove number", "4k3/8/8/8/8/8/8/4K3 w - - 0 0", chessboard.FENFullmoveNumber},
+		{"invalid fullmove number", "4k3/8/8/8/8/8/8/4K3 w - - 0 x", chessboard.FENFullmoveNumber},
+		{"overflowing fullmove number", "4k3/8/8/8/8/8/8/4K3 w - - 0 99999999999999999999", chessboard.FENFullmoveNumber},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, err := chessboard.ParseFEN(test.fen)
+			var fenErr *chessboard.FENError
+			if !errors.As(err, &fenErr) {
+				t.Fatalf("ParseFEN(%q) = %v, %v, want a *FENError", test.fen, game, err)
+			}
+			if fenErr.Field != test.field {
+				t.Errorf("ParseFEN(%q) error field = %q, want %q: %v", test.fen, fenErr.Field, test.field, err)
+			}
+		})
+	}
+}
+
+func TestFENInvalidBoard(t *testing.T) {
+	board, err := chessboard.NewBoard(4, 4)
+	if err != nil {
+		t.Fatalf("NewBoard(4, 4) failed: %v", err)
+	}
+	game := &chessboard.Game{Board: board, FullmoveNumber: 1}
+	if fen, err := game.FEN(); err == nil {
+		t.Errorf("FEN() of a 4x4 board = %q, want error", fen)
+	}
+}
@@ -0,0 +1,401 @@
+package chessboard
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Move represents a move of a piece of the side to move, e.g. "e2e4".
+// A castling is the two-square move of the king and an en passant capture is the diagonal move of the pawn to the en passant square.
+type Move struct {
+	From, To Position
+	// Promotion is the piece a pawn reaching the last rank is promoted to, nil for the other moves.
+	Promotion Piece
+}
+
+// Move implements the fmt.Stringer interface.
+var _ fmt.Stringer = Move{}
+
+// promotions are the pieces a pawn can be promoted to.
+var promotions = []Piece{QueenPiece{}, RookPiece{}, BishopPiece{}, KnightPiece{}}
+
+// String returns the move in the long algebraic notation of UCI, e.g. "e2e4" or "e7e8q".
+func (m Move) String() string {
+	s := squareName(m.From) + squareName(m.To)
+	if m.Promotion != nil {
+		letter, err := fenLetter(BoardPiece{Piece: m.Promotion, Color: Black})
+		if err != nil {
+			return s + "?"
+		}
+		s += string(letter)
+	}
+	return s
+}
+
+// ParseMove parses a move in the long algebraic notation of UCI, see Move.String.
+// The move is not checked to be legal, see Game.MakeMove.
+func ParseMove(s string) (Move, error) {
+	if len(s) != 4 && len(s) != 5 {
+		return Move{}, fmt.Errorf("invalid move %q", s)
+	}
+	from, err := parseSquare(s[0:2])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move %q: %w", s, err)
+	}
+	to, err := parseSquare(s[2:4])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid move %q: %w", s, err)
+	}
+	m := Move{From: from, To: to}
+	if len(s) == 5 {
+		piece, ok := fenPiece(s[4])
+		if !ok || !slices.Contains(promotions, piece.Piece) || piece.Color != Black {
+			return Move{}, fmt.Errorf("invalid move %q: invalid promotion %q", s, s[4])
+		}
+		m.Promotion = piece.Piece
+	}
+	return m, nil
+}
+
+// undo holds the state of the game before a move, so that the move can be unmade.
+type undo struct {
+	move Move
+	// piece is the piece that moved, before a promotion.
+	piece BoardPiece
+	// captured is the captured piece standing on capturedAt, nil if the move is not a capture.
+	captured       BoardPiece
+	capturedAt     Position
+	castling       CastlingRights
+	enPassant      *Position
+	halfmoveClock  int
+	fullmoveNumber int
+}
+
+// ErrNoMove is returned by UnmakeMove if there is no move to unmake.
+var ErrNoMove = errors.New("no move to unmake")
+
+// InCheck reports whether the king of the side to move is attacked.
+func (g *Game) InCheck() bool {
+	return g.attacked(g.king(g.SideToMove), g.SideToMove.Opponent())
+}
+
+// LegalMoves returns the legal moves of the side to move, including castlings, en passant captures and promotions.
+// The moves that would leave the own king in check, e.g. the moves of the pinned pieces, are not legal.
+// Returns no moves if the side to move is checkmated or stalemated.
+// The game must be on the 8 x 8 board with the standard pieces, see ParseFEN.
+func (g *Game) LegalMoves() []Move {
+	var legal []Move
+	side := g.SideToMove
+	for _, m := range g.pseudoLegalMoves() {
+		g.makeMove(m)
+		if !g.attacked(g.king(side), side.Opponent()) {
+			legal = append(legal, m)
+		}
+		g.unmakeMove()
+	}
+	return legal
+}
+
+// MakeMove makes the move of the side to move, it can be unmade by UnmakeMove.
+// Returns an error if the move is not legal.
+func (g *Game) MakeMove(m Move) error {
+	if !slices.Contains(g.LegalMoves(), m) {
+		return fmt.Errorf("illegal move %v", m)
+	}
+	g.makeMove(m)
+	return nil
+}
+
+// UnmakeMove unmakes the last move made by MakeMove and returns it.
+// Returns ErrNoMove if there is no move to unmake.
+func (g *Game) UnmakeMove() (Move, error) {
+	if len(g.history) == 0 {
+		return Move{}, ErrNoMove
+	}
+	return g.unmakeMove(), nil
+}
+
+// Perft returns the number of the leaf nodes of the tree of the legal moves of the given depth,
+// e.g. 20 for the depth 1 and 197281 for the depth 4 of the starting position.
+// The counts of the well-known positions in moves_test.go validate the move generator.
+// Returns an error if the depth is negative.
+func (g *Game) Perft(depth int) (int, error) {
+	if depth < 0 {
+		return 0, fmt.Errorf("invalid perft depth: %d", depth)
+	}
+	return g.perft(depth), nil
+}
+
+// perft counts the leaf nodes of the depth, the moves of the last depth are counted without being made.
+func (g *Game) perft(depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	moves := g.LegalMoves()
+	if depth == 1 {
+		return len(moves)
+	}
+	nodes := 0
+	for _, m := range moves {
+		g.makeMove(m)
+		nodes += g.perft(depth - 1)
+		g.unmakeMove()
+	}
+	return nodes
+}
+
+// pseudoLegalMoves returns the moves of the side to move that may leave the own king in check.
+// Only the castlings are fully checked, the king must not castle out of, through or into check.
+func (g *Game) pseudoLegalMoves() []Move {
+	var moves []Move
+	b := g.Board
+	for i, piece := range b.squares {
+		if piece.Piece == nil || piece.Color != g.SideToMove {
+			continue
+		}
+		from := Position{X: i / b.m, Y: i % b.m}
+		if _, ok := piece.Piece.(PawnPiece); ok {
+			moves = g.pawnMoves(moves, from)
+			continue
+		}
+
+		// the other pieces move to the squares they attack, unless a piece of the same color stands there
+		rays, err := piece.Piece.Rays(b.n, b.m, from.X, from.Y)
+		if err != nil {
+			continue
+		}
+		for _, ray := range rays {
+			for _, to := range ray {
+				target, occupied := b.At(to.X, to.Y)
+				if !occupied || target.Color != piece.Color {
+					moves = append(moves, Move{From: from, To: to})
+				}
+				if occupied {
+					break
+				}
+			}
+		}
+		if piece.Piece == (KingPiece{}) {
+			moves = g.castlingMoves(moves, from)
+		}
+	}
+	return moves
+}
+
+// pawnMoves appends the pushes and the captures of the pawn on from to the moves.
+func (g *Game) pawnMoves(moves []Move, from Position) []Move {
+	b := g.Board
+	color := g.SideToMove
+	forward, startRow, lastRow := -1, fenSize-2, 0
+	if color == Black {
+		forward, startRow, lastRow = 1, 1, fenSize-1
+	}
+
+	// add the move, or a move for every promotion on the last rank
+	add := func(to Position) {
+		if to.X != lastRow {
+			moves = append(moves, Move{From: from, To: to})
+			return
+		}
+		for _, promotion := range promotions {
+			moves = append(moves, Move{From: from, To: to, Promotion: promotion})
+		}
+	}
+
+	// one square forward, and two squares from the starting rank, to empty squares
+	one := Position{X: from.X + forward, Y: from.Y}
+	if _, occupied := b.At(one.X, one.Y); b.contains(one.X, one.Y) && !occupied {
+		add(one)
+		two := Position{X: from.X + 2*forward, Y: from.Y}
+		if _, occupied := b.At(two.X, two.Y); from.X == startRow && !occupied {
+			add(two)
+		}
+	}
+	// diagonally forward to capture an opponent piece or en passant
+	for _, side := range []int{-1, 1} {
+		to := Position{X: from.X + forward, Y: from.Y + side}
+		if !b.contains(to.X, to.Y) {
+			continue
+		}
+		target, occupied := b.At(to.X, to.Y)
+		if (occupied && target.Color != color) || (g.EnPassant != nil && *g.EnPassant == to) {
+			add(to)
+		}
+	}
+	return moves
+}
+
+// castlingMoves appends the castlings of the king on from to the moves.
+func (g *Game) castlingMoves(moves []Move, from Position) []Move {
+	b := g.Board
+	opponent := g.SideToMove.Opponent()
+	for _, castle := range castles {
+		if g.Castling&castle.right == 0 || castle.color != g.SideToMove || castle.king != from {
+			continue
+		}
+		rook, ok := b.At(castle.rookFrom.X, castle.rookFrom.Y)
+		if !ok || rook.Piece != (RookPiece{}) || rook.Color != castle.color {
+			continue
+		}
+		// the squares between the king and the rook must be empty
+		empty := true
+		for y := min(from.Y, castle.rookFrom.Y) + 1; y < max(from.Y, castle.rookFrom.Y); y++ {
+			if _, occupied := b.At(from.X, y); occupied {
+				empty = false
+				break
+			}
+		}
+		if !empty {
+			continue
+		}
+		// the king must not be in check and must not pass through or land on an attacked square
+		step := 1
+		if castle.kingTo.Y < from.Y {
+			step = -1
+		}
+		safe := true
+		for y := from.Y; y != castle.kingTo.Y+step; y += step {
+			if g.attacked(Position{X: from.X, Y: y}, opponent) {
+				safe = false
+				break
+			}
+		}
+		if safe {
+			moves = append(moves, Move{From: from, To: castle.kingTo})
+		}
+	}
+	return moves
+}
+
+// attacked reports whether any piece of the color attacks the square, looking from the square outwards
+// for the pieces that could attack it.
+func (g *Game) attacked(square Position, color Color) bool {
+	b := g.Board
+	// isPiece reports whether the piece of the color stands on (x, y)
+	isPiece := func(x, y int, piece Piece) bool {
+		p, ok := b.At(x, y)
+		return ok && p.Color == color && p.Piece == piece
+	}
+
+	// a pawn attacks the square from behind it, seen from the side of the pawn
+	behind := 1
+	if color == Black {
+		behind = -1
+	}
+	pawn := PawnPiece{Color: color}
+	if isPiece(square.X+behind, square.Y-1, pawn) || isPiece(square.X+behind, square.Y+1, pawn) {
+		return true
+	}
+	for _, offset := range knightOffsets {
+		if isPiece(square.X+offset[0], square.Y+offset[1], KnightPiece{}) {
+			return true
+		}
+	}
+	for _, direction := range allDirections {
+		if isPiece(square.X+direction[0], square.Y+direction[1], KingPiece{}) {
+			return true
+		}
+	}
+	// the sliding pieces attack the square from the first occupied square of every ray
+	for _, direction := range allDirections {
+		slider := Piece(BishopPiece{})
+		if direction[0] == 0 || direction[1] == 0 {
+			slider = RookPiece{}
+		}
+		x, y := square.X+direction[0], square.Y+direction[1]
+		for b.contains(x, y) {
+			if p, ok := b.At(x, y); ok {
+				if p.Color == color && (p.Piece == slider || p.Piece == (QueenPiece{})) {
+					return true
+				}
+				break
+			}
+			x, y = x+direction[0], y+direction[1]
+		}
+	}
+	return false
+}
+
+// makeMove makes the move without checking it and pushes the state before the move to the history.
+func (g *Game) makeMove(m Move) {
+	b := g.Board
+	piece, _ := b.At(m.From.X, m.From.Y)
+	u := undo{
+		move:           m,
+		piece:          piece,
+		capturedAt:     m.To,
+		castling:       g.Castling,
+		enPassant:      g.EnPassant,
+		halfmoveClock:  g.HalfmoveClock,
+		fullmoveNumber: g.FullmoveNumber,
+	}
+	_, isPawn := piece.Piece.(PawnPiece)
+	// a pawn moving diagonally to the en passant square captures the pawn next to it
+	if isPawn && g.EnPassant != nil && m.To == *g.EnPassant && m.From.Y != m.To.Y {
+		u.capturedAt = Position{X: m.From.X, Y: m.To.Y}
+	}
+	u.captured, _ = b.At(u.capturedAt.X, u.capturedAt.Y)
+
+	b.squares[b.index(u.capturedAt.X, u.capturedAt.Y)] = BoardPiece{}
+	b.squares[b.index(m.From.X, m.From.Y)] = BoardPiece{}
+	moved := piece
+	if m.Promotion != nil {
+		moved.Piece = m.Promotion
+	}
+	b.squares[b.index(m.To.X, m.To.Y)] = moved
+
+	for _, castle := range castles {
+		// a castling also moves the rook
+		if piece.Piece == (KingPiece{}) && m.From == castle.king && m.To == castle.kingTo {
+			b.squares[b.index(castle.rookTo.X, castle.rookTo.Y)] = b.squares[b.index(castle.rookFrom.X, castle.rookFrom.Y)]
+			b.squares[b.index(castle.rookFrom.X, castle.rookFrom.Y)] = BoardPiece{}
+		}
+		// the right is lost once the king or the rook leaves its starting square or the rook is captured
+		if m.From == castle.king || m.From == castle.rookFrom || m.To == castle.rookFrom {
+			g.Castling &^= castle.right
+		}
+	}
+
+	g.EnPassant = nil
+	if isPawn && (m.To.X-m.From.X == 2 || m.From.X-m.To.X == 2) {
+		g.EnPassant = &Position{X: (m.From.X + m.To.X) / 2, Y: m.From.Y}
+	}
+	g.HalfmoveClock++
+	if isPawn || u.captured.Piece != nil {
+		g.HalfmoveClock = 0
+	}
+	if g.SideToMove == Black {
+		g.FullmoveNumber++
+	}
+	g.SideToMove = g.SideToMove.Opponent()
+	g.history = append(g.history, u)
+}
+
+// unmakeMove pops the last move from the history, restores the state before it and returns the move.
+func (g *Game) unmakeMove() Move {
+	u := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	b := g.Board
+	m := u.move
+
+	b.squares[b.index(m.To.X, m.To.Y)] = BoardPiece{}
+	b.squares[b.index(m.From.X, m.From.Y)] = u.piece
+	if u.captured.Piece != nil {
+		b.squares[b.index(u.capturedAt.X, u.capturedAt.Y)] = u.captured
+	}
+	for _, castle := range castles {
+		// move the rook of a castling back
+		if u.piece.Piece == (KingPiece{}) && m.From == castle.king && m.To == castle.kingTo {
+			b.squares[b.index(castle.rookFrom.X, castle.rookFrom.Y)] = b.squares[b.index(castle.rookTo.X, castle.rookTo.Y)]
+			b.squares[b.index(castle.rookTo.X, castle.rookTo.Y)] = BoardPiece{}
+		}
+	}
+
+	g.Castling = u.castling
+	g.EnPassant = u.enPassant
+	g.HalfmoveClock = u.halfmoveClock
+	g.FullmoveNumber = u.fullmoveNumber
+	g.SideToMove = g.SideToMove.Opponent()
+	return m
+}
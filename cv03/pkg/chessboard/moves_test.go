@@ -0,0 +1,63 @@
+package chessboard_test
+
+import (
+	"errors"
+	"testing"
+
+	"dpb03/pkg/chessboard"
+)
+
+// perftPositions are the well-known perft test positions with their reference node counts,
+// see https://www.chessprogramming.org/Perft_Results.
+var perftPositions = []struct {
+	name  string
+	fen   string
+	depth int
+	nodes int
+}{
+	{"start", chessboard.StartFEN, 4, 197281},
+	{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 3, 97862},
+	{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 5, 674624},
+	{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 4, 422333},
+	{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 3, 62379},
+	{"position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", 3, 89890},
+}
+
+func TestPerft(t *testing.T) {
+	for _, position := range perftPositions {
+		t.Run(position.name, func(t *testing.T) {
+			game, err := chessboard.ParseFEN(position.fen)
+			if err != nil {
+				t.Fatalf("ParseFEN(%q) failed: %v", position.fen, err)
+			}
+			nodes, err := game.Perft(position.depth)
+			if err != nil {
+				t.Fatalf("Perft(%d) failed: %v", position.depth, err)
+			}
+			if nodes != position.nodes {
+				t.Errorf("Perft(%d) = %d, want %d", position.depth, nodes, position.nodes)
+			}
+			// every move made by the perft must be unmade
+			fen, err := game.FEN()
+			if err != nil {
+				t.Fatalf("FEN() after Perft(%d) failed: %v", position.depth, err)
+			}
+			if fen != position.fen {
+				t.Errorf("FEN() after Perft(%d) = %q, want %q", position.depth, fen, position.fen)
+			}
+		})
+	}
+}
+
+func TestPerftInvalidDepth(t *testing.T) {
+	game, err := chessboard.ParseFEN(chessboard.StartFEN)
+	if err != nil {
+		t.Fatalf("ParseFEN(StartFEN) failed: %v", err)
+	}
+	if _, err := game.Perft(-1); err == nil {
+		t.Errorf("Perft(-1) succeeded, want error")
+	}
+	if _, err := game.UnmakeMove(); !errors.Is(err, chessboard.ErrNoMove) {
+		t.Errorf("UnmakeMove() = %v, want ErrNoMove", err)
+	}
+}
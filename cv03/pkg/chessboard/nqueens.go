@@ -0,0 +1,179 @@
+package chessboard
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// MaxNQueensSize is the largest board size supported by the N-Queens solver.
+// The columns and the diagonals of the board are tracked in bitmasks of 64 bits.
+const MaxNQueensSize = 32
+
+// Position represents a square of the chessboard, X is the row and Y is the column the same as in Queen.
+type Position struct {
+	X, Y int
+}
+
+// NQueensSolution is a solution of the N-Queens problem.
+// The value at index i is the column of the queen in the row i.
+type NQueensSolution []int
+
+// Queens returns the positions of the queens ordered by rows.
+func (s NQueensSolution) Queens() []Position {
+	queens := make([]Position, 0, len(s))
+	for x, y := range s {
+		queens = append(queens, Position{X: x, Y: y})
+	}
+	return queens
+}
+
+// Board returns a chessboard with the queens of the solution and the squares under attack,
+// using the same symbols as Queen: 'D' for the queens, '*' for the squares under attack and '.' for the empty squares.
+func (s NQueensSolution) Board() ChessBoard {
+	n := len(s)
+	var chessBoard ChessBoard
+	for i := 0; i < n; i++ {
+		var row []string
+		for j := 0; j < n; j++ {
+			// check if a queen is on this board tile
+			if s[i] == j {
+				row = append(row, "D")
+				continue
+			}
+			// check if any queen attacks this board tile
+			attacked := false
+			for queenX, queenY := range s {
+				if i == queenX || j == queenY || i+j == queenX+queenY || i-j == queenX-queenY {
+					attacked = true
+					break
+				}
+			}
+			if attacked {
+				row = append(row, "*")
+				continue
+			}
+			row = append(row, ".")
+		}
+		chessBoard = append(chessBoard, row)
+	}
+	return chessBoard
+}
+
+// SolveNQueens returns the first solution of the N-Queens problem on the n x n board in lexicographic order,
+// placing n queens so that no two queens attack each other. The preplaced queens are kept on their squares.
+// Returns nil if there is no solution, e.g. for n = 2 or n = 3.
+// Returns an error if n is out of range or the preplaced queens are invalid.
+func SolveNQueens(n int, preplaced ...Position) (NQueensSolution, error) {
+	var solution NQueensSolution
+	err := solveNQueens(n, preplaced, func(columns []int) bool {
+		solution = append(NQueensSolution(nil), columns...)
+		// stop after the first solution
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return solution, nil
+}
+
+// AllNQueens returns all solutions of the N-Queens problem on the n x n board in lexicographic order.
+// The preplaced queens are kept on their squares.
+// Returns an error if n is out of range or the preplaced queens are invalid.
+func AllNQueens(n int, preplaced ...Position) ([]NQueensSolution, error) {
+	var solutions []NQueensSolution
+	err := solveNQueens(n, preplaced, func(columns []int) bool {
+		solutions = append(solutions, append(NQueensSolution(nil), columns...))
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return solutions, nil
+}
+
+// CountNQueens returns the number of solutions of the N-Queens problem on the n x n board without storing them,
+// e.g. 92 for n = 8 and 365596 for n = 14. The preplaced queens are kept on their squares.
+// Returns an error if n is out of range or the preplaced queens are invalid.
+func CountNQueens(n int, preplaced ...Position) (int, error) {
+	count := 0
+	err := solveNQueens(n, preplaced, func([]int) bool {
+		count++
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// nQueensSolver is the state of the bitmask backtracking.
+type nQueensSolver struct {
+	// full has the lowest n bits set, one bit for every column.
+	full uint64
+	// fixed is the column of the preplaced queen of every row, -1 if the row has none.
+	fixed []int
+	// columns is the column of the queen of every row placed so far.
+	columns []int
+	// visit is called with every solution found, the search stops when it returns false.
+	visit func(columns []int) bool
+}
+
+// solveNQueens validates the input and calls visit with every solution in lexicographic order until it returns false.
+func solveNQueens(n int, preplaced []Position, visit func(columns []int) bool) error {
+	// check if the board size is valid
+	if n < 1 || n > MaxNQueensSize {
+		return fmt.Errorf("invalid board size: %d, must be in the range [1, %d]", n, MaxNQueensSize)
+	}
+
+	fixed := make([]int, n)
+	for i := range fixed {
+		fixed[i] = -1
+	}
+	for i, queen := range preplaced {
+		// check if the queen coordinates are valid - they must be in the range [0, n-1]
+		if queen.X < 0 || queen.X >= n || queen.Y < 0 || queen.Y >= n {
+			return fmt.Errorf("invalid queen coordinates: (%d, %d)", queen.X, queen.Y)
+		}
+		// check if the queen is not attacked by any of the queens placed before
+		for _, other := range preplaced[:i] {
+			if queen.X == other.X || queen.Y == other.Y || queen.X+queen.Y == other.X+other.Y || queen.X-queen.Y == other.X-other.Y {
+				return fmt.Errorf("queens on (%d, %d) and (%d, %d) attack each other", other.X, other.Y, queen.X, queen.Y)
+			}
+		}
+		fixed[queen.X] = queen.Y
+	}
+
+	s := &nQueensSolver{
+		full:    1<<uint(n) - 1,
+		fixed:   fixed,
+		columns: make([]int, n),
+		visit:   visit,
+	}
+	s.place(0, 0, 0, 0)
+	return nil
+}
+
+// place places the queen of the row and recurses into the next row. Reports false once the search is stopped.
+// The bits of cols are the attacked columns, the bits of left and right are the columns attacked in this row
+// along the diagonals of the queens above, moving one column higher and one column lower with every row.
+func (s *nQueensSolver) place(row int, cols, left, right uint64) bool {
+	if row == len(s.columns) {
+		return s.visit(s.columns)
+	}
+
+	free := s.full &^ (cols | left | right)
+	// a preplaced queen is the only candidate of its row
+	if y := s.fixed[row]; y >= 0 {
+		free &= 1 << uint(y)
+	}
+	for free != 0 {
+		// take the lowest free column
+		bit := free & -free
+		free &^= bit
+		s.columns[row] = bits.TrailingZeros64(bit)
+		if !s.place(row+1, cols|bit, (left|bit)<<1&s.full, (right|bit)>>1) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package chessboard_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"dpb03/pkg/chessboard"
+)
+
+// assertNonAttacking fails the test if two queens of the solution attack each other.
+func assertNonAttacking(t *testing.T, solution chessboard.NQueensSolution) {
+	t.Helper()
+	queens := solution.Queens()
+	for i, queen := range queens {
+		for _, other := range queens[:i] {
+			if queen.Y == other.Y || queen.X+queen.Y == other.X+other.Y || queen.X-queen.Y == other.X-other.Y {
+				t.Errorf("queens %v and %v of %v attack each other", other, queen, solution)
+			}
+		}
+	}
+}
+
+func TestCountNQueens(t *testing.T) {
+	tests := []struct {
+		n, want int
+	}{
+		{1, 1}, {2, 0}, {3, 0}, {4, 2}, {5, 10}, {6, 4}, {7, 40}, {8, 92}, {9, 352}, {10, 724}, {14, 365596},
+	}
+	for _, test := range tests {
+		if test.n > 10 && testing.Short() {
+			continue
+		}
+		if count, err := chessboard.CountNQueens(test.n); err != nil || count != test.want {
+			t.Errorf("CountNQueens(%d) = %d, %v, want %d", test.n, count, err, test.want)
+		}
+	}
+}
+
+func TestSolveNQueens(t *testing.T) {
+	tests := []struct {
+		n    int
+		want chessboard.NQueensSolution
+	}{
+		{1, chessboard.NQueensSolution{0}},
+		{2, nil},
+		{3, nil},
+		{4, chessboard.NQueensSolution{1, 3, 0, 2}},
+		{8, chessboard.NQueensSolution{0, 4, 7, 5, 2, 6, 1, 3}},
+	}
+	for _, test := range tests {
+		solution, err := chessboard.SolveNQueens(test.n)
+		if err != nil || !reflect.DeepEqual(solution, test.want) {
+			t.Errorf("SolveNQueens(%d) = %v, %v, want %v", test.n, solution, err, test.want)
+		}
+	}
+}
+
+func TestAllNQueens(t *testing.T) {
+	solutions, err := chessboard.AllNQueens(8)
+	if err != nil || len(solutions) != 92 {
+		t.Fatalf("AllNQueens(8) = %d solutions, %v, want 92", len(solutions), err)
+	}
+	for i, solution := range solutions {
+		assertNonAttacking(t, solution)
+		// the solutions are distinct and in lexicographic order
+		if i > 0 && slices.Compare(solutions[i-1], solution) >= 0 {
+			t.Errorf("AllNQueens(8)[%d] = %v after %v, want lexicographic order", i, solution, solutions[i-1])
+		}
+	}
+	if solutions, err := chessboard.AllNQueens(3); err != nil || solutions != nil {
+		t.Errorf("AllNQueens(3) = %v, %v, want no solutions", solutions, err)
+	}
+}
+
+func TestNQueensPreplaced(t *testing.T) {
+	all, err := chessboard.AllNQueens(8)
+	if err != nil {
+		t.Fatalf("AllNQueens(8) failed: %v", err)
+	}
+	tests := []struct {
+		name      string
+		preplaced []chessboard.Position
+	}{
+		{"corner", []chessboard.Position{{X: 0, Y: 0}}},
+		{"middle", []chessboard.Position{{X: 3, Y: 4}}},
+		{"two queens", []chessboard.Position{{X: 7, Y: 3}, {X: 0, Y: 2}}},
+		// the queens do not attack each other, but no solution contains both
+		{"no solution", []chessboard.Position{{X: 0, Y: 0}, {X: 1, Y: 2}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the solutions with the preplaced queens are those of all solutions having them
+			var want []chessboard.NQueensSolution
+			for _, solution := range all {
+				kept := true
+				for _, queen := range test.preplaced {
+					kept = kept && solution[queen.X] == queen.Y
+				}
+				if kept {
+					want = append(want, solution)
+				}
+			}
+
+			solutions, err := chessboard.AllNQueens(8, test.preplaced...)
+			if err != nil || !reflect.DeepEqual(solutions, want) {
+				t.Errorf("AllNQueens(8, %v) = %v, %v, want %v", test.preplaced, solutions, err, want)
+			}
+			if count, err := chessboard.CountNQueens(8, test.preplaced...); err != nil || count != len(want) {
+				t.Errorf("CountNQueens(8, %v) = %d, %v, want %d", test.preplaced, count, err, len(want))
+			}
+			var first chessboard.NQueensSolution
+			if len(want) > 0 {
+				first = want[0]
+			}
+			if solution, err := chessboard.SolveNQueens(8, test.preplaced...); err != nil || !reflect.DeepEqual(solution, first) {
+				t.Errorf("SolveNQueens(8, %v) = %v, %v, want %v", test.preplaced, solution, err, first)
+			}
+		})
+	}
+}
+
+func TestNQueensInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int
+		preplaced []chessboard.Position
+	}{
+		{"zero size", 0, nil},
+		{"negative size", -1, nil},
+		{"size above maximum", chessboard.MaxNQueensSize + 1, nil},
+		// the queens are checked before searching, so the largest board is not solved
+		{"negative row", chessboard.MaxNQueensSize, []chessboard.Position{{X: -1, Y: 0}}},
+		{"negative column", 8, []chessboard.Position{{X: 0, Y: -1}}},
+		{"row outside", 8, []chessboard.Position{{X: 8, Y: 0}}},
+		{"column outside", 8, []chessboard.Position{{X: 0, Y: 8}}},
+		{"same square", 8, []chessboard.Position{{X: 2, Y: 2}, {X: 2, Y: 2}}},
+		{"same row", 8, []chessboard.Position{{X: 2, Y: 2}, {X: 2, Y: 5}}},
+		{"same column", 8, []chessboard.Position{{X: 2, Y: 2}, {X: 5, Y: 2}}},
+		{"diagonal", 8, []chessboard.Position{{X: 2, Y: 2}, {X: 5, Y: 5}}},
+		{"anti-diagonal", 8, []chessboard.Position{{X: 0, Y: 7}, {X: 7, Y: 0}}},
+		{"attacking third queen", 8, []chessboard.Position{{X: 0, Y: 0}, {X: 1, Y: 2}, {X: 3, Y: 3}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if solution, err := chessboard.SolveNQueens(test.n, test.preplaced...); err == nil {
+				t.Errorf("SolveNQueens(%d, %v) = %v, want error", test.n, test.preplaced, solution)
+			}
+			if solutions, err := chessboard.AllNQueens(test.n, test.preplaced...); err == nil {
+				t.Errorf("AllNQueens(%d, %v) = %v, want error", test.n, test.preplaced, solutions)
+			}
+			if count, err := chessboard.CountNQueens(test.n, test.preplaced...); err == nil {
+				t.Errorf("CountNQueens(%d, %v) = %d, want error", test.n, test.preplaced, count)
+			}
+		})
+	}
+}
+
+func TestNQueensSolutionBoard(t *testing.T) {
+	solution := chessboard.NQueensSolution{1, 3, 0, 2}
+	want := []chessboard.Position{{X: 0, Y: 1}, {X: 1, Y: 3}, {X: 2, Y: 0}, {X: 3, Y: 2}}
+	if queens := solution.Queens(); !reflect.DeepEqual(queens, want) {
+		t.Errorf("Queens() = %v, want %v", queens, want)
+	}
+	// every square without a queen is attacked in a solution
+	if board := solution.Board().String(); board != "*D**\n***D\nD***\n**D*\n" {
+		t.Errorf("Board() = %q, want the queens with all other squares attacked", board)
+	}
+}
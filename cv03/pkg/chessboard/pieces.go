@@ -0,0 +1,320 @@
+package chessboard
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Color represents the color of a piece.
+type Color int
+
+const (
+	// White pieces start at the bottom of the board, white pawns move towards the row 0.
+	White Color = iota
+	// Black pieces start at the top of the board, black pawns move towards the row n-1.
+	Black
+)
+
+// Color implements the fmt.Stringer interface.
+var _ fmt.Stringer = White
+
+// String returns the name of the color.
+func (c Color) String() string {
+	switch c {
+	case White:
+		return "white"
+	case Black:
+		return "black"
+	default:
+		return fmt.Sprintf("Color(%d)", int(c))
+	}
+}
+
+// Opponent returns the color of the other side.
+func (c Color) Opponent() Color {
+	if c == White {
+		return Black
+	}
+	return White
+}
+
+// Piece represents a chess piece that attacks squares of a rectangular n x m board.
+// The symbols of the pieces are the initials of their czech names, the same as 'D' of the queen.
+type Piece interface {
+	// Name returns the name of the piece, e.g. "queen".
+	Name() string
+	// Symbol returns the symbol of the piece on the chessboard, e.g. "D" for the queen.
+	Symbol() string
+	// Attacks returns the squares attacked by the piece standing on (x, y) of the n x m board ordered by rows and columns.
+	// Returns an error if the coordinates are not on the board.
+	Attacks(n, m, x, y int) ([]Position, error)
+	// Rays returns the squares attacked by the piece standing on (x, y) of the n x m board grouped into rays,
+	// every ray is ordered from the piece outwards so that the squares behind the first blocker can be cut off.
+	// A piece that does not slide has a ray for every attacked square.
+	// Returns an error if the coordinates are not on the board.
+	Rays(n, m, x, y int) ([][]Position, error)
+}
+
+// QueenPiece is the queen, attacking along the rows, the columns and the diagonals.
+type QueenPiece struct{}
+
+// RookPiece is the rook, attacking along the rows and the columns.
+type RookPiece struct{}
+
+// BishopPiece is the bishop, attacking along the diagonals.
+type BishopPiece struct{}
+
+// KnightPiece is the knight, attacking the squares two rows and one column or one row and two columns away.
+type KnightPiece struct{}
+
+// KingPiece is the king, attacking the adjacent squares.
+type KingPiece struct{}
+
+// PawnPiece is the pawn, attacking the two squares diagonally in front of it.
+// The front of the pawn depends on its color, see White and Black.
+type PawnPiece struct {
+	Color Color
+}
+
+// Interface guards for Piece.
+var (
+	_ Piece = QueenPiece{}
+	_ Piece = RookPiece{}
+	_ Piece = BishopPiece{}
+	_ Piece = KnightPiece{}
+	_ Piece = KingPiece{}
+	_ Piece = PawnPiece{}
+)
+
+// directions and offsets of the pieces as (row, column) steps
+var (
+	straightDirections = [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	diagonalDirections = [][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+	allDirections      = append(slices.Clone(straightDirections), diagonalDirections...)
+	knightOffsets      = [][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+)
+
+// Name implements Piece.
+func (QueenPiece) Name() string {
+	return "queen"
+}
+
+// Symbol implements Piece.
+func (QueenPiece) Symbol() string {
+	return "D"
+}
+
+// Attacks implements Piece.
+func (p QueenPiece) Attacks(n, m, x, y int) ([]Position, error) {
+	return flattenRays(p.Rays(n, m, x, y))
+}
+
+// Rays implements Piece.
+func (p QueenPiece) Rays(n, m, x, y int) ([][]Position, error) {
+	return rays(p, n, m, x, y, allDirections, true)
+}
+
+// Name implements Piece.
+func (RookPiece) Name() string {
+	return "rook"
+}
+
+// Symbol implements Piece.
+func (RookPiece) Symbol() string {
+	return "V"
+}
+
+// Attacks implements Piece.
+func (p RookPiece) Attacks(n, m, x, y int) ([]Position, error) {
+	return flattenRays(p.Rays(n, m, x, y))
+}
+
+// Rays implements Piece.
+func (p RookPiece) Rays(n, m, x, y int) ([][]Position, error) {
+	return rays(p, n, m, x, y, straightDirections, true)
+}
+
+// Name implements Piece.
+func (BishopPiece) Name() string {
+	return "bishop"
+}
+
+// Symbol implements Piece.
+func (BishopPiece) Symbol() string {
+	return "S"
+}
+
+// Attacks implements Piece.
+func (p BishopPiece) Attacks(n, m, x, y int) ([]Position, error) {
+	return flattenRays(p.Rays(n, m, x, y))
+}
+
+// Rays implements Piece.
+func (p BishopPiece) Rays(n, m, x, y int) ([][]Position, error) {
+	return rays(p, n, m, x, y, diagonalDirections, true)
+}
+
+// Name implements Piece.
+func (KnightPiece) Name() string {
+	return "knight"
+}
+
+// Symbol implements Piece.
+func (KnightPiece) Symbol() string {
+	return "J"
+}
+
+// Attacks implements Piece.
+func (p KnightPiece) Attacks(n, m, x, y int) ([]Position, error) {
+	return flattenRays(p.Rays(n, m, x, y))
+}
+
+// Rays implements Piece.
+func (p KnightPiece) Rays(n, m, x, y int) ([][]Position, error) {
+	return rays(p, n, m, x, y, knightOffsets, false)
+}
+
+// Name implements Piece.
+func (KingPiece) Name() string {
+	return "king"
+}
+
+// Symbol implements Piece.
+func (KingPiece) Symbol() string {
+	return "K"
+}
+
+// Attacks implements Piece.
+func (p KingPiece) Attacks(n, m, x, y int) ([]Position, error) {
+	return flattenRays(p.Rays(n, m, x, y))
+}
+
+// Rays implements Piece.
+func (p KingPiece) Rays(n, m, x, y int) ([][]Position, error) {
+	return rays(p, n, m, x, y, allDirections, false)
+}
+
+// Name implements Piece.
+func (PawnPiece) Name() string {
+	return "pawn"
+}
+
+// Symbol implements Piece.
+func (PawnPiece) Symbol() string {
+	return "P"
+}
+
+// Attacks implements Piece.
+func (p PawnPiece) Attacks(n, m, x, y int) ([]Position, error) {
+	return flattenRays(p.Rays(n, m, x, y))
+}
+
+// Rays implements Piece.
+func (p PawnPiece) Rays(n, m, x, y int) ([][]Position, error) {
+	// check if the color is valid, it decides the direction of the pawn
+	if p.Color != White && p.Color != Black {
+		return nil, fmt.Errorf("invalid pawn color: %v", p.Color)
+	}
+	forward := -1
+	if p.Color == Black {
+		forward = 1
+	}
+	return rays(p, n, m, x, y, [][2]int{{forward, -1}, {forward, 1}}, false)
+}
+
+// rays returns the rays of the squares attacked from (x, y) by moving in the steps.
+// A sliding piece repeats every step until it leaves the board, otherwise every step is made once.
+func rays(piece Piece, n, m, x, y int, steps [][2]int, sliding bool) ([][]Position, error) {
+	// check if the coordinates are valid - they must be in the range [0, n-1] and [0, m-1]
+	if x < 0 || x >= n || y < 0 || y >= m {
+		return nil, fmt.Errorf("invalid %s coordinates: (%d, %d)", piece.Name(), x, y)
+	}
+
+	var rays [][]Position
+	for _, step := range steps {
+		var ray []Position
+		i, j := x+step[0], y+step[1]
+		for i >= 0 && i < n && j >= 0 && j < m {
+			ray = append(ray, Position{X: i, Y: j})
+			if !sliding {
+				break
+			}
+			i, j = i+step[0], j+step[1]
+		}
+		if len(ray) > 0 {
+			rays = append(rays, ray)
+		}
+	}
+	return rays, nil
+}
+
+// flattenRays returns the squares of the rays ordered by rows and columns.
+func flattenRays(rays [][]Position, err error) ([]Position, error) {
+	if err != nil {
+		return nil, err
+	}
+	var attacked []Position
+	for _, ray := range rays {
+		attacked = append(attacked, ray...)
+	}
+	slices.SortFunc(attacked, comparePositions)
+	return attacked, nil
+}
+
+// comparePositions orders the positions by rows and columns.
+func comparePositions(a, b Position) int {
+	if c := cmp.Compare(a.X, b.X); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Y, b.Y)
+}
+
+// AttackBoard takes a piece, a board size and the piece coordinates and returns a chessboard with the piece placed on the board
+// and the squares under attack. The piece is symbolized by its symbol, the squares under attack by '*' and the empty squares by '.'.
+func AttackBoard(piece Piece, n, m, x, y int) (ChessBoard, error) {
+	attacked, err := piece.Attacks(n, m, x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	// create chessboard with empty board tiles
+	chessBoard := make(ChessBoard, n)
+	for i := range chessBoard {
+		chessBoard[i] = make([]string, m)
+		for j := range chessBoard[i] {
+			chessBoard[i][j] = "."
+		}
+	}
+	// mark the board tiles under attack and the piece
+	for _, square := range attacked {
+		chessBoard[square.X][square.Y] = "*"
+	}
+	chessBoard[x][y] = piece.Symbol()
+	return chessBoard, nil
+}
+
+// Rook returns a chessboard with the rook placed on (x, y) and the squares under attack, see AttackBoard.
+func Rook(n, m, x, y int) (ChessBoard, error) {
+	return AttackBoard(RookPiece{}, n, m, x, y)
+}
+
+// Bishop returns a chessboard with the bishop placed on (x, y) and the squares under attack, see AttackBoard.
+func Bishop(n, m, x, y int) (ChessBoard, error) {
+	return AttackBoard(BishopPiece{}, n, m, x, y)
+}
+
+// Knight returns a chessboard with the knight placed on (x, y) and the squares under attack, see AttackBoard.
+func Knight(n, m, x, y int) (ChessBoard, error) {
+	return AttackBoard(KnightPiece{}, n, m, x, y)
+}
+
+// King returns a chessboard with the king placed on (x, y) and the squares under attack, see AttackBoard.
+func King(n, m, x, y int) (ChessBoard, error) {
+	return AttackBoard(KingPiece{}, n, m, x, y)
+}
+
+// Pawn returns a chessboard with the pawn of the color placed on (x, y) and the squares under attack, see AttackBoard.
+func Pawn(n, m, x, y int, color Color) (ChessBoard, error) {
+	return AttackBoard(PawnPiece{Color: color}, n, m, x, y)
+}
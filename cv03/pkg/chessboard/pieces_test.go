@@ -0,0 +1,114 @@
+package chessboard_test
+
+import (
+	"reflect"
+	"testing"
+
+	"dpb03/pkg/chessboard"
+)
+
+func TestAttackBoard(t *testing.T) {
+	tests := []struct {
+		name  string
+		piece chessboard.Piece
+		n, m  int
+		x, y  int
+		want  string
+	}{
+		{"queen", chessboard.QueenPiece{}, 4, 5, 1, 2, ".***.\n**D**\n.***.\n*.*.*\n"},
+		{"rook", chessboard.RookPiece{}, 4, 5, 1, 2, "..*..\n**V**\n..*..\n..*..\n"},
+		{"bishop", chessboard.BishopPiece{}, 4, 5, 1, 2, ".*.*.\n..S..\n.*.*.\n*...*\n"},
+		{"knight", chessboard.KnightPiece{}, 4, 5, 1, 2, "*...*\n..J..\n*...*\n.*.*.\n"},
+		{"king", chessboard.KingPiece{}, 4, 5, 1, 2, ".***.\n.*K*.\n.***.\n.....\n"},
+		{"white pawn", chessboard.PawnPiece{Color: chessboard.White}, 4, 5, 1, 2, ".*.*.\n..P..\n.....\n.....\n"},
+		{"black pawn", chessboard.PawnPiece{Color: chessboard.Black}, 4, 5, 1, 2, ".....\n..P..\n.*.*.\n.....\n"},
+		{"knight in corner", chessboard.KnightPiece{}, 3, 3, 0, 0, "J..\n..*\n.*.\n"},
+		{"king alone", chessboard.KingPiece{}, 1, 1, 0, 0, "K\n"},
+		{"white pawn on last row", chessboard.PawnPiece{Color: chessboard.White}, 2, 2, 0, 0, "P.\n..\n"},
+		{"black pawn on edge", chessboard.PawnPiece{Color: chessboard.Black}, 2, 2, 0, 1, ".P\n*.\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			board, err := chessboard.AttackBoard(test.piece, test.n, test.m, test.x, test.y)
+			if err != nil {
+				t.Fatalf("AttackBoard(%s, %d, %d, %d, %d) failed: %v", test.piece.Name(), test.n, test.m, test.x, test.y, err)
+			}
+			if got := board.String(); got != test.want {
+				t.Errorf("AttackBoard(%s, %d, %d, %d, %d) =\n%s\nwant\n%s", test.piece.Name(), test.n, test.m, test.x, test.y, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAttacks(t *testing.T) {
+	// the squares are ordered by rows and columns, not by rays
+	attacks, err := chessboard.BishopPiece{}.Attacks(3, 3, 1, 1)
+	want := []chessboard.Position{{X: 0, Y: 0}, {X: 0, Y: 2}, {X: 2, Y: 0}, {X: 2, Y: 2}}
+	if err != nil || !reflect.DeepEqual(attacks, want) {
+		t.Errorf("Attacks() = %v, %v, want %v", attacks, err, want)
+	}
+}
+
+func TestRays(t *testing.T) {
+	tests := []struct {
+		name  string
+		piece chessboard.Piece
+		want  [][]chessboard.Position
+	}{
+		{
+			// the rays go from the piece outwards
+			name:  "rook",
+			piece: chessboard.RookPiece{},
+			want:  [][]chessboard.Position{{{X: 1, Y: 0}, {X: 2, Y: 0}}, {{X: 0, Y: 1}, {X: 0, Y: 2}}},
+		},
+		{
+			name:  "bishop",
+			piece: chessboard.BishopPiece{},
+			want:  [][]chessboard.Position{{{X: 1, Y: 1}, {X: 2, Y: 2}}},
+		},
+		{
+			// a piece that does not slide has a ray for every square
+			name:  "king",
+			piece: chessboard.KingPiece{},
+			want:  [][]chessboard.Position{{{X: 1, Y: 0}}, {{X: 0, Y: 1}}, {{X: 1, Y: 1}}},
+		},
+	}
+	for _, test := range tests {
+		if rays, err := test.piece.Rays(3, 3, 0, 0); err != nil || !reflect.DeepEqual(rays, test.want) {
+			t.Errorf("%s Rays(3, 3, 0, 0) = %v, %v, want %v", test.name, rays, err, test.want)
+		}
+	}
+}
+
+func TestAttacksInvalid(t *testing.T) {
+	pieces := []chessboard.Piece{
+		chessboard.QueenPiece{}, chessboard.RookPiece{}, chessboard.BishopPiece{}, chessboard.KnightPiece{},
+		chessboard.KingPiece{}, chessboard.PawnPiece{Color: chessboard.White}, chessboard.PawnPiece{Color: chessboard.Black},
+	}
+	// the rows are bounded by n and the columns by m
+	squares := [][2]int{{-1, 0}, {0, -1}, {4, 0}, {0, 5}, {4, 5}}
+	for _, piece := range pieces {
+		for _, square := range squares {
+			if attacks, err := piece.Attacks(4, 5, square[0], square[1]); err == nil {
+				t.Errorf("%s Attacks(4, 5, %d, %d) = %v, want error", piece.Name(), square[0], square[1], attacks)
+			}
+			if _, err := chessboard.AttackBoard(piece, 4, 5, square[0], square[1]); err == nil {
+				t.Errorf("AttackBoard(%s, 4, 5, %d, %d) succeeded, want error", piece.Name(), square[0], square[1])
+			}
+		}
+	}
+	if _, err := chessboard.Pawn(4, 5, 1, 2, chessboard.Color(2)); err == nil {
+		t.Errorf("Pawn() with Color(2) succeeded, want error")
+	}
+}
+
+func TestColor(t *testing.T) {
+	if chessboard.White.Opponent() != chessboard.Black || chessboard.Black.Opponent() != chessboard.White {
+		t.Errorf("Opponent() does not swap the colors")
+	}
+	for color, want := range map[chessboard.Color]string{chessboard.White: "white", chessboard.Black: "black", 2: "Color(2)"} {
+		if got := color.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}